@@ -3,16 +3,19 @@ package errorstack
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 )
 
 /*
-Ensure *Error complies to Go error and json.Marshaler types.
+Ensure *Error complies to Go error, json.Marshaler, and json.Unmarshaler types.
 */
 var (
-	_ error          = (*Error)(nil)
-	_ json.Marshaler = (*Error)(nil)
+	_ error            = (*Error)(nil)
+	_ json.Marshaler   = (*Error)(nil)
+	_ json.Unmarshaler = (*Error)(nil)
 )
 
 /*
@@ -28,6 +31,7 @@ const (
 	CodeNotFound           = "NOT_FOUND"
 	CodeMethodNotAllowed   = "METHOD_NOT_ALLOWED"
 	CodeConflict           = "CONFLICT"
+	CodePreconditionFailed = "PRECONDITION_FAILED"
 	CodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
 	CodeTooManyRequests    = "TOO_MANY_REQUESTS"
 	CodeInternalError      = "INTERNAL_ERROR"
@@ -63,6 +67,33 @@ type Entry struct {
 	// Extensions carries machine-readable metadata. The "code" key is always
 	// set on entries produced by New, Validation, or Wrap.
 	Extensions map[string]any `json:"extensions,omitempty"`
+
+	// MessageID identifies the message in the locale catalog, so transports can
+	// localize it when writing the response. Message is kept as the English
+	// fallback when no translation exists for the client's language. Never
+	// serialized.
+	//
+	// Example:
+	//
+	//   "must_be_set"
+	MessageID string `json:"-"`
+
+	// Params holds the values substituted to the {name} placeholders of the
+	// localized message identified by MessageID. Never serialized.
+	//
+	// Example:
+	//
+	//   map[string]any{"min": 8}
+	Params map[string]any `json:"-"`
+
+	// Retryable tells whether the operation that failed may be retried. Nil when
+	// unknown, leaving the decision to the caller. Never serialized.
+	Retryable *bool `json:"-"`
+
+	// RetryAfter is the minimum delay to wait before retrying the operation that
+	// failed. Zero when unknown. Never serialized: the REST integration writes it
+	// as the Retry-After header.
+	RetryAfter time.Duration `json:"-"`
 }
 
 /*
@@ -88,6 +119,10 @@ type Error struct {
 	// cause is the wrapped underlying error preserved for errors.Is/As
 	// support. Never serialized.
 	cause error
+
+	// stack holds the program counters of the call stack captured by New and
+	// Wrap, when enabled. Never serialized.
+	stack []uintptr
 }
 
 /*
@@ -134,12 +169,62 @@ func WithExtension(key string, value any) EntryOption {
 	}
 }
 
+/*
+WithMessageID sets the message ID and template parameters of the entry. The
+REST and GraphQL integrations resolve the ID through the locale catalog when
+writing the response, substituting every {name} placeholder of the translation
+with the matching parameter. The message passed to New is kept as the English
+fallback. Example:
+
+	errorstack.New("Must be at least 8 characters long",
+	  errorstack.WithCode(errorstack.CodeBadRequest),
+	  errorstack.WithMessageID("min_length", map[string]any{"min": 8}),
+	)
+*/
+func WithMessageID(id string, params map[string]any) EntryOption {
+	return func(entry *Entry) {
+		entry.MessageID = id
+		entry.Params = params
+	}
+}
+
+/*
+WithRetryable sets whether the operation that failed may be retried. Errors
+without this option leave the decision to the caller, such as the retry policy
+of a Temporal activity.
+*/
+func WithRetryable(retryable bool) EntryOption {
+	return func(entry *Entry) {
+		entry.Retryable = &retryable
+	}
+}
+
+/*
+WithRetryAfter sets the minimum delay to wait before retrying the operation that
+failed, such as for CodeTooManyRequests or CodeServiceUnavailable. It implies
+the error is retryable unless WithRetryable(false) is set as well.
+*/
+func WithRetryAfter(d time.Duration) EntryOption {
+	return func(entry *Entry) {
+		entry.RetryAfter = d
+	}
+}
+
 /*
 New returns an Error containing a single Entry built from the message and
 options. The entry's extensions.code defaults to CodeInternalError unless
-WithCode is provided.
+WithCode is provided. The call stack is captured when enabled with
+EnableStackTraces.
 */
 func New(message string, opts ...EntryOption) *Error {
+	return newError(message, opts)
+}
+
+/*
+newError builds the single-entry Error returned by New and Wrap, capturing the
+call stack of their caller when enabled.
+*/
+func newError(message string, opts []EntryOption) *Error {
 	entry := Entry{
 		Message:    message,
 		Extensions: map[string]any{"code": CodeInternalError},
@@ -151,6 +236,7 @@ func New(message string, opts ...EntryOption) *Error {
 
 	return &Error{
 		Entries: []Entry{entry},
+		stack:   callers(2),
 	}
 }
 
@@ -207,7 +293,7 @@ func Wrap(cause error, message string, opts ...EntryOption) error {
 		return nil
 	}
 
-	err := New(message, opts...)
+	err := newError(message, opts)
 	err.cause = cause
 	return err
 }
@@ -233,6 +319,48 @@ func (err *Error) SetExtension(key string, value any) *Error {
 	return err
 }
 
+/*
+Retryable returns whether the operation that failed may be retried, and true as
+second value if it is known. An entry set with WithRetryable(false) makes the
+whole Error non-retryable. Otherwise, the Error is retryable if one of its
+entries is set with WithRetryable(true) or WithRetryAfter.
+*/
+func (err *Error) Retryable() (retryable bool, known bool) {
+	if err == nil {
+		return false, false
+	}
+
+	for _, entry := range err.Entries {
+		if entry.Retryable != nil && !*entry.Retryable {
+			return false, true
+		}
+
+		if (entry.Retryable != nil && *entry.Retryable) || entry.RetryAfter > 0 {
+			retryable, known = true, true
+		}
+	}
+
+	return retryable, known
+}
+
+/*
+RetryAfter returns the minimum delay to wait before retrying the operation that
+failed: the longest one set with WithRetryAfter across entries. Returns zero if
+none is set, or if the Error is not retryable.
+*/
+func (err *Error) RetryAfter() time.Duration {
+	if retryable, _ := err.Retryable(); !retryable {
+		return 0
+	}
+
+	var after time.Duration
+	for _, entry := range err.Entries {
+		after = max(after, entry.RetryAfter)
+	}
+
+	return after
+}
+
 /*
 Unwrap returns the wrapped cause for errors.Is/As support.
 */
@@ -340,6 +468,70 @@ func (err *Error) MarshalJSON() ([]byte, error) {
 	})
 }
 
+/*
+UnmarshalJSON decodes the response envelope produced by MarshalJSON, so an
+*Error returned by an upstream service can be restored:
+
+	{"errors":[…], "extensions":{…}}
+
+Entries keep their message, path, and extensions (including the code). Integer
+path segments are decoded as int, string segments as string. Any other member
+of the envelope is ignored, so the "errors" member of an RFC 9457 problem is
+decoded as well. Returns an error if the "errors" member is missing or empty.
+The cause and the stack trace of the original Error are not part of the
+envelope, and are therefore not restored.
+*/
+func (err *Error) UnmarshalJSON(data []byte) error {
+	var envelope struct {
+		Errors []struct {
+			Message    string            `json:"message"`
+			Path       []json.RawMessage `json:"path"`
+			Extensions map[string]any    `json:"extensions"`
+		} `json:"errors"`
+		Extensions map[string]any `json:"extensions"`
+	}
+
+	if e := json.Unmarshal(data, &envelope); e != nil {
+		return e
+	}
+
+	if len(envelope.Errors) == 0 {
+		return errors.New("errorstack: missing errors in envelope")
+	}
+
+	entries := make([]Entry, 0, len(envelope.Errors))
+	for _, raw := range envelope.Errors {
+		entry := Entry{
+			Message:    raw.Message,
+			Extensions: raw.Extensions,
+		}
+
+		for _, segment := range raw.Path {
+			var name string
+			if e := json.Unmarshal(segment, &name); e == nil {
+				entry.Path = append(entry.Path, name)
+				continue
+			}
+
+			var index int
+			if e := json.Unmarshal(segment, &index); e != nil {
+				return fmt.Errorf("errorstack: invalid path segment %s", segment)
+			}
+
+			entry.Path = append(entry.Path, index)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	*err = Error{
+		Entries:    entries,
+		Extensions: envelope.Extensions,
+	}
+
+	return nil
+}
+
 /*
 EntriesOf returns the errors[] entries representing err. If err is an *Error
 (directly or via the unwrap chain), its Entries are returned verbatim.
@@ -366,6 +558,72 @@ func EntriesOf(err error) []Entry {
 	}}
 }
 
+/*
+CodeOf returns the extensions.code of the first entry of the *Error found in
+err's unwrap chain. Returns CodeInternalError if err is not nil but carries no
+*Error or no code, and an empty string when err is nil. This allows checking
+errors returned by the integrations without depending on their drivers:
+
+	if errorstack.CodeOf(err) == errorstack.CodeNotFound {
+	  …
+	}
+*/
+func CodeOf(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var inner *Error
+	if errors.As(err, &inner) && inner != nil && len(inner.Entries) > 0 {
+		if code, ok := inner.Entries[0].Extensions["code"].(string); ok && code != "" {
+			return code
+		}
+	}
+
+	return CodeInternalError
+}
+
+/*
+CodeToHTTPStatus maps a canonical code to its HTTP status code. It is the
+inverse of HTTPStatusToCode, with CodeValidationFailed mapped to
+http.StatusBadRequest. Unknown codes fall back to
+http.StatusInternalServerError.
+*/
+func CodeToHTTPStatus(code string) int {
+	switch code {
+	case CodeBadRequest, CodeValidationFailed:
+		return http.StatusBadRequest
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodePaymentRequired:
+		return http.StatusPaymentRequired
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeMethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	case CodeConflict:
+		return http.StatusConflict
+	case CodePreconditionFailed:
+		return http.StatusPreconditionFailed
+	case CodePayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case CodeTooManyRequests:
+		return http.StatusTooManyRequests
+	case CodeNotImplemented:
+		return http.StatusNotImplemented
+	case CodeBadGateway:
+		return http.StatusBadGateway
+	case CodeServiceUnavailable:
+		return http.StatusServiceUnavailable
+	case CodeGatewayTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 /*
 HTTPStatusToCode maps an HTTP status code to its canonical
 SCREAMING_SNAKE_CASE code. Unknown statuses fall back to CodeInternalError.
@@ -386,6 +644,8 @@ func HTTPStatusToCode(status int) string {
 		return CodeMethodNotAllowed
 	case http.StatusConflict:
 		return CodeConflict
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
 	case http.StatusRequestEntityTooLarge:
 		return CodePayloadTooLarge
 	case http.StatusTooManyRequests:
@@ -3,8 +3,11 @@ package errorstack
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -55,6 +58,35 @@ func TestNew_CombinedOptions(t *testing.T) {
 	assert.Equal(t, "use a corporate domain", err.Entries[0].Extensions["hint"])
 }
 
+func TestNew_WithMessageID(t *testing.T) {
+	err := New("Must be at least 8 characters long",
+		WithCode(CodeBadRequest),
+		WithMessageID("min_length", map[string]any{"min": 8}),
+	)
+
+	require.Len(t, err.Entries, 1)
+	assert.Equal(t, "Must be at least 8 characters long", err.Entries[0].Message)
+	assert.Equal(t, "min_length", err.Entries[0].MessageID)
+	assert.Equal(t, map[string]any{"min": 8}, err.Entries[0].Params)
+	assert.Equal(t, CodeBadRequest, err.Entries[0].Extensions["code"])
+}
+
+func TestMarshalJSON_OmitsMessageID(t *testing.T) {
+	err := New("Must be set",
+		WithCode(CodeBadRequest),
+		WithMessageID("must_be_set", map[string]any{"field": "email"}),
+	)
+
+	b, marshalErr := json.Marshal(err)
+
+	require.NoError(t, marshalErr)
+	assert.JSONEq(t, `{
+		"errors": [
+			{"message": "Must be set", "extensions": {"code": "BAD_REQUEST"}}
+		]
+	}`, string(b))
+}
+
 func TestValidation_ForcesValidationFailedCode(t *testing.T) {
 	err := NewValidation(
 		Entry{Message: "Must be set", Path: []any{"config", "address"}},
@@ -310,6 +342,69 @@ func TestMarshalJSON_NormalizesMissingCode(t *testing.T) {
 	}`, string(b))
 }
 
+func TestUnmarshalJSON_RoundTrip(t *testing.T) {
+	original := NewValidation(
+		Entry{Message: "Must be set", Path: []any{"items", 0, "name"}},
+		Entry{Message: "Must be a valid email address", Path: []any{"email"}},
+	).SetExtension("request_id", "req_123")
+
+	b, err := json.Marshal(original)
+	require.NoError(t, err)
+
+	var decoded Error
+	require.NoError(t, json.Unmarshal(b, &decoded))
+
+	assert.Equal(t, original.Entries, decoded.Entries)
+	assert.Equal(t, original.Extensions, decoded.Extensions)
+	assert.Equal(t, CodeValidationFailed, CodeOf(&decoded))
+	assert.NoError(t, decoded.Unwrap())
+}
+
+func TestUnmarshalJSON_ProblemDetails(t *testing.T) {
+	var decoded Error
+	err := json.Unmarshal([]byte(`{
+		"type": "about:blank",
+		"title": "Not Found",
+		"status": 404,
+		"errors": [{"message": "Resource does not exist", "extensions": {"code": "NOT_FOUND"}}]
+	}`), &decoded)
+
+	require.NoError(t, err)
+	assert.Equal(t, CodeNotFound, CodeOf(&decoded))
+	assert.Nil(t, decoded.Extensions)
+}
+
+func TestUnmarshalJSON_Invalid(t *testing.T) {
+	testcases := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "not JSON",
+			data: `<html></html>`,
+		},
+		{
+			name: "missing errors",
+			data: `{"data": {}}`,
+		},
+		{
+			name: "empty errors",
+			data: `{"errors": []}`,
+		},
+		{
+			name: "invalid path segment",
+			data: `{"errors": [{"message": "Must be set", "path": [1.5]}]}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var decoded Error
+			assert.Error(t, json.Unmarshal([]byte(tc.data), &decoded))
+		})
+	}
+}
+
 func TestEntry_PathSupportsIntegerIndexes(t *testing.T) {
 	// Per Path doc: segments may be lower_snake_case strings or integer
 	// indexes (e.g. when a list element validation fails).
@@ -396,6 +491,7 @@ func TestHTTPStatusToCode_AllSupportedCodes(t *testing.T) {
 		{http.StatusNotFound, CodeNotFound},
 		{http.StatusMethodNotAllowed, CodeMethodNotAllowed},
 		{http.StatusConflict, CodeConflict},
+		{http.StatusPreconditionFailed, CodePreconditionFailed},
 		{http.StatusRequestEntityTooLarge, CodePayloadTooLarge},
 		{http.StatusTooManyRequests, CodeTooManyRequests},
 		{http.StatusInternalServerError, CodeInternalError},
@@ -415,3 +511,182 @@ func TestHTTPStatusToCode_AllSupportedCodes(t *testing.T) {
 func TestHTTPStatusToCode_UnknownReturnsInternalError(t *testing.T) {
 	assert.Equal(t, CodeInternalError, HTTPStatusToCode(418))
 }
+
+func TestCodeToHTTPStatus_RoundTrip(t *testing.T) {
+	for _, status := range []int{
+		http.StatusBadRequest,
+		http.StatusUnauthorized,
+		http.StatusPaymentRequired,
+		http.StatusForbidden,
+		http.StatusNotFound,
+		http.StatusMethodNotAllowed,
+		http.StatusConflict,
+		http.StatusPreconditionFailed,
+		http.StatusRequestEntityTooLarge,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusNotImplemented,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			assert.Equal(t, status, CodeToHTTPStatus(HTTPStatusToCode(status)))
+		})
+	}
+
+	assert.Equal(t, http.StatusBadRequest, CodeToHTTPStatus(CodeValidationFailed))
+	assert.Equal(t, http.StatusInternalServerError, CodeToHTTPStatus("UNKNOWN"))
+}
+
+func TestCodeOf(t *testing.T) {
+	cause := errors.New("no rows in result set")
+
+	testcases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "nil",
+			err:      nil,
+			expected: "",
+		},
+		{
+			name:     "plain error",
+			err:      cause,
+			expected: CodeInternalError,
+		},
+		{
+			name:     "errorstack error",
+			err:      Wrap(cause, "Resource does not exist", WithCode(CodeNotFound)),
+			expected: CodeNotFound,
+		},
+		{
+			name:     "wrapped errorstack error",
+			err:      fmt.Errorf("get user: %w", New("Resource already exists", WithCode(CodeConflict))),
+			expected: CodeConflict,
+		},
+		{
+			name:     "entry without code",
+			err:      &Error{Entries: []Entry{{Message: "Failed"}}},
+			expected: CodeInternalError,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, CodeOf(tc.err))
+		})
+	}
+}
+
+func TestRetryHints(t *testing.T) {
+	testcases := []struct {
+		name              string
+		err               *Error
+		expectedRetryable bool
+		expectedKnown     bool
+		expectedAfter     time.Duration
+	}{
+		{
+			name: "unknown",
+			err:  New("Failed to process"),
+		},
+		{
+			name:              "retryable",
+			err:               New("Failed to process", WithRetryable(true)),
+			expectedRetryable: true,
+			expectedKnown:     true,
+		},
+		{
+			name:          "non retryable",
+			err:           New("Card was declined", WithCode(CodePaymentRequired), WithRetryable(false)),
+			expectedKnown: true,
+		},
+		{
+			name:              "retry after implies retryable",
+			err:               New("Rate limit has been exceeded", WithCode(CodeTooManyRequests), WithRetryAfter(30*time.Second)),
+			expectedRetryable: true,
+			expectedKnown:     true,
+			expectedAfter:     30 * time.Second,
+		},
+		{
+			name:          "non retryable wins over retry after",
+			err:           New("Failed to process", WithRetryAfter(time.Second), WithRetryable(false)),
+			expectedKnown: true,
+		},
+		{
+			name: "longest delay across entries",
+			err: New("Failed to process", WithRetryAfter(time.Second)).Append(Entry{
+				Message:    "Failed to process",
+				RetryAfter: time.Minute,
+			}),
+			expectedRetryable: true,
+			expectedKnown:     true,
+			expectedAfter:     time.Minute,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			retryable, known := tc.err.Retryable()
+
+			assert.Equal(t, tc.expectedRetryable, retryable)
+			assert.Equal(t, tc.expectedKnown, known)
+			assert.Equal(t, tc.expectedAfter, tc.err.RetryAfter())
+		})
+	}
+}
+
+func TestRetryHints_NotSerialized(t *testing.T) {
+	b, err := json.Marshal(New("Rate limit has been exceeded", WithRetryable(true), WithRetryAfter(time.Second)))
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"errors":[{"message":"Rate limit has been exceeded","extensions":{"code":"INTERNAL_ERROR"}}]}`, string(b))
+}
+
+func TestStackTrace_DisabledByDefault(t *testing.T) {
+	assert.Empty(t, New("Failed to process").StackTrace())
+	assert.Empty(t, (*Error)(nil).StackTrace())
+}
+
+func TestStackTrace_Enabled(t *testing.T) {
+	EnableStackTraces(true)
+	t.Cleanup(func() { EnableStackTraces(false) })
+
+	testcases := []struct {
+		name string
+		err  *Error
+	}{
+		{
+			name: "New",
+			err:  New("Failed to process"),
+		},
+		{
+			name: "Wrap",
+			err:  Wrap(errors.New("boom"), "Failed to process").(*Error),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			trace := tc.err.StackTrace()
+
+			require.NotEmpty(t, trace)
+			assert.True(t, strings.HasPrefix(trace, "github.com/mountayaapp/helix.go/errorstack.TestStackTrace_Enabled\n\t"), trace)
+			assert.Contains(t, trace, "error_test.go:")
+			assert.NotContains(t, trace, "errorstack.newError")
+		})
+	}
+
+	t.Run("not serialized", func(t *testing.T) {
+		b, err := json.Marshal(New("Failed to process"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(b), "error_test.go")
+	})
+
+	t.Run("NewValidation", func(t *testing.T) {
+		assert.Empty(t, NewValidation(Entry{Message: "Must be set"}).StackTrace())
+	})
+}
@@ -20,6 +20,13 @@ CodeInternalError, override with WithCode). Use NewValidation to build a
 multi-entry error where every entry carries CodeValidationFailed. Use Wrap to
 preserve an existing error in the chain for errors.Is/As support.
 
+Error implements json.Unmarshaler as the inverse of MarshalJSON, so an envelope
+returned by another service of this ecosystem can be decoded back into an Error.
+
+New and Wrap capture the call stack of their caller when enabled with
+EnableStackTraces or the ERRORSTACK_STACKTRACE environment variable. It is
+exposed by StackTrace for telemetry, and never serialized.
+
 This package must not import any other package of this ecosystem.
 */
 package errorstack
@@ -0,0 +1,87 @@
+package errorstack
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+/*
+EnvStackTraces is the environment variable enabling the capture of stack traces
+when set to a true value (e.g. "true", "1") at startup.
+*/
+const EnvStackTraces = "ERRORSTACK_STACKTRACE"
+
+/*
+maxStackDepth is the maximum number of frames captured for a stack trace.
+*/
+const maxStackDepth = 32
+
+/*
+stackTraces reports whether New and Wrap capture the call stack. It is read on
+every error creation, hence atomic rather than guarded by a mutex.
+*/
+var stackTraces atomic.Bool
+
+func init() {
+	enabled, _ := strconv.ParseBool(os.Getenv(EnvStackTraces))
+	stackTraces.Store(enabled)
+}
+
+/*
+EnableStackTraces enables or disables the capture of the call stack by New and
+Wrap, overriding the value read from the ERRORSTACK_STACKTRACE environment
+variable. Capturing has a cost on every error created, so it is disabled by
+default.
+
+The stack trace is never serialized by MarshalJSON: it is only exposed by
+StackTrace, for telemetry (span exceptions and logs).
+*/
+func EnableStackTraces(enabled bool) {
+	stackTraces.Store(enabled)
+}
+
+/*
+callers returns the program counters of the call stack, skipping the skip
+frames above the caller of callers. Returns nil if stack traces are disabled.
+*/
+func callers(skip int) []uintptr {
+	if !stackTraces.Load() {
+		return nil
+	}
+
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	return pcs[:n]
+}
+
+/*
+StackTrace returns the call stack captured when the Error was created by New or
+Wrap, formatted as in Go panics: one function per line followed by its indented
+file and line. Returns an empty string if stack traces were disabled at the time.
+*/
+func (err *Error) StackTrace() string {
+	if err == nil || len(err.stack) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(err.stack)
+	for {
+		frame, more := frames.Next()
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		b.WriteByte('\n')
+
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
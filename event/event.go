@@ -45,6 +45,11 @@ type Event struct {
 	Referrer       Referrer          `json:"referrer,omitzero"         baggage:"referrer"`
 	Screen         Screen            `json:"screen,omitzero"           baggage:"screen"`
 	Subscriptions  []Subscription    `json:"subscriptions,omitempty"   baggage:"subscriptions"`
+
+	// extensions holds the typed extensions of the Event, keyed by the name they
+	// were registered with. It is accessed with SetExtension and ExtensionOf. See
+	// RegisterExtension.
+	extensions map[string]any
 }
 
 /*
@@ -0,0 +1,377 @@
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Errors returned when registering or setting an extension.
+*/
+var (
+	ErrExtensionName      = errors.New("extension name must only contain lowercase letters, digits, and underscores")
+	ErrExtensionType      = errors.New("extension type must be a struct")
+	ErrExtensionField     = errors.New("extension field type is not supported")
+	ErrExtensionCollision = errors.New("extension collides with a built-in field or another extension")
+	ErrExtensionNotFound  = errors.New("extension type is not registered")
+)
+
+/*
+extensionName matches the valid names of extensions. Dots and brackets are not
+allowed since they are the level separators of flat maps.
+*/
+var extensionName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+/*
+registry holds the extensions registered with RegisterExtension. Extensions are
+expected to be registered once at startup, but the registry is safe for
+concurrent use.
+*/
+var registry = struct {
+	sync.RWMutex
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}{
+	types: make(map[string]reflect.Type),
+	names: make(map[reflect.Type]string),
+}
+
+/*
+RegisterExtension registers T as a typed extension of Event, under name. T must
+be a struct whose fields have baggage tags, the same way the nested structs of
+Event do. Once registered, an extension set with SetExtension is serialized
+alongside the built-in fields of the Event:
+
+  - in JSON, at the name key of the Event object;
+  - in flat maps, Baggages, and span attributes, under the "event.<name>." prefix.
+
+It is therefore round-tripped by ToFlatMap / FromFlatMap, EventFromJSON, and
+every integration propagating an Event across services, as long as the services
+register the same extensions.
+
+Fields with a baggage tag must be exported, and of a type flat maps can hold:
+string, bool, int64, float64, *bool, time.Time, map[string]string, a nested
+struct, or a slice of nested structs. Other types, such as int or uint, would be
+silently dropped from the baggage, so they are rejected.
+
+Registering T again under the same name is a no-op, so packages and tests can
+register the extensions they rely on without coordinating. Returns an error if
+name is not valid, if T is not a struct or has a field of a type not supported,
+or if name or T are already used by a built-in field or another extension.
+Example:
+
+	type Order struct {
+	  ID       string `json:"id,omitempty"       baggage:"id"`
+	  Quantity int64  `json:"quantity,omitempty" baggage:"quantity"`
+	}
+
+	err := event.RegisterExtension[Order]("order")
+*/
+func RegisterExtension[T any](name string) error {
+	if !extensionName.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrExtensionName, name)
+	}
+
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %s", ErrExtensionType, t)
+	}
+
+	if err := checkExtensionFields(t, map[reflect.Type]bool{}); err != nil {
+		return err
+	}
+
+	if isBuiltInField(name) {
+		return fmt.Errorf("%w: %q", ErrExtensionCollision, name)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registered, exists := registry.types[name]; exists {
+		if registered == t {
+			return nil
+		}
+
+		return fmt.Errorf("%w: %q", ErrExtensionCollision, name)
+	}
+
+	if _, exists := registry.names[t]; exists {
+		return fmt.Errorf("%w: %s", ErrExtensionCollision, t)
+	}
+
+	registry.types[name] = t
+	registry.names[t] = name
+
+	return nil
+}
+
+/*
+SetExtension sets the extension v on the Event passed, replacing the previous one
+of the same type, if any. Returns an error if T has not been registered with
+RegisterExtension.
+*/
+func SetExtension[T any](e *Event, v T) error {
+	registry.RLock()
+	name, ok := registry.names[reflect.TypeFor[T]()]
+	registry.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrExtensionNotFound, reflect.TypeFor[T]())
+	}
+
+	// Clone the map so copies of the Event made before this call are left
+	// untouched.
+	extensions := maps.Clone(e.extensions)
+	if extensions == nil {
+		extensions = make(map[string]any)
+	}
+
+	extensions[name] = v
+	e.extensions = extensions
+
+	return nil
+}
+
+/*
+ExtensionOf returns the extension of type T set on the Event passed, if any.
+Returns true if the extension has been found, false otherwise.
+*/
+func ExtensionOf[T any](e Event) (T, bool) {
+	registry.RLock()
+	name, ok := registry.names[reflect.TypeFor[T]()]
+	registry.RUnlock()
+
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	v, ok := e.extensions[name].(T)
+	return v, ok
+}
+
+/*
+checkExtensionFields returns an error if a field of the struct t with a baggage
+tag, or of the structs nested in it, is not exported or has a type that flat
+maps can not hold, as supported by flattenValue and unflattenValue. seen holds
+the structs already checked, so recursive types are only checked once.
+*/
+func checkExtensionFields(t reflect.Type, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+
+	seen[t] = true
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("baggage")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		if !field.IsExported() {
+			return fmt.Errorf("%w: %s.%s is not exported", ErrExtensionField, t, field.Name)
+		}
+
+		ft := field.Type
+		switch ft.Kind() {
+		case reflect.String, reflect.Bool, reflect.Int64, reflect.Float64:
+			continue
+
+		case reflect.Ptr:
+			if ft.Elem().Kind() == reflect.Bool {
+				continue
+			}
+
+		case reflect.Struct:
+			if ft == reflect.TypeFor[time.Time]() {
+				continue
+			}
+
+			if err := checkExtensionFields(ft, seen); err != nil {
+				return err
+			}
+
+			continue
+
+		case reflect.Slice:
+			if ft.Elem().Kind() == reflect.Struct && ft.Elem() != reflect.TypeFor[time.Time]() {
+				if err := checkExtensionFields(ft.Elem(), seen); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+		case reflect.Map:
+			if ft.Key().Kind() == reflect.String && ft.Elem().Kind() == reflect.String {
+				continue
+			}
+		}
+
+		return fmt.Errorf("%w: %s.%s of type %s", ErrExtensionField, t, field.Name, ft)
+	}
+
+	return nil
+}
+
+/*
+isBuiltInField returns true if name is the json or baggage name of a built-in
+field of Event.
+*/
+func isBuiltInField(name string) bool {
+	t := reflect.TypeFor[Event]()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == jsonName || name == field.Tag.Get("baggage") || strings.EqualFold(name, field.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+registeredExtensions returns a snapshot of the registered extensions.
+*/
+func registeredExtensions() map[string]reflect.Type {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return maps.Clone(registry.types)
+}
+
+/*
+flattenExtensions writes the extensions of the Event passed into the flat map,
+under the "<prefix>.<name>" keys.
+*/
+func flattenExtensions(e Event, prefix string, m map[string]string) {
+	for name, v := range e.extensions {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Struct {
+			flattenValue(rv, prefix+"."+name, m)
+		}
+	}
+}
+
+/*
+unflattenExtensions sets on the Event passed the registered extensions found in
+the flat map. Extensions for which no key is found are not set.
+*/
+func unflattenExtensions(e *Event, prefix string, m map[string]string) {
+	for name, t := range registeredExtensions() {
+		key := prefix + "." + name + "."
+		found := false
+		for k := range m {
+			if strings.HasPrefix(k, key) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			continue
+		}
+
+		v := reflect.New(t).Elem()
+		unflattenValue(v, prefix+"."+name, m)
+
+		if e.extensions == nil {
+			e.extensions = make(map[string]any)
+		}
+
+		e.extensions[name] = v.Interface()
+	}
+}
+
+/*
+MarshalJSON encodes the Event as JSON, with its extensions set at their name key
+alongside the built-in fields.
+*/
+func (e Event) MarshalJSON() ([]byte, error) {
+	type builtin Event
+
+	b, err := json.Marshal(builtin(e))
+	if err != nil || len(e.extensions) == 0 {
+		return b, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(b[:len(b)-1])
+
+	names := slices.Sorted(maps.Keys(e.extensions))
+	for i, name := range names {
+		value, err := json.Marshal(e.extensions[name])
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 || len(b) > 2 {
+			buf.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+/*
+UnmarshalJSON decodes the Event from JSON, including the registered extensions
+found at their name key. Unknown keys are ignored, as for the built-in fields.
+*/
+func (e *Event) UnmarshalJSON(data []byte) error {
+	type builtin Event
+
+	var decoded builtin
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*e = Event(decoded)
+
+	extensions := registeredExtensions()
+	if len(extensions) == 0 {
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	for name, t := range extensions {
+		value, ok := raw[name]
+		if !ok {
+			continue
+		}
+
+		v := reflect.New(t)
+		if err := json.Unmarshal(value, v.Interface()); err != nil {
+			return err
+		}
+
+		if e.extensions == nil {
+			e.extensions = make(map[string]any)
+		}
+
+		e.extensions[name] = v.Elem().Interface()
+	}
+
+	return nil
+}
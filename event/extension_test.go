@@ -0,0 +1,233 @@
+package event
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOrder struct {
+	ID       string  `json:"id,omitempty"       baggage:"id"`
+	Quantity int64   `json:"quantity,omitempty" baggage:"quantity"`
+	Amount   float64 `json:"amount,omitempty"   baggage:"amount"`
+	Gift     bool    `json:"gift,omitempty"     baggage:"gift"`
+}
+
+type testExperiment struct {
+	Variant string `json:"variant,omitempty" baggage:"variant"`
+}
+
+type testUnregistered struct {
+	Value string `json:"value,omitempty" baggage:"value"`
+}
+
+/*
+TestMain registers the extensions once for the whole package, since the registry
+cannot be reset. Tests registering extensions of their own must use distinct
+names and types.
+*/
+func TestMain(m *testing.M) {
+	if err := RegisterExtension[testOrder]("order"); err != nil {
+		panic(err)
+	}
+
+	if err := RegisterExtension[testExperiment]("experiment"); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestRegisterExtension_Errors(t *testing.T) {
+	type notRegistered struct {
+		Value string `json:"value,omitempty" baggage:"value"`
+	}
+
+	type withInt struct {
+		Quantity int `json:"quantity,omitempty" baggage:"quantity"`
+	}
+
+	type withUnexported struct {
+		value string `baggage:"value"`
+	}
+
+	type withNestedUint struct {
+		Lines []struct {
+			Quantity uint `json:"quantity,omitempty" baggage:"quantity"`
+		} `json:"lines,omitempty" baggage:"lines"`
+	}
+
+	testcases := []struct {
+		name     string
+		register func() error
+		err      error
+	}{
+		{
+			name:     "invalid name",
+			register: func() error { return RegisterExtension[notRegistered]("not.valid") },
+			err:      ErrExtensionName,
+		},
+		{
+			name:     "empty name",
+			register: func() error { return RegisterExtension[notRegistered]("") },
+			err:      ErrExtensionName,
+		},
+		{
+			name:     "not a struct",
+			register: func() error { return RegisterExtension[string]("quota") },
+			err:      ErrExtensionType,
+		},
+		{
+			name:     "field of a type not supported",
+			register: func() error { return RegisterExtension[withInt]("with_int") },
+			err:      ErrExtensionField,
+		},
+		{
+			name:     "field not exported",
+			register: func() error { return RegisterExtension[withUnexported]("with_unexported") },
+			err:      ErrExtensionField,
+		},
+		{
+			name:     "nested field of a type not supported",
+			register: func() error { return RegisterExtension[withNestedUint]("with_nested_uint") },
+			err:      ErrExtensionField,
+		},
+		{
+			name:     "collision with a built-in json name",
+			register: func() error { return RegisterExtension[notRegistered]("user_id") },
+			err:      ErrExtensionCollision,
+		},
+		{
+			name:     "collision with a built-in nested struct",
+			register: func() error { return RegisterExtension[notRegistered]("app") },
+			err:      ErrExtensionCollision,
+		},
+		{
+			name:     "collision with another extension name",
+			register: func() error { return RegisterExtension[notRegistered]("order") },
+			err:      ErrExtensionCollision,
+		},
+		{
+			name:     "type already registered under another name",
+			register: func() error { return RegisterExtension[testOrder]("purchase") },
+			err:      ErrExtensionCollision,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, tc.register(), tc.err)
+		})
+	}
+}
+
+func TestRegisterExtension_SameNameAndType(t *testing.T) {
+	require.NoError(t, RegisterExtension[testOrder]("order"))
+
+	var e Event
+	require.NoError(t, SetExtension(&e, testOrder{ID: "ord_123"}))
+
+	order, ok := ExtensionOf[testOrder](e)
+	assert.True(t, ok)
+	assert.Equal(t, "ord_123", order.ID)
+}
+
+func TestSetExtension(t *testing.T) {
+	t.Run("sets and returns the extension", func(t *testing.T) {
+		var e Event
+		require.NoError(t, SetExtension(&e, testOrder{ID: "ord_123"}))
+
+		order, ok := ExtensionOf[testOrder](e)
+		assert.True(t, ok)
+		assert.Equal(t, testOrder{ID: "ord_123"}, order)
+
+		_, ok = ExtensionOf[testExperiment](e)
+		assert.False(t, ok)
+	})
+
+	t.Run("leaves copies untouched", func(t *testing.T) {
+		var e Event
+		require.NoError(t, SetExtension(&e, testOrder{ID: "ord_123"}))
+
+		previous := e
+		require.NoError(t, SetExtension(&e, testOrder{ID: "ord_456"}))
+
+		order, _ := ExtensionOf[testOrder](previous)
+		assert.Equal(t, "ord_123", order.ID)
+	})
+
+	t.Run("unregistered type returns error", func(t *testing.T) {
+		var e Event
+		assert.ErrorIs(t, SetExtension(&e, testUnregistered{Value: "x"}), ErrExtensionNotFound)
+		assert.Nil(t, e.extensions)
+
+		_, ok := ExtensionOf[testUnregistered](e)
+		assert.False(t, ok)
+	})
+}
+
+func TestExtension_FlatMapRoundTrip(t *testing.T) {
+	e := Event{Name: "checkout"}
+	require.NoError(t, SetExtension(&e, testOrder{ID: "ord_123", Quantity: 3, Amount: 9.99, Gift: true}))
+	require.NoError(t, SetExtension(&e, testExperiment{Variant: "blue"}))
+
+	m := ToFlatMap(e)
+	assert.Equal(t, map[string]string{
+		"event.name":               "checkout",
+		"event.order.id":           "ord_123",
+		"event.order.quantity":     "3",
+		"event.order.amount":       "9.99",
+		"event.order.gift":         "true",
+		"event.experiment.variant": "blue",
+	}, m)
+
+	assert.Equal(t, e, FromFlatMap(m))
+}
+
+func TestExtension_FromFlatMapWithoutExtensions(t *testing.T) {
+	e := FromFlatMap(map[string]string{"event.name": "checkout"})
+
+	assert.Nil(t, e.extensions)
+}
+
+func TestExtension_JSONRoundTrip(t *testing.T) {
+	e := Event{Name: "checkout"}
+	require.NoError(t, SetExtension(&e, testOrder{ID: "ord_123", Quantity: 3}))
+
+	b, err := json.Marshal(e)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"checkout","order":{"id":"ord_123","quantity":3}}`, string(b))
+
+	var decoded Event
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, e, decoded)
+}
+
+func TestExtension_JSONOnlyExtensions(t *testing.T) {
+	var e Event
+	require.NoError(t, SetExtension(&e, testExperiment{Variant: "blue"}))
+
+	b, err := json.Marshal(e)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"experiment":{"variant":"blue"}}`, string(b))
+}
+
+func TestExtension_EventFromJSON(t *testing.T) {
+	e, ok := EventFromJSON(json.RawMessage(`{"event":{"name":"checkout","experiment":{"variant":"blue"},"unknown":{"a":1}}}`))
+	require.True(t, ok)
+
+	experiment, ok := ExtensionOf[testExperiment](e)
+	assert.True(t, ok)
+	assert.Equal(t, "blue", experiment.Variant)
+	assert.Len(t, e.extensions, 1)
+}
+
+func TestExtension_JSONInvalidExtension(t *testing.T) {
+	var e Event
+	err := json.Unmarshal([]byte(`{"name":"checkout","order":"not-an-object"}`), &e)
+
+	assert.Error(t, err)
+}
@@ -38,6 +38,7 @@ Will produce:
 func ToFlatMap(e Event) map[string]string {
 	m := make(map[string]string)
 	flattenValue(reflect.ValueOf(e), "event", m)
+	flattenExtensions(e, "event", m)
 	return m
 }
 
@@ -48,6 +49,7 @@ Keys must be prefixed with "event." and use "." as the level separator.
 func FromFlatMap(m map[string]string) Event {
 	var e Event
 	unflattenValue(reflect.ValueOf(&e).Elem(), "event", m)
+	unflattenExtensions(&e, "event", m)
 	return e
 }
 
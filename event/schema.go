@@ -0,0 +1,312 @@
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+/*
+schemaDraft is the JSON Schema dialect of the schema returned by JSONSchema.
+*/
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+/*
+schemaRequired is the list of properties an Event must have. An Event without a
+name is not considered found when restored from a Baggage.
+*/
+var schemaRequired = []string{"name"}
+
+/*
+schemaDescriptions holds the description of the properties of the schema, keyed
+by their dot-separated JSON path. Array items are not part of the path.
+*/
+var schemaDescriptions = map[string]string{
+	"id":              "Unique identifier of the event.",
+	"name":            "Name of the event that a client (user, device) has performed.",
+	"meta":            "Free key-value store to provide additional metadata about the event.",
+	"is_anonymous":    "Informs if the client triggering the event is related to an anonymous user.",
+	"user_id":         "Unique identifier of the user triggering the event.",
+	"organization_id": "Unique identifier of the organization triggering the event.",
+	"tenant_id":       "Unique identifier of the tenant triggering the event.",
+	"ip":              "IP address of the current user.",
+	"user_agent":      "User agent of the device making the request.",
+	"locale":          "Locale of the current user.",
+	"timezone":        "User timezone information which might be stripped from the timestamp.",
+	"timestamp":       "Timestamp when the event itself actually happened.",
+	"app":             "Holds the details about the client application executing the event.",
+	"campaign":        "Holds the details about the marketing campaign from which a client is executing the event from.",
+	"device":          "Holds the details about the user's device.",
+	"location":        "Holds the details about the user's location.",
+	"network":         "Holds the details about the user's network.",
+	"os":              "Holds the details about the user's OS.",
+	"page":            "Holds the details about the webpage from which the event is triggered from.",
+	"referrer":        "Holds the details about the marketing referrer from which a client is executing the event from.",
+	"screen":          "Holds the details about the app's screen from which the event is triggered from.",
+	"subscriptions":   "Holds the details about the account/customer subscriptions from which the event has been triggered. It's useful for tracking customer usages.",
+}
+
+/*
+schemaExamples holds the example of the properties of the schema, keyed by their
+dot-separated JSON path. Array items are not part of the path.
+*/
+var schemaExamples = map[string]any{
+	"id":                         "ef7af3f9-77b1-4455-9994-ddefe909de41",
+	"name":                       "user.registered",
+	"meta":                       map[string]any{"key": "value"},
+	"is_anonymous":               false,
+	"user_id":                    "d50825a9-0feb-4e63-9b16-3ec6fe76e0e6",
+	"organization_id":            "60d58518-78b3-4739-afa5-71e2dab2b91f",
+	"tenant_id":                  "34a1860a-8aac-403b-a8f9-74dffeb1be71",
+	"ip":                         "85.88.182.121",
+	"user_agent":                 "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) ...",
+	"locale":                     "fr-FR",
+	"timezone":                   "Europe/Paris",
+	"timestamp":                  "2023-08-24T14:15:22Z",
+	"app.name":                   "demo-app",
+	"app.version":                "2.3.1",
+	"app.build_id":               "2.3.1-abcde",
+	"campaign.name":              "Hello World Campaign",
+	"campaign.source":            "newsletter",
+	"campaign.medium":            "email",
+	"campaign.term":              "button downloads",
+	"campaign.content":           "Hello World",
+	"device.id":                  "e51d218b-6ca6-4e36-9c21-11a649301a52",
+	"device.manufacturer":        "Apple",
+	"device.model":               "iPhone",
+	"device.name":                "customname",
+	"device.type":                "mobile",
+	"device.version":             "14",
+	"device.advertising_id":      "8d391abe-8a26-439d-9d37-b8cd5b9f91a5",
+	"location.city":              "Annecy",
+	"location.country":           "France",
+	"location.region":            "Auvergne-Rhône-Alpes",
+	"location.latitude":          45.899247,
+	"location.longitude":         6.129384,
+	"location.speed":             35,
+	"network.bluetooth":          false,
+	"network.cellular":           false,
+	"network.wifi":               true,
+	"network.carrier":            "Orange FR",
+	"os.name":                    "macOS",
+	"os.arch":                    "arm64",
+	"os.version":                 "13.3",
+	"page.path":                  "/contact",
+	"page.referrer":              "https://example.org",
+	"page.search":                "?q=custom+query",
+	"page.title":                 "Contact - Hello World",
+	"page.url":                   "https://hello.world/contact?q=custom+query",
+	"referrer.type":              "seo",
+	"referrer.name":              "Example",
+	"referrer.url":               "https://example.com",
+	"referrer.link":              "https://hello.world/link",
+	"screen.density":             2,
+	"screen.width":               320,
+	"screen.height":              568,
+	"subscriptions.id":           "sub_2NqIQtvdSEyPnDJl09eZGCajDDj",
+	"subscriptions.tenant_id":    "34a1860a-8aac-403b-a8f9-74dffeb1be71",
+	"subscriptions.customer_id":  "cus_2NqITqoYLIGSR7Ac06Sd6saihUm",
+	"subscriptions.product_id":   "prod_2NqITpRSxizBwnfWY7Rnk9uPrOu",
+	"subscriptions.price_id":     "price_2NqITpRSxizBwnfWY7Rnk9uPrOu",
+	"subscriptions.usage":        "demo.request",
+	"subscriptions.increment_by": 1,
+	"subscriptions.metadata":     map[string]any{"version": "blue"},
+}
+
+/*
+schema is a JSON Schema, limited to the keywords needed to describe an Event.
+Properties are kept in the order of the struct fields they are built from.
+*/
+type schema struct {
+	Type                 string
+	Format               string
+	Description          string
+	Properties           []property
+	Items                *schema
+	AdditionalProperties *schema
+	Required             []string
+	Example              any
+}
+
+/*
+property is a named property of an object schema.
+*/
+type property struct {
+	Name   string
+	Schema *schema
+}
+
+/*
+JSONSchema returns the JSON Schema (draft 2020-12) of an Event. It is generated
+by reflection from the struct tags of Event and its nested structs: a field is a
+property of the schema if — and only if — it has both a json and a baggage tag,
+and is named after its json tag. This is the schema the OpenAPI component
+documents/openapi/components/schemas/Event.yaml is generated from.
+*/
+func JSONSchema() json.RawMessage {
+	var buf bytes.Buffer
+	buf.WriteString(`{"$schema":`)
+	writeJSON(&buf, schemaDraft)
+	buf.WriteString(`,"title":"Event",`)
+
+	s := eventSchema()
+	s.writeJSONFields(&buf)
+	buf.WriteByte('}')
+
+	return buf.Bytes()
+}
+
+/*
+eventSchema returns the schema of an Event, with descriptions, examples, and
+required properties.
+*/
+func eventSchema() *schema {
+	s := schemaOf(reflect.TypeFor[Event](), "")
+	s.Required = schemaRequired
+
+	return s
+}
+
+/*
+schemaOf returns the schema of a Go type. path is the dot-separated JSON path of
+the property holding a value of this type, used to look up its description and
+example.
+*/
+func schemaOf(t reflect.Type, path string) *schema {
+	s := &schema{
+		Description: schemaDescriptions[path],
+		Example:     schemaExamples[path],
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == reflect.TypeFor[time.Time]():
+		s.Type = "string"
+		s.Format = "date-time"
+
+	case t.Kind() == reflect.String:
+		s.Type = "string"
+
+	case t.Kind() == reflect.Bool:
+		s.Type = "boolean"
+
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		s.Type = "integer"
+
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s.Type = "number"
+
+	case t.Kind() == reflect.Map:
+		s.Type = "object"
+		s.AdditionalProperties = schemaOf(t.Elem(), "")
+
+	case t.Kind() == reflect.Slice:
+		s.Type = "array"
+		s.Items = schemaOf(t.Elem(), path)
+		s.Items.Description = ""
+		s.Items.Example = nil
+
+	case t.Kind() == reflect.Struct:
+		s.Type = "object"
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+
+			if tag := field.Tag.Get("baggage"); tag == "" || tag == "-" {
+				continue
+			}
+
+			s.Properties = append(s.Properties, property{
+				Name:   name,
+				Schema: schemaOf(field.Type, joinPath(path, name)),
+			})
+		}
+	}
+
+	return s
+}
+
+/*
+joinPath appends name to the dot-separated path passed.
+*/
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+/*
+writeJSONFields writes the keywords of the schema as JSON object members, without
+the surrounding braces.
+*/
+func (s *schema) writeJSONFields(buf *bytes.Buffer) {
+	buf.WriteString(`"type":`)
+	writeJSON(buf, s.Type)
+
+	if s.Format != "" {
+		buf.WriteString(`,"format":`)
+		writeJSON(buf, s.Format)
+	}
+
+	if s.Description != "" {
+		buf.WriteString(`,"description":`)
+		writeJSON(buf, s.Description)
+	}
+
+	if len(s.Properties) > 0 {
+		buf.WriteString(`,"properties":{`)
+		for i, p := range s.Properties {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			writeJSON(buf, p.Name)
+			buf.WriteString(`:{`)
+			p.Schema.writeJSONFields(buf)
+			buf.WriteByte('}')
+		}
+
+		buf.WriteByte('}')
+	}
+
+	if s.Items != nil {
+		buf.WriteString(`,"items":{`)
+		s.Items.writeJSONFields(buf)
+		buf.WriteByte('}')
+	}
+
+	if s.AdditionalProperties != nil {
+		buf.WriteString(`,"additionalProperties":{`)
+		s.AdditionalProperties.writeJSONFields(buf)
+		buf.WriteByte('}')
+	}
+
+	if len(s.Required) > 0 {
+		buf.WriteString(`,"required":`)
+		writeJSON(buf, s.Required)
+	}
+
+	if s.Example != nil {
+		buf.WriteString(`,"example":`)
+		writeJSON(buf, s.Example)
+	}
+}
+
+/*
+writeJSON writes the JSON encoding of v. Values written are strings, numbers,
+booleans, and maps and slices of them, which cannot fail to be encoded.
+*/
+func writeJSON(buf *bytes.Buffer, v any) {
+	b, _ := json.Marshal(v)
+	buf.Write(b)
+}
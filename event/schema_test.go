@@ -0,0 +1,188 @@
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "write the OpenAPI component of the Event generated from JSONSchema")
+
+/*
+openAPIComponentPath is the path of the checked-in OpenAPI component of the Event,
+relative to this package.
+*/
+var openAPIComponentPath = filepath.Join("..", "documents", "openapi", "components", "schemas", "Event.yaml")
+
+/*
+TestJSONSchema_OpenAPIComponentUpToDate fails when the checked-in OpenAPI
+component drifts from the schema generated from the Event struct. Regenerate it
+with:
+
+	go test ./event -run TestJSONSchema_OpenAPIComponentUpToDate -update
+*/
+func TestJSONSchema_OpenAPIComponentUpToDate(t *testing.T) {
+	generated := openAPIComponent(t)
+	if *update {
+		require.NoError(t, os.WriteFile(openAPIComponentPath, generated, 0o644))
+	}
+
+	checkedIn, err := os.ReadFile(openAPIComponentPath)
+	require.NoError(t, err)
+
+	var expected, actual any
+	require.NoError(t, yaml.Unmarshal(generated, &expected))
+	require.NoError(t, yaml.Unmarshal(checkedIn, &actual))
+
+	assert.Equal(t, expected, actual,
+		"Event.yaml is out of date, regenerate it with: go test ./event -run TestJSONSchema_OpenAPIComponentUpToDate -update")
+}
+
+func TestJSONSchema(t *testing.T) {
+	var s map[string]any
+	require.NoError(t, json.Unmarshal(JSONSchema(), &s))
+
+	assert.Equal(t, schemaDraft, s["$schema"])
+	assert.Equal(t, "Event", s["title"])
+	assert.Equal(t, "object", s["type"])
+	assert.Equal(t, []any{"name"}, s["required"])
+
+	properties := s["properties"].(map[string]any)
+	assert.Len(t, properties, 22)
+
+	assert.Equal(t, map[string]any{
+		"type":        "string",
+		"format":      "date-time",
+		"description": "Timestamp when the event itself actually happened.",
+		"example":     "2023-08-24T14:15:22Z",
+	}, properties["timestamp"])
+
+	assert.Equal(t, "boolean", properties["is_anonymous"].(map[string]any)["type"])
+	assert.Equal(t, map[string]any{"type": "string"}, properties["meta"].(map[string]any)["additionalProperties"])
+
+	screen := properties["screen"].(map[string]any)["properties"].(map[string]any)
+	assert.Equal(t, "integer", screen["width"].(map[string]any)["type"])
+
+	location := properties["location"].(map[string]any)["properties"].(map[string]any)
+	assert.Equal(t, "number", location["latitude"].(map[string]any)["type"])
+
+	subscriptions := properties["subscriptions"].(map[string]any)
+	assert.Equal(t, "array", subscriptions["type"])
+	items := subscriptions["items"].(map[string]any)
+	assert.Equal(t, "object", items["type"])
+	assert.Contains(t, items["properties"], "customer_id")
+	assert.NotContains(t, items, "description")
+}
+
+func TestJSONSchema_PropertiesFollowStructFields(t *testing.T) {
+	s := eventSchema()
+
+	names := make([]string, 0, len(s.Properties))
+	for _, p := range s.Properties {
+		names = append(names, p.Name)
+	}
+
+	assert.Equal(t, []string{
+		"id", "name", "meta", "is_anonymous", "user_id", "organization_id",
+		"tenant_id", "ip", "user_agent", "locale", "timezone", "timestamp", "app",
+		"campaign", "device", "location", "network", "os", "page", "referrer",
+		"screen", "subscriptions",
+	}, names)
+}
+
+func TestJSONSchema_AnnotationsMatchProperties(t *testing.T) {
+	paths := schemaPaths(eventSchema(), "")
+
+	for path := range schemaDescriptions {
+		assert.Contains(t, paths, path, "description set for unknown property %q", path)
+	}
+
+	for path := range schemaExamples {
+		assert.Contains(t, paths, path, "example set for unknown property %q", path)
+	}
+
+	for _, path := range paths {
+		if _, ok := schemaDescriptions[path]; !strings.Contains(path, ".") && !ok {
+			t.Errorf("missing description for property %q", path)
+		}
+	}
+}
+
+/*
+schemaPaths returns the dot-separated JSON paths of all the properties of s.
+*/
+func schemaPaths(s *schema, prefix string) []string {
+	var paths []string
+	for _, p := range s.Properties {
+		path := joinPath(prefix, p.Name)
+		paths = append(paths, path)
+
+		nested := p.Schema
+		if nested.Items != nil {
+			nested = nested.Items
+		}
+
+		paths = append(paths, schemaPaths(nested, path)...)
+	}
+
+	return paths
+}
+
+/*
+openAPIComponent returns the OpenAPI component of the Event, as YAML, generated
+from the schema returned by JSONSchema. The schema is decoded from its ordered
+JSON so properties keep the order of the Event struct fields.
+*/
+func openAPIComponent(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	eventSchema().writeJSONFields(&buf)
+	buf.WriteByte('}')
+
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &doc))
+
+	var extensions yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+x-internal: true
+x-go-type: event.Event
+x-go-type-import:
+  path: github.com/mountayaapp/helix.go/event
+`), &extensions))
+
+	// Insert the extensions right after the type of the component, and drop the
+	// flow style inherited from JSON so the component is written as block YAML.
+	component := doc.Content[0]
+	component.Content = slices.Insert(component.Content, 2, extensions.Content[0].Content...)
+	clearYAMLStyle(component)
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	require.NoError(t, enc.Encode(component))
+	require.NoError(t, enc.Close())
+
+	return out.Bytes()
+}
+
+/*
+clearYAMLStyle resets the style of node and its descendants, letting the encoder
+pick the one of each value.
+*/
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
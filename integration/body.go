@@ -0,0 +1,179 @@
+package integration
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+/*
+LimitRequestBody returns next wrapped so the body of every request it serves is
+limited to limit bytes, for the HTTP server integrations (REST, GraphQL, MCP).
+A request is rejected by calling reject, which shall write a 413:
+
+  - before calling next, when its Content-Length is greater than limit;
+  - when its body exceeds limit while read by next, such as a chunked body
+    without Content-Length. Reading fails with an *http.MaxBytesError, and the
+    response next writes in reaction — typically a 400 or a 500 — is replaced
+    by the one written by reject, along with the headers next has set.
+
+A response already written by next before the limit is exceeded can not be
+replaced, and is left as is.
+
+Returns next as is when limit is not positive, so it costs nothing per request.
+*/
+func LimitRequestBody(next http.Handler, limit int64, reject http.HandlerFunc) http.Handler {
+	if limit <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.ContentLength > limit {
+			reject(rw, req)
+			return
+		}
+
+		if req.Body == nil || req.Body == http.NoBody {
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		body := &limitedBody{
+			ReadCloser: http.MaxBytesReader(rw, req.Body, limit),
+		}
+
+		r := new(http.Request)
+		*r = *req
+		r.Body = body
+
+		lw := &limitedWriter{
+			ResponseWriter: rw,
+			req:            r,
+			body:           body,
+			reject:         reject,
+			header:         rw.Header().Clone(),
+		}
+
+		next.ServeHTTP(lw, r)
+	})
+}
+
+/*
+limitedBody is a request body limited by http.MaxBytesReader, reporting if the
+limit has been exceeded while read.
+*/
+type limitedBody struct {
+	io.ReadCloser
+
+	// exceeded reports whether reading the body failed because it exceeds the
+	// limit. The body can be read from another goroutine than the handler's.
+	exceeded atomic.Bool
+}
+
+/*
+Read reads from the body, and flags it when it exceeds the limit.
+*/
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	var maxErr *http.MaxBytesError
+	if err != nil && errors.As(err, &maxErr) {
+		b.exceeded.Store(true)
+	}
+
+	return n, err
+}
+
+/*
+limitedWriter is an http.ResponseWriter replacing the response written by the
+handler with the one written by reject, when the request body has exceeded the
+limit before the handler started writing.
+*/
+type limitedWriter struct {
+	http.ResponseWriter
+
+	// req is the request served, passed to reject.
+	req *http.Request
+
+	// body is the limited body of the request.
+	body *limitedBody
+
+	// reject writes the response replacing the handler's one.
+	reject http.HandlerFunc
+
+	// header is a copy of the headers set before calling the handler, such as the
+	// CORS ones set by outer middleware. They are restored before reject is called.
+	header http.Header
+
+	// wrote reports whether the handler started writing its response, and
+	// rejected whether it has been replaced with the one written by reject.
+	wrote    bool
+	rejected bool
+}
+
+/*
+Unwrap returns the underlying http.ResponseWriter so http.ResponseController can
+reach optional interfaces such as http.Flusher, enabling incremental streaming.
+*/
+func (lw *limitedWriter) Unwrap() http.ResponseWriter {
+	return lw.ResponseWriter
+}
+
+/*
+intercept reports whether the handler's response is replaced. The response is
+replaced on the first write if the body has exceeded the limit by then.
+*/
+func (lw *limitedWriter) intercept() bool {
+	if lw.rejected {
+		return true
+	}
+
+	if lw.wrote || !lw.body.exceeded.Load() {
+		lw.wrote = true
+		return false
+	}
+
+	lw.rejected = true
+	resetHeader(lw.Header(), lw.header)
+
+	lw.reject(lw.ResponseWriter, lw.req)
+	return true
+}
+
+/*
+WriteHeader sends an HTTP response header with the provided status code, unless
+the response is replaced.
+*/
+func (lw *limitedWriter) WriteHeader(status int) {
+	if lw.intercept() {
+		return
+	}
+
+	lw.ResponseWriter.WriteHeader(status)
+}
+
+/*
+Write writes the data to the connection as part of an HTTP reply, unless the
+response is replaced. The data is then discarded.
+*/
+func (lw *limitedWriter) Write(b []byte) (int, error) {
+	if lw.intercept() {
+		return len(b), nil
+	}
+
+	return lw.ResponseWriter.Write(b)
+}
+
+/*
+Flush flushes the data written to the client, unless the response is replaced.
+It is implemented for handlers asserting http.Flusher, such as gqlgen's SSE
+transport.
+*/
+func (lw *limitedWriter) Flush() {
+	if lw.intercept() {
+		return
+	}
+
+	http.NewResponseController(lw.ResponseWriter).Flush()
+}
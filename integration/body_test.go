@@ -0,0 +1,162 @@
+package integration
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rejectTooLarge writes the 413 used to reject requests in tests.
+func rejectTooLarge(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusRequestEntityTooLarge)
+	rw.Write([]byte(`{"errors":[{"message":"Payload exceeds size limit","extensions":{"code":"PAYLOAD_TOO_LARGE"}}]}`))
+}
+
+// echoBody writes back the body of the request, or a 400 if it can not be read.
+func echoBody(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("X-Custom", "value")
+
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(rw, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	rw.Write(b)
+}
+
+// chunked hides the length of r, so the request built with it is sent with a
+// chunked body and an unknown Content-Length.
+type chunked struct {
+	io.Reader
+}
+
+func TestLimitRequestBody(t *testing.T) {
+	testcases := []struct {
+		name           string
+		limit          int64
+		body           io.Reader
+		expectedCalled bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "no limit",
+			limit:          0,
+			body:           strings.NewReader(strings.Repeat("a", 100)),
+			expectedCalled: true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   strings.Repeat("a", 100),
+		},
+		{
+			name:           "within limit",
+			limit:          10,
+			body:           strings.NewReader("0123456789"),
+			expectedCalled: true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "0123456789",
+		},
+		{
+			name:           "without body",
+			limit:          10,
+			expectedCalled: true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Content-Length exceeds limit",
+			limit:          10,
+			body:           strings.NewReader("0123456789a"),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "chunked body within limit",
+			limit:          10,
+			body:           chunked{strings.NewReader("0123456789")},
+			expectedCalled: true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "0123456789",
+		},
+		{
+			name:           "chunked body exceeds limit mid-read",
+			limit:          10,
+			body:           chunked{strings.NewReader("0123456789a")},
+			expectedCalled: true,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var called bool
+			h := LimitRequestBody(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				called = true
+				echoBody(rw, req)
+			}), tc.limit, rejectTooLarge)
+
+			req := httptest.NewRequest(http.MethodPost, "/", tc.body)
+			req.Header.Set("Origin", "https://app.example.com")
+			rw := httptest.NewRecorder()
+			rw.Header().Set("Access-Control-Allow-Origin", "https://app.example.com")
+			h.ServeHTTP(rw, req)
+
+			assert.Equal(t, tc.expectedCalled, called)
+			assert.Equal(t, tc.expectedStatus, rw.Code)
+			assert.Equal(t, "https://app.example.com", rw.Header().Get("Access-Control-Allow-Origin"))
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, tc.expectedBody, rw.Body.String())
+				return
+			}
+
+			// Headers set by the handler are discarded along with its response, but
+			// the ones set before calling it are kept.
+			assert.Empty(t, rw.Header().Get("X-Custom"))
+			assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+			assert.JSONEq(t, `{"errors":[{"message":"Payload exceeds size limit","extensions":{"code":"PAYLOAD_TOO_LARGE"}}]}`, rw.Body.String())
+		})
+	}
+}
+
+func TestLimitRequestBody_WrittenBeforeExceeded(t *testing.T) {
+	h := LimitRequestBody(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+
+		_, err := io.ReadAll(req.Body)
+		var maxErr *http.MaxBytesError
+		require.True(t, errors.As(err, &maxErr))
+		assert.Equal(t, int64(10), maxErr.Limit)
+
+		rw.Write([]byte("accepted"))
+	}), 10, rejectTooLarge)
+
+	req := httptest.NewRequest(http.MethodPost, "/", chunked{strings.NewReader("0123456789a")})
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	// The response can not be replaced once the handler started writing it.
+	assert.Equal(t, http.StatusAccepted, rw.Code)
+	assert.Equal(t, "accepted", rw.Body.String())
+}
+
+func TestLimitRequestBody_Flush(t *testing.T) {
+	h := LimitRequestBody(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		flusher, ok := rw.(http.Flusher)
+		require.True(t, ok)
+
+		rw.Write([]byte("data: ping\n\n"))
+		flusher.Flush()
+	}), 10, rejectTooLarge)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.True(t, rw.Flushed)
+	assert.Equal(t, "data: ping\n\n", rw.Body.String())
+}
@@ -99,7 +99,8 @@ func (conn *connection) Exists(ctx context.Context, key string) bool {
 }
 
 /*
-Read reads the blob at key and returns its byte representation.
+Read reads the blob at key and returns its byte representation. Returns an error
+carrying errorstack.CodeNotFound if the blob does not exist.
 
 It automatically handles tracing and error recording.
 */
@@ -114,7 +115,7 @@ func (conn *connection) Read(ctx context.Context, key string) ([]byte, error) {
 
 	setAttributes(span, conn.config, key)
 
-	return value, err
+	return value, mapError(err, "Failed to read blob")
 }
 
 /*
@@ -146,7 +147,7 @@ func (conn *connection) Write(ctx context.Context, key string, value []byte, opt
 
 	setAttributes(span, conn.config, key)
 
-	return err
+	return mapError(err, "Failed to write blob")
 }
 
 /*
@@ -165,5 +166,5 @@ func (conn *connection) Delete(ctx context.Context, key string) error {
 
 	setAttributes(span, conn.config, key)
 
-	return err
+	return mapError(err, "Failed to delete blob")
 }
@@ -0,0 +1,49 @@
+package bucket
+
+import (
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"gocloud.dev/gcerrors"
+)
+
+/*
+mappedError is the errorstack code and message an error is mapped to.
+*/
+type mappedError struct {
+	code    string
+	message string
+}
+
+/*
+gcErrors maps the portable error codes of Go CDK to errorstack codes.
+*/
+var gcErrors = map[gcerrors.ErrorCode]mappedError{
+	gcerrors.NotFound:           {errorstack.CodeNotFound, "Resource does not exist"},
+	gcerrors.AlreadyExists:      {errorstack.CodeConflict, "Resource already exists"},
+	gcerrors.FailedPrecondition: {errorstack.CodeConflict, "Resource conflicts with current state"},
+	gcerrors.InvalidArgument:    {errorstack.CodeBadRequest, "Request is invalid"},
+	gcerrors.PermissionDenied:   {errorstack.CodeForbidden, "Access is forbidden"},
+	gcerrors.ResourceExhausted:  {errorstack.CodeTooManyRequests, "Rate limit has been exceeded"},
+	gcerrors.Unimplemented:      {errorstack.CodeNotImplemented, "Operation is not implemented"},
+	gcerrors.DeadlineExceeded:   {errorstack.CodeGatewayTimeout, "Operation timed out"},
+}
+
+/*
+mapError wraps err with errorstack.Wrap, carrying the errorstack code matching
+the Go CDK error code of the driver error, such as CodeNotFound for a missing
+blob. Other errors carry CodeInternalError with the message passed. The driver
+error is preserved in the chain for errors.Is/As support. Returns nil if err is
+nil.
+*/
+func mapError(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	mapped, ok := gcErrors[gcerrors.Code(err)]
+	if !ok {
+		mapped = mappedError{errorstack.CodeInternalError, message}
+	}
+
+	return errorstack.Wrap(err, mapped.message, errorstack.WithCode(mapped.code))
+}
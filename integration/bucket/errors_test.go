@@ -0,0 +1,43 @@
+package bucket
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob/memblob"
+	"gocloud.dev/gcerrors"
+)
+
+func TestMapError_NotFound(t *testing.T) {
+	b := memblob.OpenBucket(nil)
+	t.Cleanup(func() { b.Close() })
+
+	_, cause := b.ReadAll(t.Context(), "missing.json")
+	require.Error(t, cause)
+
+	err := mapError(cause, "Failed to read blob")
+
+	var stack *errorstack.Error
+	require.ErrorAs(t, err, &stack)
+	assert.Equal(t, errorstack.CodeNotFound, errorstack.CodeOf(err))
+	assert.Equal(t, "Resource does not exist", stack.Entries[0].Message)
+	assert.Equal(t, gcerrors.NotFound, gcerrors.Code(err))
+	assert.ErrorIs(t, err, cause)
+}
+
+func TestMapError_Unknown(t *testing.T) {
+	cause := errors.New("connection reset by peer")
+	err := mapError(cause, "Failed to read blob")
+
+	var stack *errorstack.Error
+	require.ErrorAs(t, err, &stack)
+	assert.Equal(t, errorstack.CodeInternalError, errorstack.CodeOf(err))
+	assert.Equal(t, "Failed to read blob", stack.Entries[0].Message)
+	assert.ErrorIs(t, err, cause)
+
+	assert.NoError(t, mapError(nil, "Failed to read blob"))
+}
@@ -0,0 +1,500 @@
+package compression
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+/*
+Content codings supported for compressing responses, as found in the
+Accept-Encoding and Content-Encoding headers.
+*/
+const (
+	EncodingZstd   = "zstd"
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+/*
+Default values applied to Config when none are set.
+*/
+var (
+	defaultMinSize = 1024
+
+	defaultEncodings = []string{
+		EncodingZstd,
+		EncodingBrotli,
+		EncodingGzip,
+	}
+
+	defaultContentTypes = []string{
+		"application/graphql-response+json",
+		"application/javascript",
+		"application/json",
+		"application/problem+json",
+		"application/xml",
+		"application/yaml",
+		"image/svg+xml",
+		"text/*",
+	}
+)
+
+/*
+compressor is implemented by the writers of every content coding supported, so
+they can be pooled and reused across responses.
+*/
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+/*
+compressors holds a pool of writers for every content coding supported. Levels
+favor speed over ratio, since responses are compressed on the fly.
+*/
+var compressors = map[string]*sync.Pool{
+	EncodingZstd: {
+		New: func() any {
+			w, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+			return w
+		},
+	},
+	EncodingBrotli: {
+		New: func() any {
+			return brotli.NewWriterLevel(nil, 4)
+		},
+	},
+	EncodingGzip: {
+		New: func() any {
+			w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+			return w
+		},
+	},
+}
+
+/*
+Config is the common configuration for compressing responses across
+all HTTP server integrations (REST, GraphQL, MCP).
+*/
+type Config struct {
+
+	// Enabled enables compressing responses, with the content coding negotiated
+	// from the Accept-Encoding header of every request. When disabled, other
+	// fields are ignored.
+	Enabled bool `json:"enabled"`
+
+	// MinSize is the minimum size of a response body, in bytes, to be compressed.
+	// Smaller bodies are written as is, since compressing them saves close to
+	// nothing.
+	//
+	// Default:
+	//
+	//   1024
+	MinSize int `json:"min_size,omitempty"`
+
+	// Encodings is the list of content codings allowed, by order of preference
+	// when the client accepts many with the same weight. Supported values are
+	// "zstd", "br", and "gzip".
+	//
+	// Default:
+	//
+	//   []string{"zstd", "br", "gzip"}
+	Encodings []string `json:"encodings,omitempty"`
+
+	// ContentTypes is the list of media types of the responses to compress. A
+	// media type can end with a "/*" wildcard for all its subtypes. Responses
+	// with a text/event-stream Content-Type are never compressed, so they are
+	// still flushed to the client as written.
+	//
+	// Default:
+	//
+	//   []string{"application/graphql-response+json", "application/javascript", "application/json", "application/problem+json", "application/xml", "application/yaml", "image/svg+xml", "text/*"}
+	ContentTypes []string `json:"content_types,omitempty"`
+}
+
+/*
+Sanitize sets default values - if applicable - and validates the configuration.
+Returns validation entries if configuration is not valid. This doesn't return
+a standard error since this function shall only be called by integrations,
+which collect entries from many sources before producing a final
+errorstack.NewValidation:
+
+	entries = append(entries, cfg.Compression.Sanitize()...)
+*/
+func (cfg *Config) Sanitize() []errorstack.Entry {
+	var entries []errorstack.Entry
+	if !cfg.Enabled {
+		return entries
+	}
+
+	if cfg.MinSize < 0 {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be greater than or equal to 0",
+			Path:    []any{"config", "compression", "min_size"},
+		})
+	}
+
+	if cfg.MinSize == 0 {
+		cfg.MinSize = defaultMinSize
+	}
+
+	if len(cfg.Encodings) == 0 {
+		cfg.Encodings = slices.Clone(defaultEncodings)
+	}
+
+	for i, encoding := range cfg.Encodings {
+		cfg.Encodings[i] = strings.ToLower(strings.TrimSpace(encoding))
+		if _, ok := compressors[cfg.Encodings[i]]; !ok {
+			entries = append(entries, errorstack.Entry{
+				Message: "Must be one of: zstd, br, gzip",
+				Path:    []any{"config", "compression", "encodings", i},
+			})
+		}
+	}
+
+	if len(cfg.ContentTypes) == 0 {
+		cfg.ContentTypes = slices.Clone(defaultContentTypes)
+	}
+
+	for i, contentType := range cfg.ContentTypes {
+		cfg.ContentTypes[i] = strings.ToLower(strings.TrimSpace(contentType))
+		main, sub, found := strings.Cut(cfg.ContentTypes[i], "/")
+		if !found || main == "" || main == "*" || sub == "" || (strings.Contains(sub, "*") && sub != "*") {
+			entries = append(entries, errorstack.Entry{
+				Message: "Must be a media type, such as application/json or text/*",
+				Path:    []any{"config", "compression", "content_types", i},
+			})
+		}
+	}
+
+	return entries
+}
+
+/*
+Middleware returns an HTTP middleware compressing responses when enabled. The
+content coding is negotiated from the Accept-Encoding header of the request, and
+the response is only compressed if:
+
+  - its body is at least MinSize bytes long;
+  - its Content-Type is allowed, and is not text/event-stream;
+  - its status is neither 204 nor 304, and its headers hold neither a
+    Content-Encoding nor a Cache-Control no-transform directive.
+
+A strong ETag of a compressed response is made weak, since the compressed bytes
+are not the ones it was computed from. Conditional GET and HEAD requests still
+get a 304, If-None-Match being compared weakly.
+
+The body is buffered until MinSize bytes are written, the handler returns, or
+the response is flushed, to decide if it is compressed. Flushing a compressed
+response flushes the compressed bytes written so far, so incremental responses
+keep reaching the client as written.
+
+Returns next as is when disabled, so it costs nothing per request.
+*/
+func (cfg *Config) Middleware(next http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := cfg.negotiate(req.Header.Values("Accept-Encoding"))
+		if encoding == "" || req.Method == http.MethodHead {
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: rw,
+			config:         cfg,
+			encoding:       encoding,
+		}
+
+		next.ServeHTTP(cw, req)
+		cw.close()
+	})
+}
+
+/*
+negotiate returns the content coding allowed with the highest weight in the
+Accept-Encoding header values passed, or an empty string if none is acceptable.
+Encodings with the same weight are picked by order of preference.
+*/
+func (cfg *Config) negotiate(values []string) string {
+	weights := make(map[string]float64)
+	for _, value := range values {
+		for part := range strings.SplitSeq(value, ",") {
+			name, params, _ := strings.Cut(part, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+
+			weight := 1.0
+			for param := range strings.SplitSeq(params, ";") {
+				q, found := strings.CutPrefix(strings.TrimSpace(param), "q=")
+				if !found {
+					continue
+				}
+
+				parsed, err := strconv.ParseFloat(q, 64)
+				if err != nil {
+					parsed = 0
+				}
+
+				weight = parsed
+			}
+
+			weights[name] = weight
+		}
+	}
+
+	var best string
+	var bestWeight float64
+	for _, encoding := range cfg.Encodings {
+		weight, ok := weights[encoding]
+		if !ok {
+			weight, ok = weights["*"]
+		}
+
+		if ok && weight > bestWeight {
+			best, bestWeight = encoding, weight
+		}
+	}
+
+	return best
+}
+
+/*
+isAllowedContentType reports if responses with the Content-Type passed can be
+compressed. text/event-stream is never allowed, whatever the configuration.
+*/
+func (cfg *Config) isAllowedContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "text/event-stream" {
+		return false
+	}
+
+	for _, allowed := range cfg.ContentTypes {
+		if allowed == mediaType {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok && strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+compressWriter is an http.ResponseWriter compressing the response body with the
+content coding negotiated, when the response can be compressed.
+*/
+type compressWriter struct {
+	http.ResponseWriter
+
+	// config is the configuration of the compression middleware.
+	config *Config
+
+	// encoding is the content coding negotiated with the client.
+	encoding string
+
+	// status is the status code set by the handler, or 0 if not set yet.
+	status int
+
+	// buf is the beginning of the response body, held back until it is decided
+	// if the response is compressed.
+	buf []byte
+
+	// decided reports whether it is decided if the response is compressed. The
+	// status code is written to the client once decided.
+	decided bool
+
+	// compressor is the writer compressing the body, or nil if the response is
+	// not compressed.
+	compressor compressor
+}
+
+/*
+Unwrap returns the underlying http.ResponseWriter so http.ResponseController can
+reach optional interfaces such as http.Flusher, enabling incremental streaming.
+*/
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+/*
+WriteHeader stores the status code until it is decided if the response is
+compressed. It is decided right away if the headers set so far already prevent
+compression, such as a text/event-stream Content-Type.
+*/
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.decided || status < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	if cw.status != 0 {
+		return
+	}
+
+	cw.status = status
+	if !cw.compressible() {
+		cw.decide(false)
+	}
+}
+
+/*
+Write holds the data back until it is decided if the response is compressed, and
+then writes it to the compressor or to the client directly.
+*/
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.config.MinSize {
+			return len(b), nil
+		}
+
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+
+		return len(b), nil
+	}
+
+	if cw.compressor != nil {
+		return cw.compressor.Write(b)
+	}
+
+	return cw.ResponseWriter.Write(b)
+}
+
+/*
+Flush decides if the response is compressed — whatever the size written so far —
+and flushes the data written to the client.
+*/
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+
+		cw.decide(cw.compressible())
+	}
+
+	if cw.compressor != nil {
+		cw.compressor.Flush()
+	}
+
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+/*
+compressible reports if the headers and status code set so far allow the
+response to be compressed.
+*/
+func (cw *compressWriter) compressible() bool {
+	if cw.status == http.StatusNoContent || cw.status == http.StatusNotModified ||
+		cw.status == http.StatusPartialContent {
+		return false
+	}
+
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" || strings.Contains(strings.ToLower(h.Get("Cache-Control")), "no-transform") {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	return contentType == "" || cw.config.isAllowedContentType(contentType)
+}
+
+/*
+decide writes the status code to the client, with the headers required if the
+response is compressed, and then the data held back so far. The Content-Type is
+detected from the data if not set, just like net/http does.
+*/
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+
+	h := cw.Header()
+	if compress {
+		if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+			h.Set("Content-Type", http.DetectContentType(cw.buf))
+		}
+
+		compress = cw.config.isAllowedContentType(h.Get("Content-Type"))
+	}
+
+	if compress {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+
+		// The compressed representation differs byte for byte from the one the
+		// ETag was computed from, so it can only be weakly equivalent to it.
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+
+		cw.compressor = compressors[cw.encoding].Get().(compressor)
+		cw.compressor.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.compressor != nil {
+		_, err = cw.compressor.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+
+	return err
+}
+
+/*
+close writes the response held back if it is still not decided, which means its
+body is smaller than MinSize and is not compressed. Otherwise it terminates the
+compressed body, and puts the compressor back in its pool.
+*/
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 {
+			return
+		}
+
+		cw.decide(false)
+		return
+	}
+
+	if cw.compressor != nil {
+		cw.compressor.Close()
+		cw.compressor.Reset(nil)
+		compressors[cw.encoding].Put(cw.compressor)
+		cw.compressor = nil
+	}
+}
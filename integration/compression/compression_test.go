@@ -0,0 +1,497 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decompress returns the body passed decoded with the content coding passed.
+func decompress(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+
+	var r io.Reader
+	switch encoding {
+	case EncodingGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		r = gr
+	case EncodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	case EncodingZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	default:
+		return string(body)
+	}
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return string(b)
+}
+
+func TestConfig_Sanitize(t *testing.T) {
+	testcases := []struct {
+		name     string
+		cfg      Config
+		expected []errorstack.Entry
+	}{
+		{
+			name: "disabled ignores invalid values",
+			cfg: Config{
+				Enabled:   false,
+				MinSize:   -1,
+				Encodings: []string{"deflate"},
+			},
+			expected: nil,
+		},
+		{
+			name: "enabled with defaults has no entries",
+			cfg: Config{
+				Enabled: true,
+			},
+			expected: nil,
+		},
+		{
+			name: "enabled with valid values has no entries",
+			cfg: Config{
+				Enabled:      true,
+				MinSize:      256,
+				Encodings:    []string{" GZIP ", "br"},
+				ContentTypes: []string{"application/json", "text/*"},
+			},
+			expected: nil,
+		},
+		{
+			name: "enabled with invalid values returns entries",
+			cfg: Config{
+				Enabled:      true,
+				MinSize:      -1,
+				Encodings:    []string{"gzip", "deflate"},
+				ContentTypes: []string{"application/json", "json", "*/*", "text/x*"},
+			},
+			expected: []errorstack.Entry{
+				{
+					Message: "Must be greater than or equal to 0",
+					Path:    []any{"config", "compression", "min_size"},
+				},
+				{
+					Message: "Must be one of: zstd, br, gzip",
+					Path:    []any{"config", "compression", "encodings", 1},
+				},
+				{
+					Message: "Must be a media type, such as application/json or text/*",
+					Path:    []any{"config", "compression", "content_types", 1},
+				},
+				{
+					Message: "Must be a media type, such as application/json or text/*",
+					Path:    []any{"config", "compression", "content_types", 2},
+				},
+				{
+					Message: "Must be a media type, such as application/json or text/*",
+					Path:    []any{"config", "compression", "content_types", 3},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := tc.cfg.Sanitize()
+
+			assert.Equal(t, tc.expected, entries)
+		})
+	}
+}
+
+func TestConfig_Sanitize_Defaults(t *testing.T) {
+	cfg := Config{
+		Enabled: true,
+	}
+
+	entries := cfg.Sanitize()
+
+	assert.Empty(t, entries)
+	assert.Equal(t, 1024, cfg.MinSize)
+	assert.Equal(t, defaultEncodings, cfg.Encodings)
+	assert.Equal(t, defaultContentTypes, cfg.ContentTypes)
+}
+
+func TestConfig_negotiate(t *testing.T) {
+	cfg := Config{
+		Enabled: true,
+	}
+
+	cfg.Sanitize()
+
+	testcases := []struct {
+		name     string
+		header   []string
+		expected string
+	}{
+		{
+			name:     "without header",
+			expected: "",
+		},
+		{
+			name:     "single encoding",
+			header:   []string{"gzip"},
+			expected: EncodingGzip,
+		},
+		{
+			name:     "same weight picks by order of preference",
+			header:   []string{"gzip, deflate, br, zstd"},
+			expected: EncodingZstd,
+		},
+		{
+			name:     "highest weight wins",
+			header:   []string{"zstd;q=0.5, br;q=0.8", "gzip;q=0.9"},
+			expected: EncodingGzip,
+		},
+		{
+			name:     "zero weight is not acceptable",
+			header:   []string{"gzip;q=0, zstd;q=0"},
+			expected: "",
+		},
+		{
+			name:     "wildcard",
+			header:   []string{"*;q=0.5, zstd;q=0"},
+			expected: EncodingBrotli,
+		},
+		{
+			name:     "unsupported encodings only",
+			header:   []string{"deflate, identity"},
+			expected: "",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, cfg.negotiate(tc.header))
+		})
+	}
+}
+
+func TestConfig_Middleware(t *testing.T) {
+	large := strings.Repeat(`{"id":"usr_1","name":"Jane"}`, 100)
+
+	testcases := []struct {
+		name             string
+		acceptEncoding   string
+		method           string
+		handler          http.HandlerFunc
+		expectedEncoding string
+		expectedBody     string
+	}{
+		{
+			name:           "gzip",
+			acceptEncoding: "gzip",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.Write([]byte(large))
+			},
+			expectedEncoding: EncodingGzip,
+			expectedBody:     large,
+		},
+		{
+			name:           "brotli",
+			acceptEncoding: "br",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(http.StatusCreated)
+				rw.Write([]byte(large))
+			},
+			expectedEncoding: EncodingBrotli,
+			expectedBody:     large,
+		},
+		{
+			name:           "zstd written in many chunks",
+			acceptEncoding: "zstd",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+				for i := 0; i < len(large); i += 100 {
+					rw.Write([]byte(large[i:min(i+100, len(large))]))
+				}
+			},
+			expectedEncoding: EncodingZstd,
+			expectedBody:     large,
+		},
+		{
+			name:           "detected content type",
+			acceptEncoding: "gzip",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Write([]byte(strings.Repeat("plain text ", 200)))
+			},
+			expectedEncoding: EncodingGzip,
+			expectedBody:     strings.Repeat("plain text ", 200),
+		},
+		{
+			name:           "smaller than minimum size",
+			acceptEncoding: "gzip",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.Write([]byte(`{"id":"usr_1"}`))
+			},
+			expectedEncoding: "",
+			expectedBody:     `{"id":"usr_1"}`,
+		},
+		{
+			name:           "content type not allowed",
+			acceptEncoding: "gzip",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "image/png")
+				rw.Write([]byte(large))
+			},
+			expectedEncoding: "",
+			expectedBody:     large,
+		},
+		{
+			name:           "already encoded",
+			acceptEncoding: "gzip",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.Header().Set("Content-Encoding", "identity")
+				rw.Write([]byte(large))
+			},
+			expectedEncoding: "identity",
+			expectedBody:     large,
+		},
+		{
+			name:           "no-transform",
+			acceptEncoding: "gzip",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.Header().Set("Cache-Control", "private, no-transform")
+				rw.Write([]byte(large))
+			},
+			expectedEncoding: "",
+			expectedBody:     large,
+		},
+		{
+			name: "encoding not accepted",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.Write([]byte(large))
+			},
+			expectedEncoding: "",
+			expectedBody:     large,
+		},
+		{
+			name:           "HEAD request",
+			acceptEncoding: "gzip",
+			method:         http.MethodHead,
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(http.StatusOK)
+			},
+			expectedEncoding: "",
+			expectedBody:     "",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{
+				Enabled: true,
+			}
+
+			require.Empty(t, cfg.Sanitize())
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			req := httptest.NewRequest(method, "/", nil)
+			if tc.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+
+			rw := httptest.NewRecorder()
+			cfg.Middleware(tc.handler).ServeHTTP(rw, req)
+
+			assert.Equal(t, tc.expectedEncoding, rw.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", rw.Header().Get("Vary"))
+			assert.Equal(t, tc.expectedBody, decompress(t, tc.expectedEncoding, rw.Body.Bytes()))
+			if tc.expectedEncoding != "" && tc.expectedEncoding != "identity" {
+				assert.Empty(t, rw.Header().Get("Content-Length"))
+				assert.Less(t, rw.Body.Len(), len(tc.expectedBody))
+			}
+		})
+	}
+}
+
+func TestConfig_Middleware_Status(t *testing.T) {
+	cfg := Config{
+		Enabled: true,
+	}
+
+	require.Empty(t, cfg.Sanitize())
+
+	h := cfg.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("ETag", `"v3"`)
+		rw.WriteHeader(http.StatusNotModified)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusNotModified, rw.Code)
+	assert.Empty(t, rw.Header().Get("Content-Encoding"))
+	assert.Empty(t, rw.Body.String())
+}
+
+func TestConfig_Middleware_ETag(t *testing.T) {
+	large := strings.Repeat(`{"id":"usr_1","name":"Jane"}`, 100)
+
+	testcases := []struct {
+		name     string
+		etag     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "strong tag of a compressed response is made weak",
+			etag:     `"v3"`,
+			body:     large,
+			expected: `W/"v3"`,
+		},
+		{
+			name:     "weak tag of a compressed response is kept",
+			etag:     `W/"v3"`,
+			body:     large,
+			expected: `W/"v3"`,
+		},
+		{
+			name:     "strong tag of a response written as is is kept",
+			etag:     `"v3"`,
+			body:     `{"id":"usr_1"}`,
+			expected: `"v3"`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{
+				Enabled: true,
+			}
+
+			require.Empty(t, cfg.Sanitize())
+
+			h := cfg.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.Header().Set("ETag", tc.etag)
+				rw.Write([]byte(tc.body))
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, req)
+
+			assert.Equal(t, tc.expected, rw.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestConfig_Middleware_Streaming(t *testing.T) {
+	cfg := Config{
+		Enabled: true,
+	}
+
+	require.Empty(t, cfg.Sanitize())
+
+	// The first event is written to the client before the second one is, even
+	// though it is smaller than the minimum size, so it is not compressed.
+	var flushed string
+	rw := httptest.NewRecorder()
+	h := cfg.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+
+		w.Write([]byte("data: first\n\n"))
+		require.NoError(t, http.NewResponseController(w).Flush())
+		flushed = rw.Body.String()
+
+		w.Write([]byte("data: second\n\n"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	h.ServeHTTP(rw, req)
+
+	assert.Empty(t, rw.Header().Get("Content-Encoding"))
+	assert.True(t, rw.Flushed)
+	assert.Equal(t, "data: first\n\n", flushed)
+	assert.Equal(t, "data: first\n\ndata: second\n\n", rw.Body.String())
+}
+
+func TestConfig_Middleware_Flush(t *testing.T) {
+	cfg := Config{
+		Enabled: true,
+	}
+
+	require.Empty(t, cfg.Sanitize())
+
+	// Flushing a compressed response writes the compressed bytes written so far,
+	// which can be decoded without waiting for the end of the body.
+	var flushed []byte
+	rw := httptest.NewRecorder()
+	h := cfg.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"usr_1"}` + "\n"))
+		require.NoError(t, http.NewResponseController(w).Flush())
+		flushed = bytes.Clone(rw.Body.Bytes())
+
+		w.Write([]byte(`{"id":"usr_2"}` + "\n"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	h.ServeHTTP(rw, req)
+
+	assert.Equal(t, EncodingGzip, rw.Header().Get("Content-Encoding"))
+	assert.True(t, rw.Flushed)
+
+	gr, err := gzip.NewReader(bytes.NewReader(flushed))
+	require.NoError(t, err)
+
+	line := make([]byte, len(`{"id":"usr_1"}`+"\n"))
+	_, err = io.ReadFull(gr, line)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"usr_1"}`+"\n", string(line))
+
+	assert.Equal(t, `{"id":"usr_1"}`+"\n"+`{"id":"usr_2"}`+"\n", decompress(t, EncodingGzip, rw.Body.Bytes()))
+}
+
+func TestConfig_Middleware_Disabled(t *testing.T) {
+	cfg := Config{
+		Enabled: false,
+	}
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+	h := cfg.Middleware(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.Empty(t, rw.Header().Get("Vary"))
+}
@@ -0,0 +1,6 @@
+/*
+Package compression exposes the response compression shared by the HTTP server
+integrations (REST, GraphQL, MCP). It lives in its own package so only these
+integrations depend on the zstd, brotli, and gzip encoders.
+*/
+package compression
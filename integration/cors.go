@@ -0,0 +1,343 @@
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+)
+
+/*
+Default methods and headers allowed in cross-origin requests when none are set
+in ConfigCORS.
+*/
+var (
+	defaultCORSMethods = []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+	}
+
+	defaultCORSHeaders = []string{
+		"Accept",
+		"Accept-Language",
+		"Authorization",
+		"Content-Language",
+		"Content-Type",
+	}
+)
+
+/*
+ConfigCORS is the common configuration for handling Cross-Origin Resource
+Sharing across all HTTP server integrations (REST, GraphQL, MCP).
+*/
+type ConfigCORS struct {
+
+	// Enabled enables CORS handling. When disabled, other fields are ignored and
+	// can be empty, and no CORS header is ever written.
+	Enabled bool `json:"enabled"`
+
+	// AllowedOrigins is the list of origins allowed to make cross-origin requests.
+	// An origin can contain a single "*" wildcard for the subdomains of a host,
+	// and "*" alone allows any origin. It is required when enabled.
+	//
+	// Examples:
+	//
+	//   []string{"https://app.example.com", "https://*.example.com"}
+	//   []string{"*"}
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+
+	// AllowedMethods is the list of methods allowed in cross-origin requests.
+	//
+	// Default:
+	//
+	//   []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+	AllowedMethods []string `json:"allowed_methods,omitempty"`
+
+	// AllowedHeaders is the list of headers clients are allowed to set in
+	// cross-origin requests. "*" allows any header.
+	//
+	// Default:
+	//
+	//   []string{"Accept", "Accept-Language", "Authorization", "Content-Language", "Content-Type"}
+	AllowedHeaders []string `json:"allowed_headers,omitempty"`
+
+	// ExposedHeaders is the list of response headers clients are allowed to read,
+	// in addition to the CORS-safelisted ones.
+	ExposedHeaders []string `json:"exposed_headers,omitempty"`
+
+	// AllowCredentials allows cross-origin requests to include credentials, such
+	// as cookies and the Authorization header. It can not be combined with an
+	// origin set to "*".
+	AllowCredentials bool `json:"allow_credentials"`
+
+	// MaxAge is how long browsers can cache the result of a preflight request.
+	// When 0, no Access-Control-Max-Age header is written and browsers apply
+	// their own default.
+	MaxAge time.Duration `json:"max_age,omitempty"`
+
+	// anyOrigin, origins, and wildcards hold the origins parsed from
+	// AllowedOrigins by Sanitize.
+	anyOrigin bool
+	origins   map[string]struct{}
+	wildcards []originWildcard
+
+	// anyHeader and headers hold the headers parsed from AllowedHeaders by
+	// Sanitize, in lower case.
+	anyHeader bool
+	headers   map[string]struct{}
+}
+
+/*
+originWildcard is an allowed origin holding a "*" wildcard, split around it.
+*/
+type originWildcard struct {
+	prefix string
+	suffix string
+}
+
+/*
+Sanitize sets default values - if applicable - and validates the configuration.
+Returns validation entries if configuration is not valid. This doesn't return
+a standard error since this function shall only be called by integrations,
+which collect entries from many sources before producing a final
+errorstack.NewValidation:
+
+	entries = append(entries, cfg.CORS.Sanitize()...)
+*/
+func (cfg *ConfigCORS) Sanitize() []errorstack.Entry {
+	var entries []errorstack.Entry
+	if !cfg.Enabled {
+		return entries
+	}
+
+	if len(cfg.AllowedOrigins) == 0 {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be set when CORS is enabled",
+			Path:    []any{"config", "cors", "allowed_origins"},
+		})
+	}
+
+	cfg.anyOrigin = false
+	cfg.origins = make(map[string]struct{}, len(cfg.AllowedOrigins))
+	cfg.wildcards = nil
+	for i, origin := range cfg.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin == "*" {
+			cfg.anyOrigin = true
+			if cfg.AllowCredentials {
+				entries = append(entries, errorstack.Entry{
+					Message: "Must not allow any origin when credentials are allowed",
+					Path:    []any{"config", "cors", "allowed_origins", i},
+				})
+			}
+
+			continue
+		}
+
+		if !isValidOrigin(origin) {
+			entries = append(entries, errorstack.Entry{
+				Message: "Must be a valid origin, such as https://example.com or https://*.example.com",
+				Path:    []any{"config", "cors", "allowed_origins", i},
+			})
+
+			continue
+		}
+
+		prefix, suffix, found := strings.Cut(origin, "*")
+		if !found {
+			cfg.origins[origin] = struct{}{}
+			continue
+		}
+
+		cfg.wildcards = append(cfg.wildcards, originWildcard{
+			prefix: prefix,
+			suffix: suffix,
+		})
+	}
+
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = slices.Clone(defaultCORSMethods)
+	}
+
+	for i, method := range cfg.AllowedMethods {
+		cfg.AllowedMethods[i] = strings.ToUpper(strings.TrimSpace(method))
+	}
+
+	if len(cfg.AllowedHeaders) == 0 {
+		cfg.AllowedHeaders = slices.Clone(defaultCORSHeaders)
+	}
+
+	cfg.anyHeader = false
+	cfg.headers = make(map[string]struct{}, len(cfg.AllowedHeaders))
+	for _, header := range cfg.AllowedHeaders {
+		header = strings.ToLower(strings.TrimSpace(header))
+		if header == "*" {
+			cfg.anyHeader = true
+		}
+
+		cfg.headers[header] = struct{}{}
+	}
+
+	if cfg.MaxAge < 0 {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be greater than or equal to 0",
+			Path:    []any{"config", "cors", "max_age"},
+		})
+	}
+
+	return entries
+}
+
+/*
+isValidOrigin reports if origin is a scheme and a host — optionally with a port
+— and nothing else. The host can start with a single "*." wildcard.
+*/
+func isValidOrigin(origin string) bool {
+	if strings.Count(origin, "*") > 1 {
+		return false
+	}
+
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	if err != nil || strings.Contains(u.Host, "*") {
+		return false
+	}
+
+	return u.Scheme != "" && u.Host != "" && u.User == nil && u.Path == "" &&
+		u.RawQuery == "" && u.Fragment == ""
+}
+
+/*
+Middleware returns an HTTP middleware handling CORS when enabled. It must wrap
+the router, so it runs before routing and before any user middleware:
+
+  - preflight requests — OPTIONS requests holding an Origin and an
+    Access-Control-Request-Method header — are answered with a 204 and never
+    reach next. The CORS headers are only written if the origin, the method, and
+    every header requested are allowed;
+  - for other requests holding an allowed Origin, the CORS headers are set
+    before calling next, so they are also written on error responses such as
+    404, 401, or 500.
+
+Returns next as is when disabled, so it costs nothing per request.
+*/
+func (cfg *ConfigCORS) Middleware(next http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		preflight := req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+
+		if preflight {
+			rw.Header().Add("Vary", "Origin")
+			rw.Header().Add("Vary", "Access-Control-Request-Method")
+			rw.Header().Add("Vary", "Access-Control-Request-Headers")
+			if origin != "" {
+				cfg.writePreflight(rw, req, origin)
+			}
+
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if origin != "" {
+			rw.Header().Add("Vary", "Origin")
+			if cfg.isAllowedOrigin(origin) {
+				cfg.writeOrigin(rw, origin)
+				if len(cfg.ExposedHeaders) > 0 {
+					rw.Header().Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+				}
+			}
+		}
+
+		next.ServeHTTP(rw, req)
+	})
+}
+
+/*
+writePreflight writes the CORS headers answering a preflight request, only if
+the origin, the method, and every header requested are allowed.
+*/
+func (cfg *ConfigCORS) writePreflight(rw http.ResponseWriter, req *http.Request, origin string) {
+	if !cfg.isAllowedOrigin(origin) {
+		return
+	}
+
+	method := strings.ToUpper(req.Header.Get("Access-Control-Request-Method"))
+	if !slices.Contains(cfg.AllowedMethods, method) {
+		return
+	}
+
+	var requested []string
+	for _, value := range req.Header.Values("Access-Control-Request-Headers") {
+		for header := range strings.SplitSeq(value, ",") {
+			header = strings.ToLower(strings.TrimSpace(header))
+			if header == "" {
+				continue
+			}
+
+			if _, ok := cfg.headers[header]; !ok && !cfg.anyHeader {
+				return
+			}
+
+			requested = append(requested, header)
+		}
+	}
+
+	cfg.writeOrigin(rw, origin)
+	rw.Header().Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowedMethods, ", "))
+	if len(requested) > 0 {
+		rw.Header().Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+
+	if cfg.MaxAge > 0 {
+		rw.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+	}
+}
+
+/*
+writeOrigin writes the Access-Control-Allow-Origin header, and the
+Access-Control-Allow-Credentials one if credentials are allowed. The origin of
+the request is always reflected rather than "*", so responses are identical
+whatever the configuration, and cached per origin thanks to the Vary header.
+*/
+func (cfg *ConfigCORS) writeOrigin(rw http.ResponseWriter, origin string) {
+	rw.Header().Set("Access-Control-Allow-Origin", origin)
+	if cfg.AllowCredentials {
+		rw.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+/*
+isAllowedOrigin reports if the origin passed is allowed to make cross-origin
+requests. Origins are compared case-insensitively.
+*/
+func (cfg *ConfigCORS) isAllowedOrigin(origin string) bool {
+	if cfg.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if _, ok := cfg.origins[origin]; ok {
+		return true
+	}
+
+	for _, wildcard := range cfg.wildcards {
+		if len(origin) > len(wildcard.prefix)+len(wildcard.suffix) &&
+			strings.HasPrefix(origin, wildcard.prefix) &&
+			strings.HasSuffix(origin, wildcard.suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,330 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCORS_Sanitize(t *testing.T) {
+	testcases := []struct {
+		name     string
+		cfg      ConfigCORS
+		expected []errorstack.Entry
+	}{
+		{
+			name: "disabled ignores invalid origins",
+			cfg: ConfigCORS{
+				Enabled:        false,
+				AllowedOrigins: []string{"invalid"},
+			},
+			expected: nil,
+		},
+		{
+			name: "enabled without origins returns entry",
+			cfg: ConfigCORS{
+				Enabled: true,
+			},
+			expected: []errorstack.Entry{
+				{
+					Message: "Must be set when CORS is enabled",
+					Path:    []any{"config", "cors", "allowed_origins"},
+				},
+			},
+		},
+		{
+			name: "enabled with valid origins has no entries",
+			cfg: ConfigCORS{
+				Enabled:        true,
+				AllowedOrigins: []string{"https://example.com", " https://*.example.com ", "http://localhost:3000"},
+			},
+			expected: nil,
+		},
+		{
+			name: "enabled with invalid origins returns entries",
+			cfg: ConfigCORS{
+				Enabled:        true,
+				AllowedOrigins: []string{"https://example.com", "example.com", "https://example.com/path", "https://*.*.example.com", "https://ex*ample.com"},
+				MaxAge:         -time.Second,
+			},
+			expected: []errorstack.Entry{
+				{
+					Message: "Must be a valid origin, such as https://example.com or https://*.example.com",
+					Path:    []any{"config", "cors", "allowed_origins", 1},
+				},
+				{
+					Message: "Must be a valid origin, such as https://example.com or https://*.example.com",
+					Path:    []any{"config", "cors", "allowed_origins", 2},
+				},
+				{
+					Message: "Must be a valid origin, such as https://example.com or https://*.example.com",
+					Path:    []any{"config", "cors", "allowed_origins", 3},
+				},
+				{
+					Message: "Must be a valid origin, such as https://example.com or https://*.example.com",
+					Path:    []any{"config", "cors", "allowed_origins", 4},
+				},
+				{
+					Message: "Must be greater than or equal to 0",
+					Path:    []any{"config", "cors", "max_age"},
+				},
+			},
+		},
+		{
+			name: "enabled with any origin and credentials returns entry",
+			cfg: ConfigCORS{
+				Enabled:          true,
+				AllowedOrigins:   []string{"*"},
+				AllowCredentials: true,
+			},
+			expected: []errorstack.Entry{
+				{
+					Message: "Must not allow any origin when credentials are allowed",
+					Path:    []any{"config", "cors", "allowed_origins", 0},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := tc.cfg.Sanitize()
+			assert.Equal(t, tc.expected, entries)
+		})
+	}
+}
+
+func TestConfigCORS_Sanitize_Defaults(t *testing.T) {
+	cfg := ConfigCORS{
+		Enabled:        true,
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"get", " post "},
+	}
+
+	require.Empty(t, cfg.Sanitize())
+	assert.Equal(t, []string{"GET", "POST"}, cfg.AllowedMethods)
+	assert.Equal(t, defaultCORSHeaders, cfg.AllowedHeaders)
+}
+
+func TestConfigCORS_isAllowedOrigin(t *testing.T) {
+	cfg := ConfigCORS{
+		Enabled:        true,
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+	}
+
+	require.Empty(t, cfg.Sanitize())
+
+	testcases := []struct {
+		origin   string
+		expected bool
+	}{
+		{origin: "https://app.example.com", expected: true},
+		{origin: "HTTPS://APP.EXAMPLE.COM", expected: true},
+		{origin: "http://app.example.com", expected: false},
+		{origin: "https://api.example.com", expected: false},
+		{origin: "https://api.example.org", expected: true},
+		{origin: "https://a.b.example.org", expected: true},
+		{origin: "https://example.org", expected: false},
+		{origin: "https://.example.org", expected: false},
+		{origin: "https://evil-example.org", expected: false},
+		{origin: "null", expected: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.origin, func(t *testing.T) {
+			assert.Equal(t, tc.expected, cfg.isAllowedOrigin(tc.origin))
+		})
+	}
+}
+
+func TestConfigCORS_Middleware(t *testing.T) {
+	testcases := []struct {
+		name            string
+		cfg             ConfigCORS
+		method          string
+		headers         map[string]string
+		expectedStatus  int
+		expectedNext    bool
+		expectedHeaders map[string]string
+	}{
+		{
+			name: "disabled passes preflight through",
+			cfg: ConfigCORS{
+				Enabled: false,
+			},
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "POST",
+			},
+			expectedStatus: http.StatusTeapot,
+			expectedNext:   true,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name: "allowed preflight is answered before next",
+			cfg: ConfigCORS{
+				Enabled:          true,
+				AllowedOrigins:   []string{"https://*.example.com"},
+				AllowCredentials: true,
+				MaxAge:           10 * time.Minute,
+			},
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "Content-Type, Authorization",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedNext:   false,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, HEAD, POST, PUT, PATCH, DELETE",
+				"Access-Control-Allow-Headers":     "content-type, authorization",
+				"Access-Control-Max-Age":           "600",
+			},
+		},
+		{
+			name: "preflight with disallowed header is answered without CORS headers",
+			cfg: ConfigCORS{
+				Enabled:        true,
+				AllowedOrigins: []string{"https://app.example.com"},
+			},
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "X-Custom",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedNext:   false,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name: "preflight with disallowed method is answered without CORS headers",
+			cfg: ConfigCORS{
+				Enabled:        true,
+				AllowedOrigins: []string{"https://app.example.com"},
+				AllowedMethods: []string{"GET"},
+			},
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedNext:   false,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name: "preflight with any header allowed",
+			cfg: ConfigCORS{
+				Enabled:        true,
+				AllowedOrigins: []string{"*"},
+				AllowedHeaders: []string{"*"},
+			},
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "X-Custom",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedNext:   false,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "",
+				"Access-Control-Allow-Headers":     "x-custom",
+			},
+		},
+		{
+			name: "allowed origin sets headers before next",
+			cfg: ConfigCORS{
+				Enabled:        true,
+				AllowedOrigins: []string{"https://app.example.com"},
+				ExposedHeaders: []string{"X-Request-Id", "Retry-After"},
+			},
+			method: http.MethodGet,
+			headers: map[string]string{
+				"Origin": "https://app.example.com",
+			},
+			expectedStatus: http.StatusTeapot,
+			expectedNext:   true,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "https://app.example.com",
+				"Access-Control-Expose-Headers": "X-Request-Id, Retry-After",
+				"Vary":                          "Origin",
+			},
+		},
+		{
+			name: "disallowed origin calls next without CORS headers",
+			cfg: ConfigCORS{
+				Enabled:        true,
+				AllowedOrigins: []string{"https://app.example.com"},
+			},
+			method: http.MethodGet,
+			headers: map[string]string{
+				"Origin": "https://evil.com",
+			},
+			expectedStatus: http.StatusTeapot,
+			expectedNext:   true,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "Origin",
+			},
+		},
+		{
+			name: "same-origin request calls next without CORS headers",
+			cfg: ConfigCORS{
+				Enabled:        true,
+				AllowedOrigins: []string{"*"},
+			},
+			method:         http.MethodGet,
+			expectedStatus: http.StatusTeapot,
+			expectedNext:   true,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Empty(t, tc.cfg.Sanitize())
+
+			var called bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusTeapot)
+			})
+
+			req := httptest.NewRequest(tc.method, "/", nil)
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+
+			rec := httptest.NewRecorder()
+			tc.cfg.Middleware(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedNext, called)
+			for key, value := range tc.expectedHeaders {
+				assert.Equal(t, value, rec.Header().Get(key), key)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/mountayaapp/helix.go/event"
+
+	"golang.org/x/text/language"
+)
+
+/*
+ConfigEvent is the common configuration for building an event.Event from
+incoming HTTP requests across all HTTP server integrations (REST, GraphQL, MCP).
+*/
+type ConfigEvent struct {
+
+	// Enabled enables building an Event from every incoming HTTP request. The IP
+	// and origin of the client are found with the trusted proxies of the server,
+	// configured with ConfigProxies.
+	Enabled bool `json:"enabled"`
+}
+
+/*
+Middleware returns an HTTP middleware building an Event from every incoming
+request when enabled. The Event built is merged with the one already found in
+the request context, if any, before being stored with event.ContextWithEvent.
+Values found in the context — typically restored from the baggage propagated by
+an upstream service — take precedence over the ones read from the request, since
+the request of a service-to-service call describes the caller, not the end-user.
+
+Returns next as is when disabled, so it costs nothing per request.
+*/
+func (cfg *ConfigEvent) Middleware(next http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		// The Event restored from the baggage is kept even when it carries no name:
+		// EventFromContext only reports it as "found" when named, but any value it
+		// holds still comes from upstream and must win over the request's.
+		e, _ := event.EventFromContext(ctx)
+		mergeEvent(&e, cfg.EventFromRequest(req))
+
+		next.ServeHTTP(rw, req.WithContext(event.ContextWithEvent(ctx, e)))
+	})
+}
+
+/*
+EventFromRequest builds an Event from an HTTP request. It sets:
+
+  - IP from the direct peer, or from X-Forwarded-For / X-Real-IP when the peer
+    is a trusted proxy, as returned by ClientIP;
+  - UserAgent as is, and OS and Device parsed from it;
+  - Locale from the preferred language of Accept-Language;
+  - Campaign from the utm_* query parameters;
+  - Referrer and Page.Referrer from Referer;
+  - Page from the request URL, with the origin returned by RequestOrigin.
+*/
+func (cfg *ConfigEvent) EventFromRequest(req *http.Request) event.Event {
+	ua := req.UserAgent()
+
+	e := event.Event{
+		IP:        ClientIP(req),
+		UserAgent: ua,
+		Locale:    localeFromHeader(req.Header.Get("Accept-Language")),
+		OS:        osFromUserAgent(ua),
+		Device:    deviceFromUserAgent(ua),
+	}
+
+	query := req.URL.Query()
+	e.Campaign = event.Campaign{
+		Name:    query.Get("utm_campaign"),
+		Source:  query.Get("utm_source"),
+		Medium:  query.Get("utm_medium"),
+		Term:    query.Get("utm_term"),
+		Content: query.Get("utm_content"),
+	}
+
+	if referer := req.Referer(); referer != "" {
+		e.Referrer.URL = referer
+		if u, err := url.Parse(referer); err == nil {
+			e.Referrer.Name = u.Hostname()
+		}
+	}
+
+	e.Page = event.Page{
+		Path:     req.URL.Path,
+		Referrer: req.Referer(),
+		URL:      requestURL(req),
+	}
+
+	if req.URL.RawQuery != "" {
+		e.Page.Search = "?" + req.URL.RawQuery
+	}
+
+	return e
+}
+
+/*
+requestURL rebuilds the absolute URL of the request from its origin, as returned
+by RequestOrigin.
+*/
+func requestURL(req *http.Request) string {
+	scheme, host := RequestOrigin(req)
+	if host == "" {
+		return ""
+	}
+
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     host,
+		Path:     req.URL.Path,
+		RawQuery: req.URL.RawQuery,
+	}
+
+	return u.String()
+}
+
+/*
+localeFromHeader returns the preferred language found in an Accept-Language
+header value, or an empty string when none can be parsed.
+*/
+func localeFromHeader(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+
+	return tags[0].String()
+}
+
+/*
+mergeEvent sets every zero field of dst to the value of the same field in src.
+Nested structs are merged field by field, and map entries from src are only
+added when dst has no value for their key. Slices are taken as a whole.
+*/
+func mergeEvent(dst *event.Event, src event.Event) {
+	mergeValue(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src))
+}
+
+/*
+mergeValue recursively merges the struct src into the struct dst. See mergeEvent.
+*/
+func mergeValue(dst, src reflect.Value) {
+	for i := range dst.NumField() {
+
+		// Unexported fields, such as the extensions of the Event, can not be set by
+		// reflection, and are kept as found in dst.
+		if !dst.Type().Field(i).IsExported() {
+			continue
+		}
+
+		df := dst.Field(i)
+		sf := src.Field(i)
+
+		switch df.Kind() {
+		case reflect.Struct:
+			if df.Type() == reflect.TypeFor[time.Time]() {
+				if df.IsZero() {
+					df.Set(sf)
+				}
+
+				continue
+			}
+
+			mergeValue(df, sf)
+
+		case reflect.Map:
+			if sf.Len() == 0 {
+				continue
+			}
+
+			if df.IsNil() {
+				df.Set(reflect.MakeMap(df.Type()))
+			}
+
+			iter := sf.MapRange()
+			for iter.Next() {
+				if !df.MapIndex(iter.Key()).IsValid() {
+					df.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+
+		default:
+			if df.IsZero() {
+				df.Set(sf)
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mountayaapp/helix.go/event"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+func TestConfigEvent_EventFromRequest(t *testing.T) {
+	cfg := ConfigEvent{Enabled: true}
+
+	req := httptest.NewRequest(http.MethodGet, "http://api.tld/checkout?utm_source=newsletter&utm_medium=email&utm_campaign=launch&utm_term=shoes&utm_content=banner", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1")
+	req.Header.Set("Accept-Language", "fr-FR,fr;q=0.9,en;q=0.8")
+	req.Header.Set("Referer", "https://www.google.com/search?q=shoes")
+
+	e := cfg.EventFromRequest(req)
+
+	assert.Equal(t, "203.0.113.7", e.IP)
+	assert.Equal(t, req.UserAgent(), e.UserAgent)
+	assert.Equal(t, "fr-FR", e.Locale)
+	assert.Equal(t, event.OS{Name: "iOS", Version: "17.0"}, e.OS)
+	assert.Equal(t, event.Device{Type: "mobile", Manufacturer: "Apple", Model: "iPhone"}, e.Device)
+	assert.Equal(t, event.Campaign{
+		Name:    "launch",
+		Source:  "newsletter",
+		Medium:  "email",
+		Term:    "shoes",
+		Content: "banner",
+	}, e.Campaign)
+	assert.Equal(t, event.Referrer{
+		Name: "www.google.com",
+		URL:  "https://www.google.com/search?q=shoes",
+	}, e.Referrer)
+	assert.Equal(t, event.Page{
+		Path:     "/checkout",
+		Referrer: "https://www.google.com/search?q=shoes",
+		Search:   "?utm_source=newsletter&utm_medium=email&utm_campaign=launch&utm_term=shoes&utm_content=banner",
+		URL:      "http://api.tld/checkout?utm_source=newsletter&utm_medium=email&utm_campaign=launch&utm_term=shoes&utm_content=banner",
+	}, e.Page)
+}
+
+func TestConfigEvent_EventFromRequest_FromTrustedProxy(t *testing.T) {
+	proxies := ConfigProxies{Trusted: []string{"10.0.0.0/8"}}
+	require.Empty(t, proxies.Sanitize())
+
+	cfg := ConfigEvent{Enabled: true}
+
+	var e event.Event
+	h := proxies.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		e = cfg.EventFromRequest(req)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://internal:8080/users?page=2", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.tld")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "203.0.113.7", e.IP)
+	assert.Equal(t, "https://api.tld/users?page=2", e.Page.URL)
+}
+
+func TestConfigEvent_Middleware(t *testing.T) {
+	t.Run("disabled stores no Event", func(t *testing.T) {
+		cfg := ConfigEvent{}
+
+		var found bool
+		h := cfg.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			_, found = event.EventFromContext(req.Context())
+		}))
+
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.False(t, found)
+	})
+
+	t.Run("enabled stores the Event built", func(t *testing.T) {
+		cfg := ConfigEvent{Enabled: true}
+
+		var e event.Event
+		h := cfg.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			e, _ = event.EventFromContext(req.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, "203.0.113.7", e.IP)
+		assert.Equal(t, "/orders", e.Page.Path)
+	})
+
+	t.Run("enabled merges with the Event from baggage", func(t *testing.T) {
+		cfg := ConfigEvent{Enabled: true}
+
+		var e event.Event
+		h := cfg.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			e, _ = event.EventFromContext(req.Context())
+		}))
+
+		b, err := baggage.Parse("event.name=subscribed,event.user_id=user_123,event.ip=192.0.2.1,event.meta.source=web")
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/orders?utm_source=email", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		req = req.WithContext(baggage.ContextWithBaggage(req.Context(), b))
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		// Upstream values win, the request fills in the rest.
+		assert.Equal(t, "subscribed", e.Name)
+		assert.Equal(t, "user_123", e.UserID)
+		assert.Equal(t, "192.0.2.1", e.IP)
+		assert.Equal(t, map[string]string{"source": "web"}, e.Meta)
+		assert.Equal(t, "email", e.Campaign.Source)
+		assert.Equal(t, "/orders", e.Page.Path)
+	})
+}
+
+func TestMergeEvent(t *testing.T) {
+	dst := event.Event{
+		Name: "subscribed",
+		Meta: map[string]string{"source": "api"},
+		OS:   event.OS{Name: "Linux"},
+	}
+
+	src := event.Event{
+		Name:        "ignored",
+		UserID:      "user_123",
+		IsAnonymous: event.BoolPtr(true),
+		Meta:        map[string]string{"source": "ignored", "env": "test"},
+		OS:          event.OS{Name: "ignored", Arch: "arm64"},
+		Subscriptions: []event.Subscription{
+			{ID: "sub_001"},
+		},
+	}
+
+	mergeEvent(&dst, src)
+
+	assert.Equal(t, "subscribed", dst.Name)
+	assert.Equal(t, "user_123", dst.UserID)
+	assert.Equal(t, event.BoolPtr(true), dst.IsAnonymous)
+	assert.Equal(t, map[string]string{"source": "api", "env": "test"}, dst.Meta)
+	assert.Equal(t, event.OS{Name: "Linux", Arch: "arm64"}, dst.OS)
+	assert.Equal(t, []event.Subscription{{ID: "sub_001"}}, dst.Subscriptions)
+}
+
+// testCartExtension is an extension of the Event used to test merging Events
+// holding extensions.
+type testCartExtension struct {
+	ID string `json:"id,omitempty" baggage:"id"`
+}
+
+func TestMergeEvent_Extensions(t *testing.T) {
+	require.NoError(t, event.RegisterExtension[testCartExtension]("integration_test_cart"))
+
+	dst := event.Event{Name: "subscribed"}
+	var src event.Event
+	require.NoError(t, event.SetExtension(&src, testCartExtension{ID: "cart_1"}))
+
+	// Extensions are unexported, so they are kept as found in dst rather than
+	// merged by reflection.
+	assert.NotPanics(t, func() {
+		mergeEvent(&dst, src)
+	})
+
+	_, ok := event.ExtensionOf[testCartExtension](dst)
+	assert.False(t, ok)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/mountayaapp/helix.go/errorstack"
 	"github.com/mountayaapp/helix.go/integration"
+	"github.com/mountayaapp/helix.go/integration/compression"
 	"github.com/mountayaapp/helix.go/integration/valkey"
 
 	gqlgen "github.com/99designs/gqlgen/graphql"
@@ -87,12 +88,44 @@ type Config struct {
 	// adding a chain of middlewares.
 	Middleware func(next http.Handler) http.Handler `json:"-"`
 
+	// MaxRequestBodyBytes bounds the size of the request body, in bytes, so a
+	// client cannot stream an unbounded body into a JSON decoder. A request whose
+	// body exceeds it is rejected with a 413, whether its Content-Length announces
+	// it or a chunked body exceeds it while being read. Zero leaves request bodies
+	// unbounded.
+	//
+	// Default:
+	//
+	//   0
+	MaxRequestBodyBytes int64 `json:"max_request_body_bytes"`
+
 	// TLS configures TLS for the HTTP server. Only CertPEM and KeyPEM are taken
 	// into consideration. PEM-encoded certificate and matching private key for
 	// the server must be provided. If the certificate is signed by a certificate
 	// authority, the CertPEM should be the concatenation of the server's
 	// certificate, any intermediates, and the CA's certificate.
 	TLS integration.ConfigTLS `json:"tls"`
+
+	// Proxies configures the proxies and load balancers trusted in front of the
+	// server, whose forwarding headers are honored to find the client's IP and
+	// origin. It applies whether Event is enabled or not.
+	Proxies integration.ConfigProxies `json:"proxies"`
+
+	// Event configures building an Event from every incoming HTTP request, made
+	// available to handlers with event.EventFromContext. Values already restored
+	// from the baggage propagated by an upstream service take precedence over the
+	// ones read from the request.
+	Event integration.ConfigEvent `json:"event"`
+
+	// CORS configures Cross-Origin Resource Sharing. When enabled, preflight
+	// requests are answered before routing, and the CORS headers are written on
+	// every response to an allowed origin, including error responses.
+	CORS integration.ConfigCORS `json:"cors"`
+
+	// Compression configures compressing responses with gzip, zstd, or brotli,
+	// negotiated from the Accept-Encoding header of every request. Streamed
+	// text/event-stream responses are never compressed.
+	Compression compression.Config `json:"compression"`
 }
 
 /*
@@ -199,7 +232,17 @@ func (cfg *Config) sanitize() error {
 		}
 	}
 
+	if cfg.MaxRequestBodyBytes < 0 {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be greater than or equal to 0",
+			Path:    []any{"config", "max_request_body_bytes"},
+		})
+	}
+
 	entries = append(entries, cfg.TLS.Sanitize()...)
+	entries = append(entries, cfg.Proxies.Sanitize()...)
+	entries = append(entries, cfg.CORS.Sanitize()...)
+	entries = append(entries, cfg.Compression.Sanitize()...)
 	if len(entries) > 0 {
 		return errorstack.NewValidation(entries...)
 	}
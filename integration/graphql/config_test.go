@@ -316,6 +316,22 @@ func TestConfig_Sanitize(t *testing.T) {
 			},
 			err: errorstack.NewValidation(schemaEntry),
 		},
+		{
+			name: "negative max request body bytes returns error",
+			before: Config{
+				MaxRequestBodyBytes: -1,
+			},
+			after: Config{
+				Address:             ":8080",
+				Path:                "/graphql",
+				QueryCacheSize:      defaultQueryCacheSize,
+				MaxRequestBodyBytes: -1,
+			},
+			err: errorstack.NewValidation(schemaEntry, errorstack.Entry{
+				Message: "Must be greater than or equal to 0",
+				Path:    []any{"config", "max_request_body_bytes"},
+			}),
+		},
 	}
 
 	for _, tc := range testcases {
@@ -359,3 +375,42 @@ func TestConfig_Sanitize_QueryCacheSize(t *testing.T) {
 		})
 	}
 }
+
+/*
+TestConfig_Sanitize_Proxies only asserts on the error returned: the table above
+compares whole configs, which cannot account for the trusted proxies parsed into
+the unexported state of integration.ConfigProxies. Schema is left unset, so its
+entry is always expected first.
+*/
+func TestConfig_Sanitize_Proxies(t *testing.T) {
+	testcases := []struct {
+		name    string
+		proxies integration.ConfigProxies
+		err     error
+	}{
+		{
+			name:    "valid trusted proxies are valid",
+			proxies: integration.ConfigProxies{Trusted: []string{"10.0.0.0/8"}},
+			err:     errorstack.NewValidation(schemaEntry),
+		},
+		{
+			name:    "invalid trusted proxy returns error with Event disabled",
+			proxies: integration.ConfigProxies{Trusted: []string{"not-an-ip"}},
+			err: errorstack.NewValidation(
+				schemaEntry,
+				errorstack.Entry{
+					Message: "Must be a valid IP address or CIDR range",
+					Path:    []any{"config", "proxies", "trusted", 0},
+				},
+			),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{Proxies: tc.proxies}
+
+			assert.Equal(t, tc.err, cfg.sanitize())
+		})
+	}
+}
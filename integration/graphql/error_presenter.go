@@ -7,6 +7,7 @@ import (
 	"maps"
 
 	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/internal/locales"
 
 	gqlgen "github.com/99designs/gqlgen/graphql"
 	"github.com/vektah/gqlparser/v2/ast"
@@ -29,6 +30,9 @@ entries[1:] into gqlgen's error collector via gqlgen.AddError, so the
 canonical errors[] array always has one element per Entry — no
 additional_errors aggregation.
 
+Entries carrying a message ID (see errorstack.WithMessageID) are localized
+for the language of the request, keeping their English message as fallback.
+
 Top-level *errorstack.Error.Extensions are forwarded onto the response-level
 extensions map via gqlgen.RegisterExtension, mirroring how REST surfaces
 err.SetExtension(k, v) at the response top level. Duplicate keys already
@@ -43,8 +47,9 @@ func errorPresenter(ctx context.Context, err error) *gqlerror.Error {
 	}
 
 	resolverPath := gqlErr.Path
+	entries := locales.Localize(locales.LanguageFromContext(ctx), stack.Entries)
 
-	first := stack.Entries[0]
+	first := entries[0]
 	gqlErr.Message = first.Message
 
 	if len(first.Path) > 0 {
@@ -57,7 +62,7 @@ func errorPresenter(ctx context.Context, err error) *gqlerror.Error {
 
 	maps.Copy(gqlErr.Extensions, first.Extensions)
 
-	for _, entry := range stack.Entries[1:] {
+	for _, entry := range entries[1:] {
 		extra := &gqlerror.Error{
 			Message:    entry.Message,
 			Extensions: map[string]any{},
@@ -111,7 +116,8 @@ This is the canonical path for resolvers to surface multi-entry validation
 errors so each appears as its own element of the spec errors[] array.
 
 Each emitted gqlerror's spec-level Path is the entry's Path when set, falling
-back to the gqlgen-derived resolver path when the entry has none. Callers
+back to the gqlgen-derived resolver path when the entry has none. Entries are
+localized the same way as in errorPresenter. Callers
 should typically return nil (or a sentinel) from the resolver after calling
 AddErrors to avoid double-reporting via errorPresenter.
 */
@@ -121,7 +127,7 @@ func AddErrors(ctx context.Context, err *errorstack.Error) {
 	}
 
 	resolverPath := gqlgen.GetPath(ctx)
-	for _, entry := range err.Entries {
+	for _, entry := range locales.Localize(locales.LanguageFromContext(ctx), err.Entries) {
 		gqlErr := &gqlerror.Error{
 			Message:    entry.Message,
 			Extensions: map[string]any{},
@@ -3,15 +3,19 @@ package graphql
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/internal/locales"
 
 	gqlgen "github.com/99designs/gqlgen/graphql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
+	"golang.org/x/text/language"
 )
 
 func TestErrorPresenter_PlainErrorPassesThrough(t *testing.T) {
@@ -34,6 +38,43 @@ func TestErrorPresenter_ErrorstackSingleEntry(t *testing.T) {
 	assert.Nil(t, gqlErr.Extensions["additional_errors"])
 }
 
+/*
+requestContext returns the context of a request sent with the Accept-Language
+header passed, once handled by the locales middleware wired in New.
+*/
+func requestContext(t *testing.T, acceptLanguage string) context.Context {
+	t.Helper()
+
+	var ctx context.Context
+	h := locales.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		ctx = req.Context()
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	req.Header.Set("Accept-Language", acceptLanguage)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	return ctx
+}
+
+func TestErrorPresenter_LocalizedByMessageID(t *testing.T) {
+	AddOrEditMessages(language.Amharic, map[string]string{
+		"min_length": "am-min-length-{min}",
+	})
+
+	err := errorstack.New("Must be at least 8 characters long",
+		errorstack.WithCode(errorstack.CodeBadRequest),
+		errorstack.WithMessageID("min_length", map[string]any{"min": 8}),
+	)
+
+	gqlErr := errorPresenter(requestContext(t, "am"), err)
+	assert.Equal(t, "am-min-length-8", gqlErr.Message)
+
+	gqlErr = errorPresenter(requestContext(t, "en"), err)
+	assert.Equal(t, "Must be at least 8 characters long", gqlErr.Message)
+	assert.Equal(t, "Must be at least 8 characters long", err.Entries[0].Message, "error passed must not be mutated")
+}
+
 func TestErrorPresenter_EntryPath_PromotesToSpecLevel(t *testing.T) {
 	// User-supplied Entry.Path must be promoted to the spec-level
 	// errors[].path slot, not buried under extensions.path. This mirrors
@@ -4,6 +4,7 @@ go 1.25.4
 
 require (
 	github.com/99designs/gqlgen v0.17.94
+	github.com/klauspost/compress v1.19.1
 	github.com/mountayaapp/helix.go v0.28.0
 	github.com/mountayaapp/helix.go/integration/valkey v0.28.0
 	github.com/stretchr/testify v1.11.1
@@ -14,6 +15,7 @@ require (
 
 require (
 	github.com/agnivade/levenshtein v1.2.1 // indirect
+	github.com/andybalholm/brotli v1.2.6 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/cenkalti/backoff/v5 v5.0.3 // indirect
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
@@ -3,6 +3,7 @@ package graphql
 import (
 	"net/http"
 
+	"github.com/mountayaapp/helix.go/internal/locales"
 	"github.com/mountayaapp/helix.go/service"
 
 	"github.com/99designs/gqlgen/graphql/handler"
@@ -53,10 +54,11 @@ func New(svc *service.Service, cfg Config) error {
 	// Create the gqlgen handler with the executable schema and add the POST
 	// transport for handling GraphQL requests. Wire the errorstack-aware error
 	// presenter so resolver errors carry path/extensions consistently with the
-	// rest of helix.go.
+	// rest of helix.go, and report the panics of resolvers gqlgen recovers.
 	gqlHandler := handler.New(cfg.Schema)
 	gqlHandler.AddTransport(transport.POST{})
 	gqlHandler.SetErrorPresenter(errorPresenter)
+	gqlHandler.SetRecoverFunc(g.recoverResolver)
 
 	// Cache parsed documents. handler.New installs no caches at all — only the
 	// deprecated NewDefaultServer does — so without this every request re-lexes,
@@ -81,10 +83,12 @@ func New(svc *service.Service, cfg Config) error {
 
 	// Build the HTTP serve mux with the health, GraphQL (POST + OPTIONS for
 	// CORS preflight), method not allowed, and catch-all not found endpoints.
+	// The preferred language of GraphQL requests is stored in their context so
+	// the error presenter can localize entries carrying a message ID.
 	g.mux = http.NewServeMux()
 	g.mux.HandleFunc("GET /health", g.handlerLiveness)
 	g.mux.HandleFunc("GET /ready", g.handlerReadiness)
-	g.mux.Handle("POST "+cfg.Path, gqlHandler)
+	g.mux.Handle("POST "+cfg.Path, locales.Middleware(gqlHandler))
 	g.mux.Handle("OPTIONS "+cfg.Path, gqlHandler)
 	g.mux.HandleFunc(cfg.Path, g.handlerMethodNotAllowed)
 	if cfg.GraphiQL.Enabled {
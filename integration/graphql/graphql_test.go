@@ -1,11 +1,20 @@
 package graphql
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/event"
+	"github.com/mountayaapp/helix.go/integration"
+	"github.com/mountayaapp/helix.go/integration/compression"
+
+	"github.com/klauspost/compress/gzip"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func newTestMux() *graphql {
@@ -181,3 +190,185 @@ func TestMux_HeadGraphQL_ReturnsMethodNotAllowed(t *testing.T) {
 
 	assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
 }
+
+func TestMux_Handler_EventAvailableToMiddleware(t *testing.T) {
+	g := newTestMux()
+	g.config.Event = integration.ConfigEvent{Enabled: true}
+
+	var e event.Event
+	var found bool
+	g.config.Middleware = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			e, found = event.EventFromContext(req.Context())
+			next.ServeHTTP(rw, req)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	req.RemoteAddr = "203.0.113.7:52100"
+	req.Header.Set("Accept-Language", "fr-FR,fr;q=0.9")
+	rw := httptest.NewRecorder()
+	g.handler().ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.True(t, found)
+	assert.Equal(t, "203.0.113.7", e.IP)
+	assert.Equal(t, "fr-FR", e.Locale)
+	assert.Equal(t, "/graphql", e.Page.Path)
+}
+
+func TestMux_Handler_CORSPreflightAnsweredBeforeRouting(t *testing.T) {
+	g := newTestMux()
+	g.config.CORS = integration.ConfigCORS{
+		Enabled:          true,
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+	}
+
+	require.Empty(t, g.config.CORS.Sanitize())
+
+	req := httptest.NewRequest(http.MethodOptions, "/graphql", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	rw := httptest.NewRecorder()
+	g.handler().ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusNoContent, rw.Code)
+	assert.Equal(t, "https://app.example.com", rw.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rw.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "content-type", rw.Header().Get("Access-Control-Allow-Headers"))
+}
+
+func TestMux_Handler_CORSHeadersOnErrors(t *testing.T) {
+	g := newTestMux()
+	g.config.CORS = integration.ConfigCORS{
+		Enabled:        true,
+		AllowedOrigins: []string{"https://app.example.com"},
+	}
+
+	require.Empty(t, g.config.CORS.Sanitize())
+
+	req := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rw := httptest.NewRecorder()
+	g.handler().ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+	assert.Equal(t, "https://app.example.com", rw.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestMux_Handler_CompressionOnErrors(t *testing.T) {
+	g := newTestMux()
+	g.config.Compression = compression.Config{
+		Enabled: true,
+		MinSize: 1,
+	}
+
+	require.Empty(t, g.config.Compression.Sanitize())
+
+	req := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+	req.Header.Set("Accept-Encoding", "br;q=0.5, gzip")
+	rw := httptest.NewRecorder()
+	g.handler().ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+	assert.Equal(t, "gzip", rw.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", rw.Header().Get("Vary"))
+
+	gr, err := gzip.NewReader(rw.Body)
+	require.NoError(t, err)
+
+	decoded, err := io.ReadAll(gr)
+	require.NoError(t, err)
+	assert.Contains(t, string(decoded), `"code":"METHOD_NOT_ALLOWED"`)
+}
+
+func TestMux_Handler_MaxRequestBodyBytes(t *testing.T) {
+	g := newTestMux()
+	g.config.MaxRequestBodyBytes = 16
+
+	// Replace the GraphQL endpoint with one reading the body like gqlgen does,
+	// answering with its own 400 when the body can not be read.
+	g.mux = http.NewServeMux()
+	g.mux.HandleFunc("POST "+g.config.Path, func(rw http.ResponseWriter, req *http.Request) {
+		if _, err := io.ReadAll(req.Body); err != nil {
+			http.Error(rw, "json request body could not be decoded", http.StatusBadRequest)
+			return
+		}
+
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	testcases := []struct {
+		name           string
+		body           io.Reader
+		expectedStatus int
+	}{
+		{
+			name:           "within limit",
+			body:           strings.NewReader(`{"query":"{a}"}`),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Content-Length exceeds limit",
+			body:           strings.NewReader(`{"query":"{ me { id } }"}`),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "chunked body exceeds limit mid-read",
+			body:           io.MultiReader(strings.NewReader(`{"query":"{ me { id } }"}`)),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/graphql", tc.body)
+			rw := httptest.NewRecorder()
+			g.handler().ServeHTTP(rw, req)
+
+			assert.Equal(t, tc.expectedStatus, rw.Code)
+			if tc.expectedStatus == http.StatusRequestEntityTooLarge {
+				assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+				assert.JSONEq(t, `{"errors":[{"message":"Payload exceeds size limit","extensions":{"code":"PAYLOAD_TOO_LARGE"}}]}`, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestMux_Handler_RecoversPanic(t *testing.T) {
+	g := newTestMux()
+
+	// Replace the GraphQL endpoint with one panicking outside of the recovery of
+	// gqlgen, such as in its transport.
+	g.mux = http.NewServeMux()
+	g.mux.HandleFunc("POST "+g.config.Path, func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("X-Custom", "value")
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	rw := httptest.NewRecorder()
+
+	require.NotPanics(t, func() {
+		g.handler().ServeHTTP(rw, req)
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+	assert.Empty(t, rw.Header().Get("X-Custom"))
+	assert.JSONEq(t, `{"errors":[{"message":"Internal server error","extensions":{"code":"INTERNAL_ERROR"}}]}`, rw.Body.String())
+}
+
+func TestGraphQL_RecoverResolver(t *testing.T) {
+	g := newTestMux()
+
+	err := g.recoverResolver(t.Context(), "boom")
+
+	var stack *errorstack.Error
+	require.ErrorAs(t, err, &stack)
+	require.Len(t, stack.Entries, 1)
+	assert.Equal(t, "Internal server error", stack.Entries[0].Message)
+	assert.Equal(t, errorstack.CodeInternalError, errorstack.CodeOf(err))
+}
@@ -27,30 +27,7 @@ Start starts the HTTP server of the GraphQL integration.
 */
 func (g *graphql) Start(ctx context.Context) error {
 
-	// Wrap the built-in HTTP handler with the one given by the user, if applicable.
-	// Skip user middleware for the health endpoint so it always responds without
-	// requiring authentication or other service-level checks.
-	var h http.Handler = g.mux
-	if g.config.Middleware != nil {
-		wrapped := g.config.Middleware(g.mux)
-		h = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-			if req.URL.Path == "/health" || req.URL.Path == "/ready" {
-				g.mux.ServeHTTP(rw, req)
-				return
-			}
-
-			wrapped.ServeHTTP(rw, req)
-		})
-	}
-
-	// Wrap the handler previously built with the one designed for OpenTelemetry
-	// traces.
-	h = otelhttp.NewHandler(h, "",
-		otelhttp.WithMessageEvents(otelhttp.ReadEvents, otelhttp.WriteEvents),
-		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
-			return r.Method + " " + r.URL.Path
-		}),
-	)
+	h := g.handler()
 
 	// Create the HTTP server with the given configuration and the handler built.
 	g.server = &http.Server{
@@ -80,6 +57,79 @@ func (g *graphql) Start(ctx context.Context) error {
 	return nil
 }
 
+/*
+handler returns the HTTP handler served by the HTTP server of the GraphQL
+integration, wrapping the built-in one with the user's middleware, the request
+body limit, the panic recovery, the Event and trusted proxies middleware, the
+compression and CORS middleware, and the OpenTelemetry handler.
+*/
+func (g *graphql) handler() http.Handler {
+
+	// Wrap the built-in HTTP handler with the one given by the user, if applicable.
+	// Skip user middleware for the health endpoint so it always responds without
+	// requiring authentication or other service-level checks.
+	var h http.Handler = g.mux
+	if g.config.Middleware != nil {
+		wrapped := g.config.Middleware(g.mux)
+		h = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/health" || req.URL.Path == "/ready" {
+				g.mux.ServeHTTP(rw, req)
+				return
+			}
+
+			wrapped.ServeHTTP(rw, req)
+		})
+	}
+
+	// Limit the size of request bodies, if configured. This is applied outside of
+	// the user's middleware so a body exceeding the limit is rejected with the
+	// same 413 whichever layer reads it, the user's middleware or gqlgen.
+	h = integration.LimitRequestBody(h, g.config.MaxRequestBodyBytes, func(rw http.ResponseWriter, req *http.Request) {
+		writeError(rw, req, http.StatusRequestEntityTooLarge)
+	})
+
+	// Recover panics of gqlgen outside of its own recovery and of the user's
+	// middleware, writing a 500 in place of the response. This is applied inside
+	// the Event middleware so the panic is logged with the Event, and inside the
+	// OpenTelemetry handler so it is recorded on the request's span.
+	h = integration.RecoverPanic(h, g.logContext, func(rw http.ResponseWriter, req *http.Request) {
+		writeError(rw, req, http.StatusInternalServerError)
+	})
+
+	// Build an Event from every incoming request, if enabled. This is applied
+	// outside of the user's middleware so it can read and enrich the Event, and
+	// inside the OpenTelemetry handler so the Event restored from the baggage
+	// propagated by an upstream service is already in the request context.
+	h = g.config.Event.Middleware(h)
+
+	// Make the trusted proxies available to the handlers and middleware finding
+	// the client's IP and origin, including the Event middleware, whether the
+	// Event is enabled or not.
+	h = g.config.Proxies.Middleware(h)
+
+	// Compress responses, if enabled. This is applied outside of the user's
+	// middleware so error responses are compressed as well, and inside the CORS
+	// middleware so preflight requests are answered without being buffered.
+	h = g.config.Compression.Middleware(h)
+
+	// Handle CORS, if enabled. This is applied outside of the user's middleware
+	// and before routing, so preflight requests are answered without requiring
+	// authentication, and so the CORS headers are set on every response,
+	// including errors written by the router and the user's middleware.
+	h = g.config.CORS.Middleware(h)
+
+	// Wrap the handler previously built with the one designed for OpenTelemetry
+	// traces.
+	h = otelhttp.NewHandler(h, "",
+		otelhttp.WithMessageEvents(otelhttp.ReadEvents, otelhttp.WriteEvents),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}),
+	)
+
+	return h
+}
+
 /*
 Stop tries to gracefully stop the HTTP server.
 */
@@ -0,0 +1,39 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/integration"
+	"github.com/mountayaapp/helix.go/internal/locales"
+	"github.com/mountayaapp/helix.go/service"
+)
+
+/*
+recoverResolver is the gqlgen recover function, called with the value recovered
+from the panic of a resolver. The panic is reported with integration.ReportPanic
+rather than printed to stderr by gqlgen, and returned as a localized internal
+error the error presenter renders in the errors of the response.
+*/
+func (g *graphql) recoverResolver(ctx context.Context, recovered any) error {
+	integration.ReportPanic(g.logContext(ctx), recovered, debug.Stack())
+
+	return errorstack.New(
+		locales.MessageFor(locales.LanguageFromContext(ctx), http.StatusInternalServerError),
+		errorstack.WithCode(errorstack.CodeInternalError),
+	)
+}
+
+/*
+logContext returns a copy of ctx enriched with the Service's logger and tracer,
+so logs written from a request context are sent with the Service's ones.
+*/
+func (g *graphql) logContext(ctx context.Context) context.Context {
+	if g.svc != nil {
+		ctx = service.Context(g.svc, ctx)
+	}
+
+	return ctx
+}
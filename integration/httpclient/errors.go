@@ -0,0 +1,191 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/internal/locales"
+)
+
+/*
+ExtensionUpstream is the key of the top-level extension set on the errors built
+by the client, holding the Name of the client that received them.
+*/
+const ExtensionUpstream = "upstream"
+
+/*
+maxEnvelopeSize is the maximum size of a response body decoded as an errorstack
+envelope by ErrorFromResponse.
+*/
+const maxEnvelopeSize = 1 << 20
+
+/*
+upstreamKey is the context key of the Name of the client, stored in the context
+of every request sent so ErrorFromResponse can retrieve it from the response.
+*/
+type upstreamKey struct{}
+
+/*
+ErrorFromResponse returns an *errorstack.Error for a response with a non-2xx
+status code, and nil otherwise.
+
+When the body of the response is the errorstack envelope returned by helix
+services (or an RFC 9457 problem carrying an "errors" member), the error is
+decoded from it: entries keep their message, path, and code, and top-level
+extensions are preserved. Otherwise, a response with a 4xx or 5xx status code
+returns an error whose code is mapped from the status, so REST and GraphQL
+responses get the right status without any mapping in handlers:
+
+  - a 4xx status keeps its code (e.g. CodeNotFound for 404), unknown ones being
+    mapped to CodeBadRequest;
+  - 503 and 504 are mapped to CodeServiceUnavailable and CodeGatewayTimeout;
+  - any other 5xx status is mapped to CodeBadGateway, since the failure is the
+    upstream's.
+
+A 3xx response without envelope returns nil. The top-level extension
+ExtensionUpstream is set to the Name of the client that sent the request, and
+the Retry-After header of the response, if any, is kept as the retry delay of
+the error (see errorstack.WithRetryAfter).
+
+The body is read to be decoded, but is not closed: it is replaced with a reader
+returning the same content, so the caller can still read it and must still
+close it.
+*/
+func ErrorFromResponse(resp *http.Response) error {
+	if resp == nil || (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return nil
+	}
+
+	err := decodeEnvelope(resp)
+	if err == nil {
+		if resp.StatusCode < 400 {
+			return nil
+		}
+
+		message := locales.Message(nil, resp.StatusCode)
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+
+		err = errorstack.New(message, errorstack.WithCode(codeOfStatus(resp.StatusCode)))
+	}
+
+	if after := retryAfterOf(resp); after > 0 {
+		err.Entries[0].RetryAfter = after
+	}
+
+	if resp.Request != nil {
+		if name, ok := resp.Request.Context().Value(upstreamKey{}).(string); ok && name != "" {
+			err.SetExtension(ExtensionUpstream, name)
+		}
+	}
+
+	return err
+}
+
+/*
+retryAfterOf returns the delay of the Retry-After header of resp, given either
+in seconds or as an HTTP date. Returns zero if the header is missing or invalid.
+*/
+func retryAfterOf(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+/*
+decodeEnvelope decodes the body of resp as an errorstack envelope. Returns nil
+if the body is empty, too large, or not an envelope. The body read is replaced
+with a reader returning the same content, closing the original body.
+*/
+func decodeEnvelope(resp *http.Response) *errorstack.Error {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return nil
+	}
+
+	data, readErr := io.ReadAll(io.LimitReader(resp.Body, maxEnvelopeSize+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+
+	if readErr != nil || len(data) > maxEnvelopeSize {
+		return nil
+	}
+
+	var decoded errorstack.Error
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil
+	}
+
+	return &decoded
+}
+
+/*
+codeOfStatus returns the errorstack code of an upstream 4xx or 5xx status code.
+*/
+func codeOfStatus(status int) string {
+	switch {
+	case status == http.StatusServiceUnavailable:
+		return errorstack.CodeServiceUnavailable
+
+	case status == http.StatusGatewayTimeout:
+		return errorstack.CodeGatewayTimeout
+
+	case status >= 500:
+		return errorstack.CodeBadGateway
+	}
+
+	if code := errorstack.HTTPStatusToCode(status); code != errorstack.CodeInternalError {
+		return code
+	}
+
+	return errorstack.CodeBadRequest
+}
+
+/*
+mapTransportError wraps a transport error with errorstack.Wrap: timeouts carry
+CodeGatewayTimeout, cancellations CodeInternalError, and any other error
+CodeBadGateway. The top-level extension ExtensionUpstream is set to name. The
+transport error is preserved in the chain for errors.Is/As support. Returns nil
+if err is nil.
+*/
+func mapTransportError(err error, name string) error {
+	if err == nil {
+		return nil
+	}
+
+	code, message := errorstack.CodeBadGateway, "Endpoint is not reachable"
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		code, message = errorstack.CodeGatewayTimeout, "Endpoint timed out"
+
+	case errors.Is(err, context.Canceled):
+		code, message = errorstack.CodeInternalError, "Failed to execute request"
+	}
+
+	wrapped := errorstack.Wrap(err, message, errorstack.WithCode(code))
+	wrapped.(*errorstack.Error).SetExtension(ExtensionUpstream, name)
+	return wrapped
+}
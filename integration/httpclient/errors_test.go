@@ -0,0 +1,156 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorFromResponse(t *testing.T) {
+	testcases := []struct {
+		status          int
+		expectedCode    string
+		expectedMessage string
+	}{
+		{http.StatusBadRequest, errorstack.CodeBadRequest, "Request is invalid"},
+		{http.StatusNotFound, errorstack.CodeNotFound, "Resource does not exist"},
+		{http.StatusConflict, errorstack.CodeConflict, "Resource conflicts with current state"},
+		{http.StatusUnprocessableEntity, errorstack.CodeBadRequest, "Unprocessable Entity"},
+		{http.StatusInternalServerError, errorstack.CodeBadGateway, "Internal server error"},
+		{http.StatusServiceUnavailable, errorstack.CodeServiceUnavailable, "Service is temporarily unavailable"},
+		{http.StatusGatewayTimeout, errorstack.CodeGatewayTimeout, "Upstream gateway timed out"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			err := ErrorFromResponse(&http.Response{StatusCode: tc.status})
+
+			var stack *errorstack.Error
+			require.ErrorAs(t, err, &stack)
+			assert.Equal(t, tc.expectedCode, errorstack.CodeOf(err))
+			assert.Equal(t, tc.expectedMessage, stack.Entries[0].Message)
+		})
+	}
+}
+
+func TestErrorFromResponse_Success(t *testing.T) {
+	assert.NoError(t, ErrorFromResponse(nil))
+	assert.NoError(t, ErrorFromResponse(&http.Response{StatusCode: http.StatusOK}))
+	assert.NoError(t, ErrorFromResponse(&http.Response{StatusCode: http.StatusNotModified}))
+}
+
+func TestErrorFromResponse_DecodesEnvelope(t *testing.T) {
+	url, _ := newServer(t, func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte(`{
+			"errors": [
+				{"message": "Must be set", "path": ["body", "items", 0, "name"], "extensions": {"code": "VALIDATION_FAILED"}},
+				{"message": "Must be a valid email address", "path": ["body", "email"], "extensions": {"code": "VALIDATION_FAILED"}}
+			],
+			"extensions": {"request_id": "req_123"}
+		}`))
+	})
+	conn := newConn([]string{url})
+
+	resp, err := conn.Get(context.Background(), "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	err = ErrorFromResponse(resp)
+
+	var stack *errorstack.Error
+	require.ErrorAs(t, err, &stack)
+	require.Len(t, stack.Entries, 2)
+	assert.Equal(t, []any{"body", "items", 0, "name"}, stack.Entries[0].Path)
+	assert.Equal(t, "Must be a valid email address", stack.Entries[1].Message)
+	assert.Equal(t, errorstack.CodeValidationFailed, errorstack.CodeOf(err))
+	assert.Equal(t, map[string]any{"request_id": "req_123", ExtensionUpstream: "test"}, stack.Extensions)
+
+	// The body can still be read by the caller.
+	body, readErr := io.ReadAll(resp.Body)
+	require.NoError(t, readErr)
+	assert.Contains(t, string(body), "Must be set")
+}
+
+func TestErrorFromResponse_RetryAfter(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+	}
+
+	err := ErrorFromResponse(resp)
+
+	var stack *errorstack.Error
+	require.ErrorAs(t, err, &stack)
+	assert.Equal(t, errorstack.CodeTooManyRequests, errorstack.CodeOf(err))
+	assert.Equal(t, 30*time.Second, stack.RetryAfter())
+}
+
+func TestErrorFromResponse_NotAnEnvelope(t *testing.T) {
+	url, _ := newServer(t, func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+		rw.Write([]byte("404 page not found"))
+	})
+	conn := newConn([]string{url})
+
+	resp, err := conn.Get(context.Background(), "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	err = ErrorFromResponse(resp)
+
+	var stack *errorstack.Error
+	require.ErrorAs(t, err, &stack)
+	assert.Equal(t, errorstack.CodeNotFound, errorstack.CodeOf(err))
+	assert.Equal(t, "test", stack.Extensions[ExtensionUpstream])
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "404 page not found", string(body))
+}
+
+func TestDo_TransportErrorCodes(t *testing.T) {
+	t.Run("unreachable", func(t *testing.T) {
+		conn := newConn([]string{deadURL(t)})
+
+		_, err := conn.Get(context.Background(), "/")
+		assert.Equal(t, errorstack.CodeBadGateway, errorstack.CodeOf(err))
+
+		var stack *errorstack.Error
+		require.ErrorAs(t, err, &stack)
+		assert.Equal(t, "test", stack.Extensions[ExtensionUpstream])
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		url, _ := newServer(t, func(rw http.ResponseWriter, _ *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+			rw.WriteHeader(http.StatusOK)
+		})
+		conn := newConn([]string{url})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := conn.Get(ctx, "/")
+		assert.Equal(t, errorstack.CodeGatewayTimeout, errorstack.CodeOf(err))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		conn := newConn([]string{deadURL(t), deadURL(t)})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := conn.Get(ctx, "/")
+		assert.Equal(t, errorstack.CodeInternalError, errorstack.CodeOf(err))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/mountayaapp/helix.go/errorstack"
 	"github.com/mountayaapp/helix.go/service"
 	"github.com/mountayaapp/helix.go/telemetry/trace"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 /*
@@ -17,6 +20,7 @@ Pre-computed span names to avoid allocations on every call.
 */
 const (
 	spanRequest = humanized + ": Request"
+	spanAttempt = humanized + ": Attempt"
 	spanStatus  = humanized + ": Status"
 )
 
@@ -36,7 +40,9 @@ type HTTPClient interface {
 	// client's configured default Headers.
 	//
 	// It returns the raw response: the caller is responsible for closing resp.Body
-	// and decoding the payload.
+	// and decoding the payload. Use ErrorFromResponse to turn a non-2xx response
+	// into an error. Transport errors carry errorstack.CodeBadGateway, or
+	// errorstack.CodeGatewayTimeout on timeouts.
 	Do(ctx context.Context, method, path string, body []byte, opts ...RequestOption) (*http.Response, error)
 
 	// Get is a convenience for a GET request. The path may carry a query string.
@@ -114,15 +120,28 @@ func Connect(svc *service.Service, cfg Config) (HTTPClient, error) {
 attempt builds and sends a single request to a specific endpoint, applying each
 RequestOption first and then the configured default headers when not already
 present on the request - so per-request options take precedence over defaults.
+
+Every attempt runs in its own child span, whose context is injected in the
+request headers through the global propagator (traceparent, tracestate, and
+baggage). The Event found in the context, if any, is serialized into the baggage
+by trace.Start, so the callee can restore it.
 */
 func (conn *connection) attempt(ctx context.Context, endpoint, method, path string, body []byte, opts ...RequestOption) (*http.Response, error) {
+	ctx, span := trace.Start(ctx, trace.SpanKindClient, spanAttempt)
+	defer span.End()
+
 	var reader io.Reader
 	if body != nil {
 		reader = bytes.NewReader(body)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, reader)
+	// The Name of the client is stored in the request context so
+	// ErrorFromResponse can mark the errors it returns with it.
+	reqCtx := context.WithValue(ctx, upstreamKey{}, conn.config.Name)
+	req, err := http.NewRequestWithContext(reqCtx, method, endpoint+path, reader)
 	if err != nil {
+		span.RecordError("failed to build request", err)
+		setRequestAttributes(span, endpoint, method, 0)
 		return nil, err
 	}
 
@@ -136,7 +155,15 @@ func (conn *connection) attempt(ctx context.Context, endpoint, method, path stri
 		}
 	}
 
-	return conn.client.Do(req)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := conn.client.Do(req)
+	if err != nil {
+		span.RecordError("failed to execute request", err)
+	}
+
+	setRequestAttributes(span, endpoint, method, statusOf(resp))
+	return resp, err
 }
 
 /*
@@ -154,7 +181,7 @@ func (conn *connection) Do(ctx context.Context, method, path string, body []byte
 		}
 
 		setRequestAttributes(span, conn.endpoints[0], method, statusOf(resp))
-		return resp, err
+		return resp, mapTransportError(err, conn.config.Name)
 	}
 
 	start := conn.index.Add(1) - 1
@@ -173,7 +200,7 @@ func (conn *connection) Do(ctx context.Context, method, path string, body []byte
 		if err := ctx.Err(); err != nil {
 			drainClose(lastResp)
 			span.RecordError("failed to execute request", err)
-			return nil, err
+			return nil, mapTransportError(err, conn.config.Name)
 		}
 
 		endpoint = conn.endpoints[(start+i)%count]
@@ -211,7 +238,7 @@ func (conn *connection) Do(ctx context.Context, method, path string, body []byte
 
 	span.RecordError("failed to execute request", lastErr)
 	setRequestAttributes(span, endpoint, method, 0)
-	return nil, lastErr
+	return nil, mapTransportError(lastErr, conn.config.Name)
 }
 
 /*
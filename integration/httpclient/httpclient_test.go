@@ -14,11 +14,16 @@ import (
 	"time"
 
 	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/event"
 	"github.com/mountayaapp/helix.go/integration"
 	"github.com/mountayaapp/helix.go/service"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 func init() {
@@ -199,6 +204,129 @@ func TestDo_WithHeaderForwardedAcrossFailover(t *testing.T) {
 	assert.Equal(t, "Bearer token", resp.Header.Get("X-Echo-Auth"))
 }
 
+// propagationContext registers the same global propagator as service.New and
+// returns a context carrying a valid remote span context, so the no-op spans
+// started by the client keep its trace ID and traceparent can be asserted.
+func propagationContext(t *testing.T) (context.Context, oteltrace.TraceID) {
+	t.Helper()
+
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(
+		propagation.NewCompositeTextMapPropagator(propagation.Baggage{}, propagation.TraceContext{}),
+	)
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(previous)
+	})
+
+	traceID := oteltrace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	sc := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     oteltrace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: oteltrace.FlagsSampled,
+		Remote:     true,
+	})
+
+	return oteltrace.ContextWithRemoteSpanContext(context.Background(), sc), traceID
+}
+
+func TestDo_InjectsTraceContext(t *testing.T) {
+	ctx, traceID := propagationContext(t)
+
+	var traceparent string
+	url, _ := newServer(t, func(rw http.ResponseWriter, req *http.Request) {
+		traceparent = req.Header.Get("traceparent")
+		rw.WriteHeader(http.StatusOK)
+	})
+	conn := newConn([]string{url})
+
+	resp, err := conn.Get(ctx, "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.NotEmpty(t, traceparent)
+	assert.Contains(t, traceparent, traceID.String())
+}
+
+func TestDo_InjectsEventBaggage(t *testing.T) {
+	ctx, _ := propagationContext(t)
+	ctx = event.ContextWithEvent(ctx, event.Event{
+		Name:   "subscribed",
+		UserID: "user_123",
+		App:    event.App{Name: "my-app"},
+	})
+
+	var header string
+	url, _ := newServer(t, func(rw http.ResponseWriter, req *http.Request) {
+		header = req.Header.Get("baggage")
+		rw.WriteHeader(http.StatusOK)
+	})
+	conn := newConn([]string{url})
+
+	resp, err := conn.Get(ctx, "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	b, err := baggage.Parse(header)
+	require.NoError(t, err)
+
+	// The callee restores the same Event from the propagated baggage.
+	e, ok := event.EventFromContext(baggage.ContextWithBaggage(context.Background(), b))
+	require.True(t, ok)
+	assert.Equal(t, "subscribed", e.Name)
+	assert.Equal(t, "user_123", e.UserID)
+	assert.Equal(t, "my-app", e.App.Name)
+}
+
+func TestDo_NoEventInjectsNoBaggage(t *testing.T) {
+	ctx, _ := propagationContext(t)
+
+	var header string
+	url, _ := newServer(t, func(rw http.ResponseWriter, req *http.Request) {
+		header = req.Header.Get("baggage")
+		rw.WriteHeader(http.StatusOK)
+	})
+	conn := newConn([]string{url})
+
+	resp, err := conn.Get(ctx, "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Empty(t, header)
+}
+
+func TestDo_InjectsTraceContextOnEveryFailoverAttempt(t *testing.T) {
+	ctx, traceID := propagationContext(t)
+	ctx = event.ContextWithEvent(ctx, event.Event{Name: "subscribed"})
+
+	var mu sync.Mutex
+	var seen []http.Header
+	record := func(req *http.Request) {
+		mu.Lock()
+		seen = append(seen, req.Header.Clone())
+		mu.Unlock()
+	}
+
+	url0, _ := newServer(t, func(rw http.ResponseWriter, req *http.Request) {
+		record(req)
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	})
+	url1, _ := newServer(t, func(rw http.ResponseWriter, req *http.Request) {
+		record(req)
+		rw.WriteHeader(http.StatusOK)
+	})
+	conn := newConn([]string{url0, url1})
+
+	resp, err := conn.Do(ctx, http.MethodPost, "/", []byte(`{}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Len(t, seen, 2)
+	for _, header := range seen {
+		assert.Contains(t, header.Get("traceparent"), traceID.String())
+		assert.Contains(t, header.Get("baggage"), "event.name=subscribed")
+	}
+}
+
 func TestDo_RoundRobinDistribution(t *testing.T) {
 	urls := make([]string, 3)
 	counters := make([]*atomic.Int64, 3)
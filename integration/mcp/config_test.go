@@ -187,6 +187,39 @@ func TestConfig_Sanitize(t *testing.T) {
 			}(),
 			wantErr: errorstack.NewValidation(oauthResourceIdEntry, oauthAuthServersEntry),
 		},
+		{
+			name: "valid trusted proxies are valid",
+			cfg: func() Config {
+				cfg := validConfig()
+				cfg.Proxies = integration.ConfigProxies{Trusted: []string{"10.0.0.0/8"}}
+				return cfg
+			}(),
+			wantErr: nil,
+		},
+		{
+			name: "invalid trusted proxy returns error with Event disabled",
+			cfg: func() Config {
+				cfg := validConfig()
+				cfg.Proxies = integration.ConfigProxies{Trusted: []string{"10.0.0.0/8", "not-an-ip"}}
+				return cfg
+			}(),
+			wantErr: errorstack.NewValidation(errorstack.Entry{
+				Message: "Must be a valid IP address or CIDR range",
+				Path:    []any{"config", "proxies", "trusted", 1},
+			}),
+		},
+		{
+			name: "negative max request body bytes returns error",
+			cfg: func() Config {
+				cfg := validConfig()
+				cfg.MaxRequestBodyBytes = -1
+				return cfg
+			}(),
+			wantErr: errorstack.NewValidation(errorstack.Entry{
+				Message: "Must be greater than or equal to 0",
+				Path:    []any{"config", "max_request_body_bytes"},
+			}),
+		},
 	}
 
 	for _, tc := range testcases {
@@ -27,6 +27,44 @@ Start starts the HTTP server of the MCP server integration.
 */
 func (m *mcp) Start(ctx context.Context) error {
 
+	h := m.handler()
+
+	// Create the HTTP server with the given configuration and the handler built.
+	m.server = &http.Server{
+		Addr:    m.config.Address,
+		Handler: h,
+	}
+
+	// Start the HTTP server with or without TLS depending on the Config, and catch
+	// unexpected errors.
+	var err error
+	if m.config.TLS.Enabled {
+		tlsConfig, tlsEntries := m.config.TLS.ToStandardTLS()
+		if len(tlsEntries) > 0 {
+			return errorstack.NewValidation(tlsEntries...)
+		}
+
+		m.server.TLSConfig = tlsConfig
+		err = m.server.ListenAndServeTLS("", "")
+	} else {
+		err = m.server.ListenAndServe()
+	}
+
+	if err != nil && err != http.ErrServerClosed {
+		return errorstack.Wrap(err, "Failed to start server")
+	}
+
+	return nil
+}
+
+/*
+handler returns the HTTP handler served by the HTTP server of the MCP server
+integration, wrapping the built-in one with the user's middleware, the request
+body limit, the panic recovery, the Event and trusted proxies middleware, the
+compression and CORS middleware, and the OpenTelemetry handler.
+*/
+func (m *mcp) handler() http.Handler {
+
 	// Wrap the built-in HTTP handler with the one given by the user, if applicable.
 	// Skip user middleware for the health endpoints so they always respond without
 	// requiring authentication or other service-level checks. In OAuth Resource
@@ -52,6 +90,44 @@ func (m *mcp) Start(ctx context.Context) error {
 		})
 	}
 
+	// Limit the size of request bodies, if configured. This is applied outside of
+	// the user's middleware so a body exceeding the limit is rejected with the
+	// same 413 whichever layer reads it, the user's middleware or the MCP SDK.
+	h = integration.LimitRequestBody(h, m.config.MaxRequestBodyBytes, func(rw http.ResponseWriter, req *http.Request) {
+		writeError(rw, req, http.StatusRequestEntityTooLarge)
+	})
+
+	// Recover panics of the MCP transport and of the user's middleware, writing a
+	// 500 in place of the response. Panics of tools, resources, and prompts are
+	// recovered by middlewareRecover. This is applied inside the Event middleware
+	// so the panic is logged with the Event, and inside the OpenTelemetry handler
+	// so it is recorded on the request's span.
+	h = integration.RecoverPanic(h, m.logContext, func(rw http.ResponseWriter, req *http.Request) {
+		writeError(rw, req, http.StatusInternalServerError)
+	})
+
+	// Build an Event from every incoming request, if enabled. This is applied
+	// outside of the user's middleware so it can read and enrich the Event, and
+	// inside the OpenTelemetry handler so the Event restored from the baggage
+	// propagated by an upstream service is already in the request context.
+	h = m.config.Event.Middleware(h)
+
+	// Make the trusted proxies available to the handlers and middleware finding
+	// the client's IP and origin, including the Event middleware, whether the
+	// Event is enabled or not.
+	h = m.config.Proxies.Middleware(h)
+
+	// Compress responses, if enabled. This is applied outside of the user's
+	// middleware so error responses are compressed as well, and inside the CORS
+	// middleware so preflight requests are answered without being buffered.
+	h = m.config.Compression.Middleware(h)
+
+	// Handle CORS, if enabled. This is applied outside of the user's middleware
+	// and before routing, so preflight requests are answered without requiring
+	// authentication, and so the CORS headers are set on every response,
+	// including errors written by the router and the user's middleware.
+	h = m.config.CORS.Middleware(h)
+
 	// Wrap the handler previously built with the one designed for OpenTelemetry
 	// traces.
 	h = otelhttp.NewHandler(h, "",
@@ -61,32 +137,7 @@ func (m *mcp) Start(ctx context.Context) error {
 		}),
 	)
 
-	// Create the HTTP server with the given configuration and the handler built.
-	m.server = &http.Server{
-		Addr:    m.config.Address,
-		Handler: h,
-	}
-
-	// Start the HTTP server with or without TLS depending on the Config, and catch
-	// unexpected errors.
-	var err error
-	if m.config.TLS.Enabled {
-		tlsConfig, tlsEntries := m.config.TLS.ToStandardTLS()
-		if len(tlsEntries) > 0 {
-			return errorstack.NewValidation(tlsEntries...)
-		}
-
-		m.server.TLSConfig = tlsConfig
-		err = m.server.ListenAndServeTLS("", "")
-	} else {
-		err = m.server.ListenAndServe()
-	}
-
-	if err != nil && err != http.ErrServerClosed {
-		return errorstack.Wrap(err, "Failed to start server")
-	}
-
-	return nil
+	return h
 }
 
 /*
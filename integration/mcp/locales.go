@@ -1,6 +1,8 @@
 package mcp
 
 import (
+	"io/fs"
+
 	"github.com/mountayaapp/helix.go/internal/locales"
 
 	"golang.org/x/text/language"
@@ -22,6 +24,7 @@ Supported status code:
   - [http.StatusNotFound]
   - [http.StatusMethodNotAllowed]
   - [http.StatusConflict]
+  - [http.StatusPreconditionFailed]
   - [http.StatusRequestEntityTooLarge]
   - [http.StatusTooManyRequests]
   - [http.StatusInternalServerError]
@@ -39,6 +42,7 @@ Example:
 		http.StatusNotFound:              "<locale>",
 		http.StatusMethodNotAllowed:      "<locale>",
 		http.StatusConflict:              "<locale>",
+		http.StatusPreconditionFailed:    "<locale>",
 		http.StatusRequestEntityTooLarge: "<locale>",
 		http.StatusTooManyRequests:       "<locale>",
 		http.StatusInternalServerError:   "<locale>",
@@ -50,3 +54,43 @@ Example:
 func AddOrEditLanguage(lang language.Tag, m map[int]string) {
 	locales.AddOrEditLanguage(lang, m)
 }
+
+/*
+LoadLanguages allows a service to add or edit languages from catalog files
+instead of Go maps, loading every file of fsys matching the glob pattern passed.
+fsys can be an embed.FS or a directory opened with os.DirFS. Each file holds the
+catalog of the language it is named after (e.g. "fr.yaml", "pt-BR.json"), as
+JSON or YAML. Messages keyed by message ID are used by the REST and GraphQL integrations.
+The catalog is shared with the REST and GraphQL integrations.
+
+Example, with locales/fr.yaml:
+
+	statuses:
+	  400: Requête invalide
+	messages:
+	  must_be_set: Doit être renseigné
+	  items_selected:
+	    one: "{count} élément sélectionné"
+	    other: "{count} éléments sélectionnés"
+
+And:
+
+	//go:embed locales/*.yaml
+	var catalogs embed.FS
+
+	err := mcp.LoadLanguages(catalogs, "locales/*.yaml")
+*/
+func LoadLanguages(fsys fs.FS, pattern string) error {
+	return locales.LoadFS(fsys, pattern)
+}
+
+/*
+AddBuiltInLanguages adds the translations of the default per-status error
+messages shipped with helix.go for the languages passed: French, German,
+Spanish, Italian, Portuguese, and Japanese. All of them are added when no
+language is passed. Translations set previously for the same status codes are
+replaced, so custom ones should be set after calling this function.
+*/
+func AddBuiltInLanguages(langs ...language.Tag) error {
+	return locales.AddBuiltInLanguages(langs...)
+}
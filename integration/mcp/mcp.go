@@ -110,9 +110,9 @@ func (m *mcp) buildMux() {
 
 /*
 buildServer builds a fresh MCP SDK server from the ServerInfo and lets the
-consumer attach tools, resources, and prompts through Config.Register. In
-stateless mode a new server is built per request, so this is called for every
-incoming request.
+consumer attach tools, resources, and prompts through Config.Register. Panics of
+the methods received are recovered by middlewareRecover. In stateless mode a new
+server is built per request, so this is called for every incoming request.
 */
 func (m *mcp) buildServer() *mcpsdk.Server {
 	server := mcpsdk.NewServer(&mcpsdk.Implementation{
@@ -120,6 +120,8 @@ func (m *mcp) buildServer() *mcpsdk.Server {
 		Version: m.config.ServerInfo.Version,
 	}, nil)
 
+	server.AddReceivingMiddleware(m.middlewareRecover)
+
 	m.config.Register(server)
 	return server
 }
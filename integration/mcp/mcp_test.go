@@ -2,14 +2,23 @@ package mcp
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/mountayaapp/helix.go/event"
+	"github.com/mountayaapp/helix.go/integration"
+	"github.com/mountayaapp/helix.go/integration/compression"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
 	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
 )
 
 // greetInput is the toy tool's typed input. The jsonschema struct tag is
@@ -121,6 +130,19 @@ func TestMCP_UnknownRoute_ReturnsNotFound(t *testing.T) {
 	assert.Contains(t, rw.Body.String(), `"code":"NOT_FOUND"`)
 }
 
+func TestMCP_UnknownRoute_BuiltInLanguage(t *testing.T) {
+	require.NoError(t, AddBuiltInLanguages(language.German))
+	m := newTestMCP(t, toyConfig())
+
+	req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	req.Header.Set("Accept-Language", "de-CH")
+	rw := httptest.NewRecorder()
+	m.mux.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"Die Ressource existiert nicht","extensions":{"code":"NOT_FOUND"}}]}`, rw.Body.String())
+}
+
 // TestMCP_MethodNotAllowed covers the integration's own method-not-allowed
 // fallback, which answers methods the mux does not route to the transport at all
 // (GET, POST, and DELETE are routed; PUT is not). It is therefore the only 405
@@ -352,3 +374,208 @@ func TestMCP_OAuth_Authenticated(t *testing.T) {
 
 	assert.NotEqual(t, http.StatusUnauthorized, rw.Code)
 }
+
+func TestMCP_Handler_EventAvailableToMiddleware(t *testing.T) {
+	cfg := toyConfig()
+	cfg.Proxies = integration.ConfigProxies{Trusted: []string{"10.0.0.0/8"}}
+	cfg.Event = integration.ConfigEvent{Enabled: true}
+
+	var e event.Event
+	var found bool
+	cfg.Middleware = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			e, found = event.EventFromContext(req.Context())
+			next.ServeHTTP(rw, req)
+		})
+	}
+
+	m := newTestMCP(t, cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	req.RemoteAddr = "10.0.0.3:52100"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+	rw := httptest.NewRecorder()
+	m.handler().ServeHTTP(rw, req)
+
+	assert.True(t, found)
+	assert.Equal(t, "203.0.113.7", e.IP)
+}
+
+func TestMCP_Handler_CORSPreflightSkipsOAuth(t *testing.T) {
+	cfg := toyConfig()
+	cfg.CORS = integration.ConfigCORS{
+		Enabled:        true,
+		AllowedOrigins: []string{"https://*.example.com"},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "Mcp-Session-Id"},
+		ExposedHeaders: []string{"Mcp-Session-Id"},
+	}
+	cfg.OAuth = OAuthResourceServer{
+		Enabled:              true,
+		ResourceId:           "https://api.example.com",
+		AuthorizationServers: []string{"https://auth.example.com"},
+		ValidateToken: func(_ context.Context, _ string) (any, time.Time, error) {
+			return nil, time.Time{}, errors.New("invalid token")
+		},
+	}
+	m := newTestMCP(t, cfg)
+
+	// Preflight requests are answered before the OAuth gate, since browsers never
+	// send credentials with them.
+	req := httptest.NewRequest(http.MethodOptions, "/mcp", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "authorization, mcp-session-id")
+	rw := httptest.NewRecorder()
+	m.handler().ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusNoContent, rw.Code)
+	assert.Equal(t, "https://app.example.com", rw.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "authorization, mcp-session-id", rw.Header().Get("Access-Control-Allow-Headers"))
+
+	// The unauthenticated request itself is rejected, with the CORS headers set
+	// so the browser lets the client read the 401.
+	req = httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rw = httptest.NewRecorder()
+	m.handler().ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	assert.Equal(t, "https://app.example.com", rw.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Mcp-Session-Id", rw.Header().Get("Access-Control-Expose-Headers"))
+}
+
+func TestMCP_Handler_CompressionSkipsEventStream(t *testing.T) {
+	cfg := toyConfig()
+	cfg.Compression = compression.Config{
+		Enabled: true,
+		MinSize: 1,
+	}
+	m := newTestMCP(t, cfg)
+
+	// Responses streamed with text/event-stream are written as is, so every
+	// message keeps reaching the client as soon as it is flushed.
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test-client","version":"v1.0.0"}}}`
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Content-Type", "application/json")
+	rw := httptest.NewRecorder()
+	m.handler().ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "text/event-stream", rw.Header().Get("Content-Type"))
+	assert.Empty(t, rw.Header().Get("Content-Encoding"))
+	assert.Contains(t, rw.Body.String(), `"serverInfo"`)
+
+	// The client of the SDK still works end to end through the compression
+	// middleware.
+	srv := httptest.NewServer(m.handler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test-client", Version: "v1.0.0"}, nil)
+	session, err := client.Connect(ctx, &mcpsdk.StreamableClientTransport{
+		Endpoint: srv.URL + "/mcp",
+	}, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	res, err := session.CallTool(ctx, &mcpsdk.CallToolParams{
+		Name:      "greet",
+		Arguments: map[string]any{"name": "Ada"},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Content, 1)
+	text, ok := res.Content[0].(*mcpsdk.TextContent)
+	require.True(t, ok)
+	assert.Equal(t, "Hi Ada", text.Text)
+}
+
+func TestMCP_Handler_MaxRequestBodyBytes(t *testing.T) {
+	cfg := toyConfig()
+	cfg.MaxRequestBodyBytes = 64
+	m := newTestMCP(t, cfg)
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test-client","version":"v1.0.0"}}}`
+
+	// The body is rejected whether its Content-Length announces it, or it is only
+	// found to be too large while read by the MCP SDK. io.MultiReader hides the
+	// length of the body, so the request is built without Content-Length.
+	for _, r := range []io.Reader{strings.NewReader(body), io.MultiReader(strings.NewReader(body))} {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", r)
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		req.Header.Set("Content-Type", "application/json")
+		rw := httptest.NewRecorder()
+		m.handler().ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+		assert.JSONEq(t, `{"errors":[{"message":"Payload exceeds size limit","extensions":{"code":"PAYLOAD_TOO_LARGE"}}]}`, rw.Body.String())
+	}
+}
+
+func TestMCP_Handler_RecoversPanic(t *testing.T) {
+	cfg := toyConfig()
+	cfg.Middleware = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("X-Custom", "value")
+			panic("boom")
+		})
+	}
+
+	m := newTestMCP(t, cfg)
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	rw := httptest.NewRecorder()
+
+	require.NotPanics(t, func() {
+		m.handler().ServeHTTP(rw, req)
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	assert.Empty(t, rw.Header().Get("X-Custom"))
+	assert.JSONEq(t, `{"errors":[{"message":"Internal server error","extensions":{"code":"INTERNAL_ERROR"}}]}`, rw.Body.String())
+}
+
+// TestMCP_Handler_RecoversToolPanic drives a tool panicking with a real MCP
+// client. The SDK runs tools in goroutines of its own, so without recovery the
+// panic would crash the test binary.
+func TestMCP_Handler_RecoversToolPanic(t *testing.T) {
+	cfg := toyConfig()
+	cfg.Register = func(server *Server) {
+		AddTool(server, &Tool{Name: "explode"}, func(_ context.Context, _ *CallToolRequest, in greetInput) (*CallToolResult, greetOutput, error) {
+			panic("boom")
+		})
+	}
+
+	m := newTestMCP(t, cfg)
+
+	srv := httptest.NewServer(m.handler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test-client", Version: "v1.0.0"}, nil)
+	session, err := client.Connect(ctx, &mcpsdk.StreamableClientTransport{
+		Endpoint: srv.URL + "/mcp",
+	}, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	_, err = session.CallTool(ctx, &mcpsdk.CallToolParams{
+		Name:      "explode",
+		Arguments: map[string]any{"name": "Ada"},
+	})
+
+	var rpcErr *jsonrpc.Error
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, int64(jsonrpc.CodeInternalError), rpcErr.Code)
+	assert.Equal(t, "Internal server error", rpcErr.Message)
+
+	// The session is still usable after the panic.
+	res, err := session.ListTools(ctx, &mcpsdk.ListToolsParams{})
+	require.NoError(t, err)
+	assert.Len(t, res.Tools, 1)
+}
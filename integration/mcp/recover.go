@@ -0,0 +1,62 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/mountayaapp/helix.go/integration"
+	"github.com/mountayaapp/helix.go/internal/locales"
+	"github.com/mountayaapp/helix.go/service"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+/*
+middlewareRecover is the MCP middleware recovering the panics of the methods
+received, such as the handlers of tools, resources, and prompts. The MCP SDK
+calls them from goroutines of its own, so their panics are out of reach of the
+HTTP handler's recovery and would otherwise crash the whole service. The panic
+is reported with integration.ReportPanic, and answered with a JSON-RPC internal
+error holding the localized message of a 500.
+*/
+func (m *mcp) middlewareRecover(next mcpsdk.MethodHandler) mcpsdk.MethodHandler {
+	return func(ctx context.Context, method string, req mcpsdk.Request) (result mcpsdk.Result, err error) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			integration.ReportPanic(m.logContext(ctx), recovered, debug.Stack())
+
+			// The preferred language is read from the headers of the HTTP request
+			// carrying the method, when available.
+			r := &http.Request{Header: http.Header{}}
+			if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+				r.Header = extra.Header
+			}
+
+			result = nil
+			err = &jsonrpc.Error{
+				Code:    jsonrpc.CodeInternalError,
+				Message: locales.Message(r, http.StatusInternalServerError),
+			}
+		}()
+
+		return next(ctx, method, req)
+	}
+}
+
+/*
+logContext returns a copy of ctx enriched with the Service's logger and tracer,
+so logs written from a request context are sent with the Service's ones.
+*/
+func (m *mcp) logContext(ctx context.Context) context.Context {
+	if m.svc != nil {
+		ctx = service.Context(m.svc, ctx)
+	}
+
+	return ctx
+}
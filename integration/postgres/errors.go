@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+/*
+mappedError is the errorstack code and message an error is mapped to.
+*/
+type mappedError struct {
+	code    string
+	message string
+}
+
+/*
+pgErrors maps PostgreSQL error codes to errorstack codes. See
+https://www.postgresql.org/docs/current/errcodes-appendix.html for the list of
+codes.
+*/
+var pgErrors = map[string]mappedError{
+	"23505": {errorstack.CodeConflict, "Resource already exists"},                   // unique_violation
+	"23P01": {errorstack.CodeConflict, "Resource conflicts with current state"},     // exclusion_violation
+	"23503": {errorstack.CodeConflict, "Resource conflicts with current state"},     // foreign_key_violation
+	"40001": {errorstack.CodeConflict, "Resource conflicts with concurrent update"}, // serialization_failure
+	"40P01": {errorstack.CodeConflict, "Resource conflicts with concurrent update"}, // deadlock_detected
+	"23502": {errorstack.CodeBadRequest, "Request is invalid"},                      // not_null_violation
+	"23514": {errorstack.CodeBadRequest, "Request is invalid"},                      // check_violation
+	"22001": {errorstack.CodeBadRequest, "Request is invalid"},                      // string_data_right_truncation
+	"22P02": {errorstack.CodeBadRequest, "Request is invalid"},                      // invalid_text_representation
+	"57014": {errorstack.CodeGatewayTimeout, "Query timed out"},                     // query_canceled
+	"53300": {errorstack.CodeServiceUnavailable, "Database is unavailable"},         // too_many_connections
+}
+
+/*
+mapError wraps err with errorstack.Wrap, carrying the errorstack code matching
+the driver error: CodeNotFound for pgx.ErrNoRows, the code found in pgErrors for
+a *pgconn.PgError, and CodeGatewayTimeout when the context deadline is exceeded.
+Other errors carry CodeInternalError with the message passed. The driver error
+is preserved in the chain for errors.Is/As support. Returns nil if err is nil,
+and err as is if it is already an *errorstack.Error.
+*/
+func mapError(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	var stack *errorstack.Error
+	if errors.As(err, &stack) {
+		return err
+	}
+
+	mapped := mappedError{errorstack.CodeInternalError, message}
+
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		mapped = mappedError{errorstack.CodeNotFound, "Resource does not exist"}
+
+	case errors.Is(err, context.DeadlineExceeded):
+		mapped = mappedError{errorstack.CodeGatewayTimeout, "Query timed out"}
+
+	case errors.As(err, &pgErr):
+		if known, ok := pgErrors[pgErr.Code]; ok {
+			mapped = known
+		}
+	}
+
+	return errorstack.Wrap(err, mapped.message, errorstack.WithCode(mapped.code))
+}
+
+/*
+row wraps a pgx.Row so the error returned by Scan is mapped with mapError.
+*/
+type row struct {
+	pgx.Row
+}
+
+/*
+Scan reads the values of the row into dest. It returns an error carrying
+CodeNotFound if the query returned no rows.
+*/
+func (r row) Scan(dest ...any) error {
+	return mapError(r.Row.Scan(dest...), "Failed to scan row")
+}
+
+/*
+rows wraps a pgx.Rows so the error returned by Err is mapped with mapError.
+*/
+type rows struct {
+	pgx.Rows
+}
+
+/*
+Err returns the error, if any, encountered while reading the rows.
+*/
+func (r rows) Err() error {
+	return mapError(r.Rows.Err(), "Failed to read rows")
+}
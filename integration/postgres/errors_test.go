@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapError(t *testing.T) {
+	testcases := []struct {
+		name            string
+		err             error
+		expectedCode    string
+		expectedMessage string
+	}{
+		{
+			name:            "no rows",
+			err:             pgx.ErrNoRows,
+			expectedCode:    errorstack.CodeNotFound,
+			expectedMessage: "Resource does not exist",
+		},
+		{
+			name:            "wrapped no rows",
+			err:             fmt.Errorf("scan: %w", pgx.ErrNoRows),
+			expectedCode:    errorstack.CodeNotFound,
+			expectedMessage: "Resource does not exist",
+		},
+		{
+			name:            "unique violation",
+			err:             &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"},
+			expectedCode:    errorstack.CodeConflict,
+			expectedMessage: "Resource already exists",
+		},
+		{
+			name:            "foreign key violation",
+			err:             &pgconn.PgError{Code: "23503"},
+			expectedCode:    errorstack.CodeConflict,
+			expectedMessage: "Resource conflicts with current state",
+		},
+		{
+			name:            "check violation",
+			err:             &pgconn.PgError{Code: "23514"},
+			expectedCode:    errorstack.CodeBadRequest,
+			expectedMessage: "Request is invalid",
+		},
+		{
+			name:            "deadline exceeded",
+			err:             context.DeadlineExceeded,
+			expectedCode:    errorstack.CodeGatewayTimeout,
+			expectedMessage: "Query timed out",
+		},
+		{
+			name:            "unmapped PostgreSQL error",
+			err:             &pgconn.PgError{Code: "42P01"},
+			expectedCode:    errorstack.CodeInternalError,
+			expectedMessage: "Failed to execute query",
+		},
+		{
+			name:            "unknown error",
+			err:             errors.New("connection reset by peer"),
+			expectedCode:    errorstack.CodeInternalError,
+			expectedMessage: "Failed to execute query",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := mapError(tc.err, "Failed to execute query")
+
+			var stack *errorstack.Error
+			require.ErrorAs(t, err, &stack)
+			assert.Equal(t, tc.expectedCode, errorstack.CodeOf(err))
+			assert.Equal(t, tc.expectedMessage, stack.Entries[0].Message)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestMapError_PreservesPgError(t *testing.T) {
+	err := mapError(&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, "Failed to execute query")
+
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, "users_email_key", pgErr.ConstraintName)
+}
+
+func TestMapError_Passthrough(t *testing.T) {
+	assert.NoError(t, mapError(nil, "Failed to execute query"))
+
+	original := errorstack.New("Resource does not exist", errorstack.WithCode(errorstack.CodeNotFound))
+	assert.Same(t, original, mapError(original, "Failed to execute query"))
+}
+
+type stubRow struct {
+	err error
+}
+
+func (r stubRow) Scan(dest ...any) error {
+	return r.err
+}
+
+func TestRow_Scan(t *testing.T) {
+	assert.NoError(t, row{Row: stubRow{}}.Scan())
+
+	err := row{Row: stubRow{err: pgx.ErrNoRows}}.Scan()
+	assert.Equal(t, errorstack.CodeNotFound, errorstack.CodeOf(err))
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+// stubTx implements the Query method of pgx.Tx, failing with err. Other methods
+// are not implemented and panic if called.
+type stubTx struct {
+	pgx.Tx
+
+	err error
+}
+
+func (tx stubTx) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	return nil, tx.err
+}
+
+func TestTransaction_Query_Error(t *testing.T) {
+	tx := &transaction{client: stubTx{err: context.DeadlineExceeded}}
+
+	r, err := tx.Query(context.Background(), "SELECT 1")
+
+	assert.Nil(t, r)
+	assert.Equal(t, errorstack.CodeGatewayTimeout, errorstack.CodeOf(err))
+}
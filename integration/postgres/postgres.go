@@ -137,7 +137,7 @@ func (conn *connection) BeginTx(ctx context.Context, opts pgx.TxOptions) (Tx, er
 		client: client,
 	}
 
-	return tx, err
+	return tx, mapError(err, "Failed to begin transaction")
 }
 
 /*
@@ -159,7 +159,7 @@ func (conn *connection) Exec(ctx context.Context, query string, args ...any) (pg
 	setDefaultAttributes(span, conn.config)
 	setQueryAttributes(span, query)
 
-	return stmt, err
+	return stmt, mapError(err, "Failed to execute query")
 }
 
 /*
@@ -190,7 +190,7 @@ func (conn *connection) Query(ctx context.Context, query string, args ...any) (p
 	ctx, span := trace.Start(ctx, trace.SpanKindClient, spanQuery)
 	defer span.End()
 
-	rows, err := conn.client.Query(ctx, query, args...)
+	r, err := conn.client.Query(ctx, query, args...)
 	if err != nil {
 		span.RecordError("failed to query rows", err)
 	}
@@ -198,13 +198,17 @@ func (conn *connection) Query(ctx context.Context, query string, args ...any) (p
 	setDefaultAttributes(span, conn.config)
 	setQueryAttributes(span, query)
 
-	return rows, err
+	if err != nil {
+		return nil, mapError(err, "Failed to query rows")
+	}
+
+	return rows{Rows: r}, nil
 }
 
 /*
 QueryRow is a convenience wrapper over Query. Any error that occurs while querying
 is deferred until calling Scan on the returned Row. That Row will error with
-pgx.ErrNoRows if no rows are returned.
+errorstack.CodeNotFound, wrapping pgx.ErrNoRows, if no rows are returned.
 
 It automatically handles tracing.
 */
@@ -212,10 +216,10 @@ func (conn *connection) QueryRow(ctx context.Context, query string, args ...any)
 	ctx, span := trace.Start(ctx, trace.SpanKindClient, spanQueryRow)
 	defer span.End()
 
-	row := conn.client.QueryRow(ctx, query, args...)
+	r := conn.client.QueryRow(ctx, query, args...)
 
 	setDefaultAttributes(span, conn.config)
 	setQueryAttributes(span, query)
 
-	return row
+	return row{Row: r}
 }
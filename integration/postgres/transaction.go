@@ -65,7 +65,7 @@ func (tx *transaction) Begin(ctx context.Context) (Tx, error) {
 		client: subtx,
 	}
 
-	return sub, err
+	return sub, mapError(err, "Failed to begin transaction")
 }
 
 /*
@@ -84,7 +84,7 @@ func (tx *transaction) Commit(ctx context.Context) error {
 
 	setDefaultAttributes(span, tx.config)
 
-	return err
+	return mapError(err, "Failed to commit transaction")
 }
 
 /*
@@ -106,7 +106,7 @@ func (tx *transaction) Rollback(ctx context.Context) error {
 
 	setDefaultAttributes(span, tx.config)
 
-	return err
+	return mapError(err, "Failed to rollback transaction")
 }
 
 /*
@@ -126,7 +126,7 @@ func (tx *transaction) Exec(ctx context.Context, query string, args ...any) (pgc
 	setDefaultAttributes(span, tx.config)
 	setTransactionQueryAttributes(span, query)
 
-	return stmt, err
+	return stmt, mapError(err, "Failed to execute query")
 }
 
 /*
@@ -146,7 +146,7 @@ func (tx *transaction) Prepare(ctx context.Context, id string, query string) (*p
 	setDefaultAttributes(span, tx.config)
 	setTransactionQueryAttributes(span, query)
 
-	return stmt, err
+	return stmt, mapError(err, "Failed to prepare statement")
 }
 
 /*
@@ -158,7 +158,7 @@ func (tx *transaction) Query(ctx context.Context, query string, args ...any) (pg
 	ctx, span := trace.Start(ctx, trace.SpanKindClient, spanTxQuery)
 	defer span.End()
 
-	rows, err := tx.client.Query(ctx, query, args...)
+	r, err := tx.client.Query(ctx, query, args...)
 	if err != nil {
 		span.RecordError("failed to query rows", err)
 	}
@@ -166,7 +166,11 @@ func (tx *transaction) Query(ctx context.Context, query string, args ...any) (pg
 	setDefaultAttributes(span, tx.config)
 	setTransactionQueryAttributes(span, query)
 
-	return rows, err
+	if err != nil {
+		return nil, mapError(err, "Failed to query rows")
+	}
+
+	return rows{Rows: r}, nil
 }
 
 /*
@@ -178,10 +182,10 @@ func (tx *transaction) QueryRow(ctx context.Context, query string, args ...any)
 	ctx, span := trace.Start(ctx, trace.SpanKindClient, spanTxQueryRow)
 	defer span.End()
 
-	row := tx.client.QueryRow(ctx, query, args...)
+	r := tx.client.QueryRow(ctx, query, args...)
 
 	setDefaultAttributes(span, tx.config)
 	setTransactionQueryAttributes(span, query)
 
-	return row
+	return row{Row: r}
 }
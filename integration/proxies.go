@@ -0,0 +1,209 @@
+package integration
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+)
+
+/*
+ConfigProxies is the common configuration of the proxies and load balancers
+sitting in front of the HTTP server integrations (REST, GraphQL, MCP). It is
+used to find the client's IP and the origin it used to reach the server, for the
+Event built from requests, rate limiting, and the OpenAPI description served.
+*/
+type ConfigProxies struct {
+
+	// Trusted is the list of IP addresses or CIDR ranges of the proxies and load
+	// balancers sitting in front of the server. The X-Forwarded-For, X-Real-IP,
+	// X-Forwarded-Proto, and X-Forwarded-Host headers are only honored when the
+	// direct peer is one of them, so a client cannot spoof its IP or origin by
+	// setting the headers itself. When empty, the direct peer is the client.
+	//
+	// Examples:
+	//
+	//   []string{"10.0.0.0/8", "172.16.0.0/12"}
+	//   []string{"0.0.0.0/0", "::/0"}
+	Trusted []string `json:"trusted,omitempty"`
+
+	// trusted holds the prefixes parsed from Trusted by Sanitize.
+	trusted []netip.Prefix
+}
+
+/*
+Sanitize sets default values - if applicable - and validates the configuration.
+Returns validation entries if configuration is not valid. This doesn't return
+a standard error since this function shall only be called by integrations,
+which collect entries from many sources before producing a final
+errorstack.NewValidation:
+
+	entries = append(entries, cfg.Proxies.Sanitize()...)
+*/
+func (cfg *ConfigProxies) Sanitize() []errorstack.Entry {
+	var entries []errorstack.Entry
+
+	cfg.trusted = nil
+	for i, proxy := range cfg.Trusted {
+		proxy = strings.TrimSpace(proxy)
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				entries = append(entries, errorstack.Entry{
+					Message: "Must be a valid IP address or CIDR range",
+					Path:    []any{"config", "proxies", "trusted", i},
+				})
+
+				continue
+			}
+
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+
+		cfg.trusted = append(cfg.trusted, prefix.Masked())
+	}
+
+	return entries
+}
+
+/*
+proxiesKey is the key of the ConfigProxies stored in a context by Middleware.
+*/
+type proxiesKey struct{}
+
+/*
+Middleware returns an HTTP middleware making the trusted proxies available to
+ClientIP and RequestOrigin for every incoming request. It must wrap every other
+middleware and handler relying on them, such as the Event middleware.
+
+Returns next as is when no proxy is trusted, so it costs nothing per request.
+*/
+func (cfg *ConfigProxies) Middleware(next http.Handler) http.Handler {
+	if len(cfg.trusted) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), proxiesKey{}, cfg)
+		next.ServeHTTP(rw, req.WithContext(ctx))
+	})
+}
+
+/*
+proxiesFromRequest returns the ConfigProxies stored in the request context by
+Middleware, or an empty one trusting no proxy if none is found.
+*/
+func proxiesFromRequest(req *http.Request) *ConfigProxies {
+	if cfg, ok := req.Context().Value(proxiesKey{}).(*ConfigProxies); ok {
+		return cfg
+	}
+
+	return &ConfigProxies{}
+}
+
+/*
+ClientIP returns the IP of the client that sent the request. Forwarding headers
+are only read when the direct peer is one of the proxies trusted by the server,
+as made available by ConfigProxies.Middleware. X-Forwarded-For is walked from
+right to left, skipping trusted proxies, so the first untrusted hop — the
+closest one that could not have been forged by a proxy we trust — is returned.
+*/
+func ClientIP(req *http.Request) string {
+	cfg := proxiesFromRequest(req)
+
+	host := remoteHost(req)
+	if !cfg.isTrustedPeer(req) {
+		return host
+	}
+
+	if xff := req.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+
+			if !cfg.isTrusted(hop) || i == 0 {
+				return hop.Unmap().String()
+			}
+		}
+	}
+
+	if real, err := netip.ParseAddr(strings.TrimSpace(req.Header.Get("X-Real-IP"))); err == nil {
+		return real.Unmap().String()
+	}
+
+	return host
+}
+
+/*
+RequestOrigin returns the scheme and host the client used to reach the server
+for the request passed. They are read from X-Forwarded-Proto and
+X-Forwarded-Host only when the direct peer is one of the proxies trusted by the
+server, as made available by ConfigProxies.Middleware. The host is empty if the
+request has none.
+*/
+func RequestOrigin(req *http.Request) (scheme string, host string) {
+	scheme = "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+
+	host = req.Host
+	if proxiesFromRequest(req).isTrustedPeer(req) {
+		if proto := req.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+
+		if forwarded := req.Header.Get("X-Forwarded-Host"); forwarded != "" {
+			host = forwarded
+		}
+	}
+
+	return scheme, host
+}
+
+/*
+isTrusted returns true if addr is part of the trusted proxies.
+*/
+func (cfg *ConfigProxies) isTrusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range cfg.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+isTrustedPeer returns true if the direct peer of the request is part of the
+trusted proxies.
+*/
+func (cfg *ConfigProxies) isTrustedPeer(req *http.Request) bool {
+	peer, err := netip.ParseAddr(remoteHost(req))
+	if err != nil {
+		return false
+	}
+
+	return cfg.isTrusted(peer)
+}
+
+/*
+remoteHost returns the host part of the request's remote address.
+*/
+func remoteHost(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigProxies_Sanitize(t *testing.T) {
+	testcases := []struct {
+		name     string
+		cfg      ConfigProxies
+		expected []errorstack.Entry
+	}{
+		{
+			name:     "no proxies has no entries",
+			cfg:      ConfigProxies{},
+			expected: nil,
+		},
+		{
+			name: "valid IPs and CIDR ranges have no entries",
+			cfg: ConfigProxies{
+				Trusted: []string{"10.0.0.1", "172.16.0.0/12", " ::1 ", "fd00::/8"},
+			},
+			expected: nil,
+		},
+		{
+			name: "invalid proxies return entries",
+			cfg: ConfigProxies{
+				Trusted: []string{"10.0.0.0/8", "invalid", "10.0.0.0/99"},
+			},
+			expected: []errorstack.Entry{
+				{
+					Message: "Must be a valid IP address or CIDR range",
+					Path:    []any{"config", "proxies", "trusted", 1},
+				},
+				{
+					Message: "Must be a valid IP address or CIDR range",
+					Path:    []any{"config", "proxies", "trusted", 2},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := tc.cfg.Sanitize()
+			assert.Equal(t, tc.expected, entries)
+		})
+	}
+}
+
+// serveWithProxies serves req through the Middleware of a ConfigProxies trusting
+// the proxies passed, and returns the request as seen by the handler.
+func serveWithProxies(t *testing.T, proxies []string, req *http.Request) *http.Request {
+	t.Helper()
+
+	cfg := ConfigProxies{Trusted: proxies}
+	require.Empty(t, cfg.Sanitize())
+
+	var served *http.Request
+	h := cfg.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		served = req
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	return served
+}
+
+func TestClientIP(t *testing.T) {
+	testcases := []struct {
+		name     string
+		proxies  []string
+		remote   string
+		headers  map[string]string
+		expected string
+	}{
+		{
+			name:     "no trusted proxies ignores X-Forwarded-For",
+			remote:   "10.0.0.1:1234",
+			headers:  map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			expected: "10.0.0.1",
+		},
+		{
+			name:     "untrusted peer ignores X-Forwarded-For",
+			proxies:  []string{"10.0.0.0/8"},
+			remote:   "198.51.100.1:1234",
+			headers:  map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			expected: "198.51.100.1",
+		},
+		{
+			name:     "trusted peer honors X-Forwarded-For",
+			proxies:  []string{"10.0.0.0/8"},
+			remote:   "10.0.0.1:1234",
+			headers:  map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			expected: "203.0.113.7",
+		},
+		{
+			name:     "trusted hops are skipped from the right",
+			proxies:  []string{"10.0.0.0/8"},
+			remote:   "10.0.0.1:1234",
+			headers:  map[string]string{"X-Forwarded-For": "192.0.2.1, 203.0.113.7, 10.0.0.2"},
+			expected: "203.0.113.7",
+		},
+		{
+			name:     "every hop trusted returns the left-most one",
+			proxies:  []string{"10.0.0.0/8"},
+			remote:   "10.0.0.1:1234",
+			headers:  map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"},
+			expected: "10.0.0.3",
+		},
+		{
+			name:     "trusted peer honors X-Real-IP without X-Forwarded-For",
+			proxies:  []string{"10.0.0.1"},
+			remote:   "10.0.0.1:1234",
+			headers:  map[string]string{"X-Real-IP": "203.0.113.7"},
+			expected: "203.0.113.7",
+		},
+		{
+			name:     "IPv6 peer",
+			remote:   "[2001:db8::1]:1234",
+			expected: "2001:db8::1",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remote
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tc.expected, ClientIP(serveWithProxies(t, tc.proxies, req)))
+		})
+	}
+}
+
+func TestClientIP_WithoutMiddleware(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	assert.Equal(t, "10.0.0.1", ClientIP(req))
+}
+
+func TestRequestOrigin(t *testing.T) {
+	testcases := []struct {
+		name   string
+		remote string
+		scheme string
+		host   string
+	}{
+		{name: "trusted proxy", remote: "10.0.0.1:1234", scheme: "https", host: "api.tld"},
+		{name: "untrusted peer", remote: "203.0.113.7:1234", scheme: "http", host: "internal:8080"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://internal:8080/openapi.json", nil)
+			req.RemoteAddr = tc.remote
+			req.Header.Set("X-Forwarded-Proto", "https")
+			req.Header.Set("X-Forwarded-Host", "api.tld")
+
+			scheme, host := RequestOrigin(serveWithProxies(t, []string{"10.0.0.0/8"}, req))
+			assert.Equal(t, tc.scheme, scheme)
+			assert.Equal(t, tc.host, host)
+		})
+	}
+}
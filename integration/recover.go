@@ -0,0 +1,149 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/mountayaapp/helix.go/event"
+	"github.com/mountayaapp/helix.go/telemetry/log"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+/*
+RecoverPanic returns next wrapped so a panic while serving a request is recovered
+rather than left to net/http, which drops the connection without recording it,
+for the HTTP server integrations (REST, GraphQL, MCP). The panic is reported with
+ReportPanic, using the context returned by withLogger so the Service's logger is
+found. Then:
+
+  - if next has not started writing its response, reject is called to write the
+    500 in its place, along with the headers set before calling next;
+  - otherwise the response can not be replaced, and the request is aborted with
+    http.ErrAbortHandler so the client does not mistake it for a complete one.
+
+A panic with http.ErrAbortHandler is the way for a handler to abort a request on
+purpose, and is neither reported nor recovered.
+*/
+func RecoverPanic(next http.Handler, withLogger func(context.Context) context.Context, reject http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rcw := &recoverWriter{
+			ResponseWriter: rw,
+		}
+
+		header := rw.Header().Clone()
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			ReportPanic(withLogger(req.Context()), recovered, debug.Stack(),
+				log.String("method", req.Method),
+				log.String("path", req.URL.Path),
+			)
+
+			if rcw.wrote {
+				panic(http.ErrAbortHandler)
+			}
+
+			resetHeader(rw.Header(), header)
+			reject(rw, req)
+		}()
+
+		next.ServeHTTP(rcw, req)
+	})
+}
+
+/*
+ReportPanic records the value recovered from a panic and the stack passed on the
+span active in ctx, setting its status to error, and logs them at the error
+level along with the Event found in ctx and the fields passed. Returns the error
+built from the value recovered, wrapping it if it is an error.
+*/
+func ReportPanic(ctx context.Context, recovered any, stack []byte, fields ...log.Field) error {
+	var err error
+	if cause, ok := recovered.(error); ok {
+		err = fmt.Errorf("panic: %w", cause)
+	} else {
+		err = fmt.Errorf("panic: %v", recovered)
+	}
+
+	span := oteltrace.SpanFromContext(ctx)
+	span.RecordError(err, oteltrace.WithAttributes(attribute.String("exception.stacktrace", string(stack))))
+	span.SetStatus(codes.Error, "panic recovered")
+
+	e, _ := event.EventFromContext(ctx)
+	fields = append(fields,
+		log.Any("event", e),
+		log.Err(err),
+		log.String("error_stacktrace", string(stack)),
+	)
+
+	log.Error(ctx, "Recovered from panic", fields...)
+	return err
+}
+
+/*
+recoverWriter is an http.ResponseWriter reporting whether the handler started
+writing its response, so RecoverPanic knows if it can still be replaced.
+*/
+type recoverWriter struct {
+	http.ResponseWriter
+
+	// wrote reports whether the handler started writing its response.
+	wrote bool
+}
+
+/*
+Unwrap returns the underlying http.ResponseWriter so http.ResponseController can
+reach optional interfaces such as http.Flusher, enabling incremental streaming.
+*/
+func (rcw *recoverWriter) Unwrap() http.ResponseWriter {
+	return rcw.ResponseWriter
+}
+
+/*
+WriteHeader sends an HTTP response header with the provided status code.
+*/
+func (rcw *recoverWriter) WriteHeader(status int) {
+	rcw.wrote = true
+	rcw.ResponseWriter.WriteHeader(status)
+}
+
+/*
+Write writes the data to the connection as part of an HTTP reply.
+*/
+func (rcw *recoverWriter) Write(b []byte) (int, error) {
+	rcw.wrote = true
+	return rcw.ResponseWriter.Write(b)
+}
+
+/*
+Flush flushes the data written to the client. It is implemented for handlers
+asserting http.Flusher, such as gqlgen's SSE transport.
+*/
+func (rcw *recoverWriter) Flush() {
+	rcw.wrote = true
+	http.NewResponseController(rcw.ResponseWriter).Flush()
+}
+
+/*
+resetHeader replaces the headers of a response with the snapshot passed, taken
+before calling the handler whose response is replaced. This drops the headers it
+has set, while keeping the ones set by outer middleware such as the CORS ones.
+*/
+func resetHeader(header http.Header, snapshot http.Header) {
+	clear(header)
+	for key, values := range snapshot {
+		header[key] = values
+	}
+}
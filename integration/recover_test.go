@@ -0,0 +1,164 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// rejectPanic writes the 500 used to reject requests in tests.
+func rejectPanic(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusInternalServerError)
+	rw.Write([]byte(`{"errors":[{"message":"Internal server error","extensions":{"code":"INTERNAL_ERROR"}}]}`))
+}
+
+// withoutLogger returns ctx as is, since no Service is available in tests.
+func withoutLogger(ctx context.Context) context.Context {
+	return ctx
+}
+
+func TestRecoverPanic(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+
+	h := RecoverPanic(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("X-Custom", "value")
+		panic("boom")
+	}), withoutLogger, rejectPanic)
+
+	ctx, span := tracer.Start(t.Context(), "GET /")
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	rw := httptest.NewRecorder()
+	rw.Header().Set("Access-Control-Allow-Origin", "https://app.example.com")
+
+	require.NotPanics(t, func() {
+		h.ServeHTTP(rw, req)
+	})
+	span.End()
+
+	// Headers set by the handler are discarded along with its response, but the
+	// ones set before calling it are kept.
+	assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	assert.Equal(t, "https://app.example.com", rw.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rw.Header().Get("X-Custom"))
+	assert.JSONEq(t, `{"errors":[{"message":"Internal server error","extensions":{"code":"INTERNAL_ERROR"}}]}`, rw.Body.String())
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	require.Len(t, spans[0].Events, 1)
+
+	attrs := make(map[string]string)
+	for _, attr := range spans[0].Events[0].Attributes {
+		attrs[string(attr.Key)] = attr.Value.AsString()
+	}
+
+	assert.Equal(t, "panic: boom", attrs["exception.message"])
+	assert.Contains(t, attrs["exception.stacktrace"], "TestRecoverPanic")
+}
+
+func TestRecoverPanic_WrittenBeforePanic(t *testing.T) {
+	h := RecoverPanic(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+		panic("boom")
+	}), withoutLogger, rejectPanic)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rw := httptest.NewRecorder()
+
+	// The response can not be replaced once the handler started writing it, so the
+	// request is aborted instead.
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(rw, req)
+	})
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Empty(t, rw.Body.String())
+}
+
+func TestRecoverPanic_ErrAbortHandler(t *testing.T) {
+	var called bool
+	h := RecoverPanic(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), func(ctx context.Context) context.Context {
+		called = true
+		return ctx
+	}, rejectPanic)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rw := httptest.NewRecorder()
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(rw, req)
+	})
+	assert.False(t, called)
+}
+
+func TestRecoverPanic_Flush(t *testing.T) {
+	h := RecoverPanic(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		flusher, ok := rw.(http.Flusher)
+		require.True(t, ok)
+
+		rw.Write([]byte("data: ping\n\n"))
+		flusher.Flush()
+	}), withoutLogger, rejectPanic)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	assert.True(t, rw.Flushed)
+	assert.Equal(t, "data: ping\n\n", rw.Body.String())
+}
+
+func TestReportPanic(t *testing.T) {
+	testcases := []struct {
+		name      string
+		recovered any
+		expected  string
+	}{
+		{
+			name:      "string",
+			recovered: "boom",
+			expected:  "panic: boom",
+		},
+		{
+			name:      "error",
+			recovered: io.ErrUnexpectedEOF,
+			expected:  "panic: unexpected EOF",
+		},
+		{
+			name:      "other value",
+			recovered: 42,
+			expected:  "panic: 42",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ReportPanic(t.Context(), tc.recovered, nil)
+
+			assert.EqualError(t, err, tc.expected)
+			if cause, ok := tc.recovered.(error); ok {
+				assert.True(t, errors.Is(err, cause))
+			}
+		})
+	}
+}
+
+func TestReportPanic_WithoutSpan(t *testing.T) {
+	assert.False(t, oteltrace.SpanFromContext(t.Context()).SpanContext().IsValid())
+	assert.NotPanics(t, func() {
+		ReportPanic(t.Context(), "boom", []byte("goroutine 1 [running]:"))
+	})
+}
@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/mountayaapp/helix.go/integration"
+
+	"github.com/uptrace/bunrouter"
+)
+
+/*
+middlewareBodyLimit is the HTTP middleware limiting the size of request bodies to
+the limit the matched route resolved to, or to Config.MaxRequestBodyBytes for
+requests matching no route. It runs before the OpenAPI validation, which reads
+the body of the request to validate it.
+*/
+func (r *rest) middlewareBodyLimit(next bunrouter.HandlerFunc) bunrouter.HandlerFunc {
+	return func(rw http.ResponseWriter, req bunrouter.Request) error {
+		limit, ok := r.maxBodySizes[routeKey(req.Method, req.Route())]
+		if !ok {
+			limit = r.config.MaxRequestBodyBytes
+		}
+
+		if limit <= 0 {
+			return next(rw, req)
+		}
+
+		// The request passed to next is a copy with a limited body. It is set back
+		// in the bunrouter.Request so the route's params are kept.
+		var err error
+		h := integration.LimitRequestBody(http.HandlerFunc(func(rw http.ResponseWriter, limited *http.Request) {
+			req.Request = limited
+			err = next(rw, req)
+		}), limit, rejectBodyTooLarge)
+
+		h.ServeHTTP(rw, req.Request)
+		return err
+	}
+}
+
+/*
+rejectBodyTooLarge writes the 413 ResponseError rejecting a request whose body
+exceeds the limit of its route.
+*/
+func rejectBodyTooLarge(rw http.ResponseWriter, req *http.Request) {
+	NewResponseError[NoMetadata](req).
+		SetStatus(http.StatusRequestEntityTooLarge).
+		Write(rw)
+}
+
+/*
+routeKey returns the key identifying the route registered for the method and the
+full path passed, matching the method of a request and the route pattern
+bunrouter matched it to.
+*/
+func routeKey(method string, path string) string {
+	return method + " " + path
+}
+
+/*
+joinPath joins the path prefix of a group with the one of a nested group, the
+same way bunrouter does, so the full path of a route matches its route pattern.
+*/
+func joinPath(base string, path string) string {
+	path = base + path
+	if len(path) > 1 && path[len(path)-1] == '/' {
+		path = path[:len(path)-1]
+	}
+
+	return path
+}
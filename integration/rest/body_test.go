@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// chunkedBody hides the length of the reader it embeds, so a request built with
+// it has no Content-Length and its body is only known to be too large once read.
+type chunkedBody struct {
+	io.Reader
+}
+
+type createNoteInput struct {
+	Text string `json:"text"`
+}
+
+func TestRouter_MaxRequestBodyBytes(t *testing.T) {
+	r := newTestRouter(t, func(cfg *Config) {
+		cfg.MaxRequestBodyBytes = 32
+	})
+
+	var called bool
+	create := Handle(func(ctx context.Context, in createNoteInput) (createNoteInput, error) {
+		called = true
+		return in, nil
+	})
+
+	r.POST("/notes", create)
+	r.POST("/uploads", create, WithMaxBodySize(1024))
+	r.POST("/unbounded", create, WithMaxBodySize(0))
+	r.Group("/v1/").Group("/teams/:team").POST("/notes", create, WithMaxBodySize(8))
+
+	small := `{"text":"hi"}`
+	large := `{"text":"` + strings.Repeat("a", 64) + `"}`
+
+	testcases := []struct {
+		name           string
+		path           string
+		body           io.Reader
+		expectedCalled bool
+		expectedStatus int
+	}{
+		{
+			name:           "within server-wide limit",
+			path:           "/notes",
+			body:           strings.NewReader(small),
+			expectedCalled: true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Content-Length exceeds server-wide limit",
+			path:           "/notes",
+			body:           strings.NewReader(large),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "chunked body exceeds server-wide limit mid-read",
+			path:           "/notes",
+			body:           chunkedBody{strings.NewReader(large)},
+			expectedCalled: false,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "route limit raises server-wide limit",
+			path:           "/uploads",
+			body:           chunkedBody{strings.NewReader(large)},
+			expectedCalled: true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "route limit waives server-wide limit",
+			path:           "/unbounded",
+			body:           strings.NewReader(large),
+			expectedCalled: true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "route limit in nested groups lowers server-wide limit",
+			path:           "/v1/teams/acme/notes",
+			body:           strings.NewReader(small),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			called = false
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, tc.body)
+			rw := httptest.NewRecorder()
+			r.handler().ServeHTTP(rw, req)
+
+			// A chunked body exceeding the limit is detected while the handler decodes
+			// it, and the 400 it writes in reaction is replaced by the 413.
+			assert.Equal(t, tc.expectedCalled, called)
+			assert.Equal(t, tc.expectedStatus, rw.Code)
+			if tc.expectedStatus == http.StatusRequestEntityTooLarge {
+				assert.JSONEq(t, `{"errors":[{"message":"Payload exceeds size limit","extensions":{"code":"PAYLOAD_TOO_LARGE"}}]}`, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_MaxRequestBodyBytes_BeforeOpenAPIValidation(t *testing.T) {
+	r := newTestRouter(t, withTestDescription(t, ValidationModeEnforce, ValidationModeObserve), func(cfg *Config) {
+		cfg.MaxRequestBodyBytes = 32
+	})
+
+	var called bool
+	r.POST("/users/:id", func(rw http.ResponseWriter, req *http.Request) {
+		called = true
+	})
+
+	for _, body := range []io.Reader{
+		strings.NewReader(`{"email":"` + strings.Repeat("a", 64) + `@example.com"}`),
+		chunkedBody{strings.NewReader(`{"email":"` + strings.Repeat("a", 64) + `@example.com"}`)},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/users/42", body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant", "acme")
+		rw := httptest.NewRecorder()
+		r.handler().ServeHTTP(rw, req)
+
+		// The body is limited before being read for validation, so the request is
+		// rejected with a 413 rather than a 400 describing a truncated body.
+		assert.False(t, called)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+		assert.JSONEq(t, `{"errors":[{"message":"Payload exceeds size limit","extensions":{"code":"PAYLOAD_TOO_LARGE"}}]}`, rw.Body.String())
+	}
+}
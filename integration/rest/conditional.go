@@ -0,0 +1,193 @@
+package rest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+)
+
+/*
+etagKey is the context key marking a request as served by a route registered
+with WithETag, stored by the route's middleware.
+*/
+type etagKey struct{}
+
+/*
+WithETag enables conditional requests for a route, typically a read-heavy GET.
+Every 2xx ResponseSuccess written by the route carries an ETag header: the
+version set with ResponseSuccess.SetETag if any, or a hash of the marshaled
+envelope otherwise. For GET and HEAD requests, a 200 response matching the
+client's If-None-Match header — or, when the header is not set, not modified
+since its If-Modified-Since header — is replaced with a 304 with no body.
+
+The whole envelope is still built and marshaled by the handler. To also skip
+loading the resource, the handler can compare its version with ShouldWriteBody
+first.
+*/
+func WithETag() RouteOption {
+	return WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			ctx := context.WithValue(req.Context(), etagKey{}, true)
+			next.ServeHTTP(rw, req.WithContext(ctx))
+		})
+	})
+}
+
+/*
+etagEnabled reports if the request passed is served by a route registered with
+WithETag.
+*/
+func etagEnabled(req *http.Request) bool {
+	if req == nil {
+		return false
+	}
+
+	enabled, _ := req.Context().Value(etagKey{}).(bool)
+	return enabled
+}
+
+/*
+formatETag returns the version passed as a strong entity tag, quoting it if not
+already quoted. Weak tags, prefixed with W/, are returned as is.
+*/
+func formatETag(version string) string {
+	if strings.HasPrefix(version, `W/"`) || (len(version) >= 2 && strings.HasPrefix(version, `"`) && strings.HasSuffix(version, `"`)) {
+		return version
+	}
+
+	return `"` + version + `"`
+}
+
+/*
+hashETag returns the strong entity tag of the body passed.
+*/
+func hashETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+/*
+matchETag reports if etag matches one of the entity tags listed in the header
+value passed, such as the one of If-None-Match or If-Match. "*" matches any
+entity tag. With weak comparison, weak and strong tags with the same opaque value
+match; with strong comparison, weak tags never match.
+*/
+func matchETag(header string, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+
+			continue
+		}
+
+		if !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+notModified reports if the response to the GET or HEAD request passed, with the
+entity tag and last modification time passed, is not modified for the client.
+If-None-Match takes precedence over If-Modified-Since, as required by RFC 9110.
+*/
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		return matchETag(inm, etag, true)
+	}
+
+	ims := req.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+/*
+ShouldWriteBody reports if the handler of a GET or HEAD request should go on
+writing the response, given the version of the resource passed. It returns false
+when the client already has this version, per its If-None-Match header: a 304 is
+then written with the ETag header, and the handler must return without writing
+anything else. This allows to skip loading a resource when only its version is
+cheap to get:
+
+	version, err := users.Version(ctx, id)
+	if !rest.ShouldWriteBody(rw, req, version) {
+	  return
+	}
+*/
+func ShouldWriteBody(rw http.ResponseWriter, req *http.Request, version string) bool {
+	etag := formatETag(version)
+	if !notModified(req, etag, time.Time{}) {
+		return true
+	}
+
+	rw.Header().Set("ETag", etag)
+	rw.WriteHeader(http.StatusNotModified)
+	return false
+}
+
+/*
+ShouldUpdate reports if the handler of a PUT or PATCH request should go on
+updating the resource, given its current version. It returns false when the
+precondition of the request's If-Match header fails: a 412 ResponseError is then
+written, and the handler must return without updating the resource. An empty
+version means the resource does not exist. Requests without If-Match, and
+requests with other methods, always go on:
+
+	user, err := users.Get(ctx, id)
+	if !rest.ShouldUpdate(rw, req, user.Version) {
+	  return
+	}
+*/
+func ShouldUpdate(rw http.ResponseWriter, req *http.Request, version string) bool {
+	if req.Method != http.MethodPut && req.Method != http.MethodPatch {
+		return true
+	}
+
+	header := req.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+
+	if version != "" && matchETag(header, formatETag(version), false) {
+		return true
+	}
+
+	NewResponseError[NoMetadata](req).
+		SetError(errorstack.New("Resource has been modified since it was last fetched",
+			errorstack.WithCode(errorstack.CodePreconditionFailed),
+			errorstack.WithPath("request", "header", "If-Match"),
+		)).
+		Write(rw)
+
+	return false
+}
@@ -0,0 +1,297 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mountayaapp/helix.go/integration/compression"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithETag_HashOfEnvelope(t *testing.T) {
+	r := newTestRouter(t)
+	r.GET("/users/:id", func(rw http.ResponseWriter, req *http.Request) {
+		NewResponseSuccess[NoMetadata, map[string]string](req).
+			SetStatus(http.StatusOK).
+			SetData(map[string]string{"id": "usr_1"}).
+			Write(rw)
+	}, WithETag())
+
+	first := serveTest(r, http.MethodGet, "/users/usr_1")
+	etag := first.Header().Get("ETag")
+
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+
+	testcases := []struct {
+		name           string
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{
+			name:           "matching entity tag",
+			ifNoneMatch:    etag,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "matching weak entity tag in list",
+			ifNoneMatch:    `"other", W/` + etag,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "any entity tag",
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "different entity tag",
+			ifNoneMatch:    `"other"`,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/usr_1", nil)
+			req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			rw := httptest.NewRecorder()
+			r.handler().ServeHTTP(rw, req)
+
+			assert.Equal(t, tc.expectedStatus, rw.Code)
+			assert.Equal(t, etag, rw.Header().Get("ETag"))
+			if tc.expectedStatus == http.StatusNotModified {
+				assert.Empty(t, rw.Body.String())
+				assert.Empty(t, rw.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestWithETag_Compression(t *testing.T) {
+	r := newTestRouter(t)
+	r.config.Compression = compression.Config{
+		Enabled: true,
+	}
+
+	require.Empty(t, r.config.Compression.Sanitize())
+
+	r.GET("/users/:id", func(rw http.ResponseWriter, req *http.Request) {
+		NewResponseSuccess[NoMetadata, map[string]string](req).
+			SetStatus(http.StatusOK).
+			SetData(map[string]string{"bio": strings.Repeat("Jane ", 500)}).
+			Write(rw)
+	}, WithETag())
+
+	req := httptest.NewRequest(http.MethodGet, "/users/usr_1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	first := httptest.NewRecorder()
+	r.handler().ServeHTTP(first, req)
+
+	// The tag computed from the uncompressed envelope is made weak once the
+	// response is compressed.
+	etag := first.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "gzip", first.Header().Get("Content-Encoding"))
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+
+	// Revalidating with the weak tag still gets a 304.
+	req = httptest.NewRequest(http.MethodGet, "/users/usr_1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", etag)
+	second := httptest.NewRecorder()
+	r.handler().ServeHTTP(second, req)
+
+	assert.Equal(t, http.StatusNotModified, second.Code)
+	assert.Empty(t, second.Header().Get("Content-Encoding"))
+	assert.Empty(t, second.Body.String())
+}
+
+func TestWithETag_Disabled(t *testing.T) {
+	r := newTestRouter(t)
+	r.GET("/users/:id", func(rw http.ResponseWriter, req *http.Request) {
+		NewResponseSuccess[NoMetadata, NoData](req).
+			SetStatus(http.StatusOK).
+			Write(rw)
+	})
+
+	rw := serveTest(r, http.MethodGet, "/users/usr_1")
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Empty(t, rw.Header().Get("ETag"))
+}
+
+func TestResponseSuccess_SetETagAndLastModified(t *testing.T) {
+	modified := time.Date(2026, time.March, 10, 8, 30, 0, 0, time.UTC)
+
+	testcases := []struct {
+		name           string
+		method         string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{
+			name:           "without validators",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "matching version",
+			method:         http.MethodGet,
+			headers:        map[string]string{"If-None-Match": `"v3"`},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "matching version on HEAD",
+			method:         http.MethodHead,
+			headers:        map[string]string{"If-None-Match": `"v3"`},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "matching version ignored on POST",
+			method:         http.MethodPost,
+			headers:        map[string]string{"If-None-Match": `"v3"`},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not modified since",
+			method:         http.MethodGet,
+			headers:        map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "modified since",
+			method:         http.MethodGet,
+			headers:        map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "If-None-Match takes precedence over If-Modified-Since",
+			method: http.MethodGet,
+			headers: map[string]string{
+				"If-None-Match":     `"v2"`,
+				"If-Modified-Since": modified.Format(http.TimeFormat),
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/users/usr_1", nil)
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+
+			rw := httptest.NewRecorder()
+			NewResponseSuccess[NoMetadata, NoData](req).
+				SetStatus(http.StatusOK).
+				SetETag("v3").
+				SetLastModified(modified).
+				Write(rw)
+
+			assert.Equal(t, tc.expectedStatus, rw.Code)
+			assert.Equal(t, `"v3"`, rw.Header().Get("ETag"))
+			assert.Equal(t, "Tue, 10 Mar 2026 08:30:00 GMT", rw.Header().Get("Last-Modified"))
+		})
+	}
+}
+
+func TestShouldWriteBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/usr_1", nil)
+	req.Header.Set("If-None-Match", `"v3"`)
+
+	rw := httptest.NewRecorder()
+	ok := ShouldWriteBody(rw, req, "v3")
+
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusNotModified, rw.Code)
+	assert.Equal(t, `"v3"`, rw.Header().Get("ETag"))
+
+	rw = httptest.NewRecorder()
+	ok = ShouldWriteBody(rw, req, "v4")
+
+	assert.True(t, ok)
+	assert.Empty(t, rw.Header().Get("ETag"))
+}
+
+func TestShouldUpdate(t *testing.T) {
+	testcases := []struct {
+		name     string
+		method   string
+		ifMatch  string
+		version  string
+		expected bool
+	}{
+		{
+			name:     "without header",
+			method:   http.MethodPut,
+			version:  "v3",
+			expected: true,
+		},
+		{
+			name:     "matching version",
+			method:   http.MethodPatch,
+			ifMatch:  `"v3"`,
+			version:  "v3",
+			expected: true,
+		},
+		{
+			name:     "any version of an existing resource",
+			method:   http.MethodPut,
+			ifMatch:  "*",
+			version:  "v3",
+			expected: true,
+		},
+		{
+			name:     "any version of a missing resource",
+			method:   http.MethodPut,
+			ifMatch:  "*",
+			version:  "",
+			expected: false,
+		},
+		{
+			name:     "stale version",
+			method:   http.MethodPut,
+			ifMatch:  `"v2"`,
+			version:  "v3",
+			expected: false,
+		},
+		{
+			name:     "weak version never matches",
+			method:   http.MethodPut,
+			ifMatch:  `W/"v3"`,
+			version:  "v3",
+			expected: false,
+		},
+		{
+			name:     "ignored on GET",
+			method:   http.MethodGet,
+			ifMatch:  `"v2"`,
+			version:  "v3",
+			expected: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/users/usr_1", nil)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			rw := httptest.NewRecorder()
+			ok := ShouldUpdate(rw, req, tc.version)
+
+			assert.Equal(t, tc.expected, ok)
+			if !tc.expected {
+				assert.Equal(t, http.StatusPreconditionFailed, rw.Code)
+				assert.JSONEq(t, `{"errors":[{"message":"Resource has been modified since it was last fetched","path":["request","header","If-Match"],"extensions":{"code":"PRECONDITION_FAILED"}}]}`, rw.Body.String())
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/mountayaapp/helix.go/errorstack"
 	"github.com/mountayaapp/helix.go/integration"
+	"github.com/mountayaapp/helix.go/integration/compression"
 )
 
 /*
@@ -48,6 +49,19 @@ type Config struct {
 	//   0
 	RequestTimeout time.Duration `json:"request_timeout"`
 
+	// MaxRequestBodyBytes bounds the size of any route's request body, in bytes,
+	// so a client cannot stream an unbounded body into a JSON decoder. A request
+	// whose body exceeds it is rejected with a 413, whether its Content-Length
+	// announces it or a chunked body exceeds it while being read.
+	//
+	// A route deviates from it with WithMaxBodySize, such as one accepting file
+	// uploads. Zero leaves every route unbounded unless it sets a limit of its own.
+	//
+	// Default:
+	//
+	//   0
+	MaxRequestBodyBytes int64 `json:"max_request_body_bytes"`
+
 	// ReadHeaderTimeout bounds how long a client may take to send its request
 	// headers, so a connection that stalls mid-handshake cannot hold a goroutine
 	// open indefinitely.
@@ -71,13 +85,45 @@ type Config struct {
 	// authority, the CertPEM should be the concatenation of the server's
 	// certificate, any intermediates, and the CA's certificate.
 	TLS integration.ConfigTLS `json:"tls"`
+
+	// Proxies configures the proxies and load balancers trusted in front of the
+	// server, whose forwarding headers are honored to find the client's IP and
+	// origin. It applies whether Event is enabled or not.
+	Proxies integration.ConfigProxies `json:"proxies"`
+
+	// Event configures building an Event from every incoming HTTP request, made
+	// available to handlers with event.EventFromContext. Values already restored
+	// from the baggage propagated by an upstream service take precedence over the
+	// ones read from the request.
+	Event integration.ConfigEvent `json:"event"`
+
+	// CORS configures Cross-Origin Resource Sharing. When enabled, preflight
+	// requests are answered before routing, and the CORS headers are written on
+	// every response to an allowed origin, including error responses.
+	CORS integration.ConfigCORS `json:"cors"`
+
+	// Compression configures compressing responses with gzip, zstd, or brotli,
+	// negotiated from the Accept-Encoding header of every request. Streamed
+	// text/event-stream responses are never compressed.
+	Compression compression.Config `json:"compression"`
+
+	// ErrorFormat selects the format of the error responses written with
+	// ResponseError: the GraphQL-spec {"errors":[…]} envelope, RFC 9457
+	// application/problem+json, or either one negotiated from the Accept header
+	// of every request. Left empty, the {"errors":[…]} envelope is used.
+	//
+	// Default:
+	//
+	//   ErrorFormatErrors
+	ErrorFormat ErrorFormat `json:"error_format,omitempty"`
 }
 
 /*
 ConfigOpenAPI configures OpenAPI behavior within the REST API. When enabled, HTTP
 requests and responses are automatically validated againt the description passed.
-If a request is not valid, a 4xx error is returned to the client. If a response
-is not valid, an error is logged but the response is still returned to the client.
+If a request is not valid, it is rejected with a 400 error in enforce mode, and
+still passed to the handler otherwise. If a response is not valid, it is replaced
+with a 500 error in enforce mode, and still returned to the client otherwise.
 */
 type ConfigOpenAPI struct {
 
@@ -92,6 +138,49 @@ type ConfigOpenAPI struct {
 	//   "./descriptions/openapi.yaml"
 	//   "http://domain.tld/openapi.yaml"
 	Description string `json:"description,omitempty"`
+
+	// RequestValidation is how requests not matching the description are handled.
+	// In ValidationModeObserve, validation errors are recorded on the trace and the
+	// request is still passed to the handler. In ValidationModeEnforce, the request
+	// is rejected with a 400 error holding one validation entry per violation,
+	// before the handler is called.
+	//
+	// Default:
+	//
+	//   ValidationModeObserve
+	RequestValidation ValidationMode `json:"request_validation,omitempty"`
+
+	// ResponseValidation is how responses not matching the description are
+	// handled. In ValidationModeObserve, validation errors are recorded on the
+	// trace and the response is still returned to the client. In
+	// ValidationModeEnforce, responses are held back until validated: an invalid
+	// one is logged at the error level and replaced with a 500 error holding one
+	// entry per violation. Enforce mode is designed for local development and
+	// contract tests. Streamed responses are never validated.
+	//
+	// Default:
+	//
+	//   ValidationModeObserve
+	ResponseValidation ValidationMode `json:"response_validation,omitempty"`
+
+	// Serve serves the description, with its external references bundled under
+	// its components, at:
+	//
+	//   GET /openapi.json
+	//   GET /openapi.yaml
+	//
+	// The URLs of its servers are rewritten to the scheme and host the client used
+	// to reach the server. Like the health endpoints, these routes are not wrapped
+	// by Middleware.
+	Serve bool `json:"serve"`
+
+	// Docs serves an interactive documentation page of the description at:
+	//
+	//   GET /docs
+	//
+	// The page is self-contained and loads the description from /openapi.json, so
+	// Serve must be enabled as well.
+	Docs bool `json:"docs"`
 }
 
 /*
@@ -125,6 +214,13 @@ func (cfg *Config) sanitize() error {
 		})
 	}
 
+	if cfg.MaxRequestBodyBytes < 0 {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be greater than or equal to 0",
+			Path:    []any{"config", "max_request_body_bytes"},
+		})
+	}
+
 	if cfg.ReadHeaderTimeout < 0 {
 		entries = append(entries, errorstack.Entry{
 			Message: "Must be a positive duration",
@@ -146,7 +242,51 @@ func (cfg *Config) sanitize() error {
 		})
 	}
 
+	if cfg.OpenAPI.Serve && !cfg.OpenAPI.Enabled {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be enabled to serve the description",
+			Path:    []any{"config", "openapi", "enabled"},
+		})
+	}
+
+	if cfg.OpenAPI.Docs && !cfg.OpenAPI.Serve {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be enabled to serve the documentation",
+			Path:    []any{"config", "openapi", "serve"},
+		})
+	}
+
+	switch cfg.OpenAPI.RequestValidation {
+	case "", ValidationModeObserve, ValidationModeEnforce:
+	default:
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be one of: observe, enforce",
+			Path:    []any{"config", "openapi", "request_validation"},
+		})
+	}
+
+	switch cfg.OpenAPI.ResponseValidation {
+	case "", ValidationModeObserve, ValidationModeEnforce:
+	default:
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be one of: observe, enforce",
+			Path:    []any{"config", "openapi", "response_validation"},
+		})
+	}
+
+	switch cfg.ErrorFormat {
+	case "", ErrorFormatErrors, ErrorFormatProblem, ErrorFormatNegotiate:
+	default:
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be one of: errors, problem, negotiate",
+			Path:    []any{"config", "error_format"},
+		})
+	}
+
 	entries = append(entries, cfg.TLS.Sanitize()...)
+	entries = append(entries, cfg.Proxies.Sanitize()...)
+	entries = append(entries, cfg.CORS.Sanitize()...)
+	entries = append(entries, cfg.Compression.Sanitize()...)
 	if len(entries) > 0 {
 		return errorstack.NewValidation(entries...)
 	}
@@ -17,6 +17,34 @@ func TestConfig_Sanitize(t *testing.T) {
 		after  Config
 		err    error
 	}{
+		{
+			name: "problem error format is preserved",
+			before: Config{
+				ErrorFormat: ErrorFormatProblem,
+			},
+			after: Config{
+				Address:           ":8080",
+				IdleTimeout:       120 * time.Second,
+				ReadHeaderTimeout: 10 * time.Second,
+				ErrorFormat:       ErrorFormatProblem,
+			},
+			err: nil,
+		},
+		{
+			name: "unknown error format returns error",
+			before: Config{
+				ErrorFormat: "xml",
+			},
+			after: Config{
+				Address:           ":8080",
+				IdleTimeout:       120 * time.Second,
+				ReadHeaderTimeout: 10 * time.Second,
+				ErrorFormat:       "xml",
+			},
+			err: errorstack.NewValidation(
+				errorstack.Entry{Message: "Must be one of: errors, problem, negotiate", Path: []any{"config", "error_format"}},
+			),
+		},
 		{
 			name:   "empty config applies default address",
 			before: Config{},
@@ -77,6 +105,90 @@ func TestConfig_Sanitize(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name: "OpenAPI unknown request validation mode returns error",
+			before: Config{
+				OpenAPI: ConfigOpenAPI{
+					Enabled:           true,
+					Description:       "./openapi.yaml",
+					RequestValidation: "strict",
+				},
+			},
+			after: Config{
+				Address:           ":8080",
+				IdleTimeout:       120 * time.Second,
+				ReadHeaderTimeout: 10 * time.Second,
+				OpenAPI: ConfigOpenAPI{
+					Enabled:           true,
+					Description:       "./openapi.yaml",
+					RequestValidation: "strict",
+				},
+			},
+			err: errorstack.NewValidation(
+				errorstack.Entry{Message: "Must be one of: observe, enforce", Path: []any{"config", "openapi", "request_validation"}},
+			),
+		},
+		{
+			name: "OpenAPI unknown response validation mode returns error",
+			before: Config{
+				OpenAPI: ConfigOpenAPI{
+					Enabled:            true,
+					Description:        "./openapi.yaml",
+					ResponseValidation: "strict",
+				},
+			},
+			after: Config{
+				Address:           ":8080",
+				IdleTimeout:       120 * time.Second,
+				ReadHeaderTimeout: 10 * time.Second,
+				OpenAPI: ConfigOpenAPI{
+					Enabled:            true,
+					Description:        "./openapi.yaml",
+					ResponseValidation: "strict",
+				},
+			},
+			err: errorstack.NewValidation(
+				errorstack.Entry{Message: "Must be one of: observe, enforce", Path: []any{"config", "openapi", "response_validation"}},
+			),
+		},
+		{
+			name: "OpenAPI docs without serve returns error",
+			before: Config{
+				OpenAPI: ConfigOpenAPI{
+					Docs: true,
+				},
+			},
+			after: Config{
+				Address:           ":8080",
+				IdleTimeout:       120 * time.Second,
+				ReadHeaderTimeout: 10 * time.Second,
+				OpenAPI: ConfigOpenAPI{
+					Docs: true,
+				},
+			},
+			err: errorstack.NewValidation(
+				errorstack.Entry{Message: "Must be enabled to serve the documentation", Path: []any{"config", "openapi", "serve"}},
+			),
+		},
+		{
+			name: "OpenAPI serve without being enabled returns error",
+			before: Config{
+				OpenAPI: ConfigOpenAPI{
+					Serve: true,
+				},
+			},
+			after: Config{
+				Address:           ":8080",
+				IdleTimeout:       120 * time.Second,
+				ReadHeaderTimeout: 10 * time.Second,
+				OpenAPI: ConfigOpenAPI{
+					Serve: true,
+				},
+			},
+			err: errorstack.NewValidation(
+				errorstack.Entry{Message: "Must be enabled to serve the description", Path: []any{"config", "openapi", "enabled"}},
+			),
+		},
 		{
 			name: "OpenAPI disabled is valid",
 			before: Config{
@@ -297,6 +409,21 @@ func TestConfig_Sanitize(t *testing.T) {
 				errorstack.Entry{Message: "Must be a positive duration", Path: []any{"config", "idle_timeout"}},
 			),
 		},
+		{
+			name: "negative max request body bytes returns error",
+			before: Config{
+				MaxRequestBodyBytes: -1,
+			},
+			after: Config{
+				Address:             ":8080",
+				IdleTimeout:         120 * time.Second,
+				ReadHeaderTimeout:   10 * time.Second,
+				MaxRequestBodyBytes: -1,
+			},
+			err: errorstack.NewValidation(
+				errorstack.Entry{Message: "Must be greater than or equal to 0", Path: []any{"config", "max_request_body_bytes"}},
+			),
+		},
 	}
 
 	for _, tc := range testcases {
@@ -308,3 +435,40 @@ func TestConfig_Sanitize(t *testing.T) {
 		})
 	}
 }
+
+/*
+TestConfig_Sanitize_Proxies only asserts on the error returned: the table above
+compares whole configs, which cannot account for the trusted proxies parsed into
+the unexported state of integration.ConfigProxies.
+*/
+func TestConfig_Sanitize_Proxies(t *testing.T) {
+	testcases := []struct {
+		name    string
+		proxies integration.ConfigProxies
+		err     error
+	}{
+		{
+			name:    "valid trusted proxies are valid",
+			proxies: integration.ConfigProxies{Trusted: []string{"10.0.0.0/8", "192.168.1.1"}},
+			err:     nil,
+		},
+		{
+			name:    "invalid trusted proxy returns error with Event disabled",
+			proxies: integration.ConfigProxies{Trusted: []string{"10.0.0.0/8", "not-an-ip"}},
+			err: errorstack.NewValidation(
+				errorstack.Entry{
+					Message: "Must be a valid IP address or CIDR range",
+					Path:    []any{"config", "proxies", "trusted", 1},
+				},
+			),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{Proxies: tc.proxies}
+
+			assert.Equal(t, tc.err, cfg.sanitize())
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package rest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/mountayaapp/helix.go/integration"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/uptrace/bunrouter"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Paths of the OpenAPI endpoints, registered when enabled in ConfigOpenAPI.
+*/
+const (
+	pathOpenAPIJSON = "/openapi.json"
+	pathOpenAPIYAML = "/openapi.yaml"
+	pathDocs        = "/docs"
+)
+
+/*
+docsPage is the self-contained documentation page served at /docs. It has no
+external assets, and renders the description loaded from /openapi.json.
+*/
+//go:embed docs.html
+var docsPage []byte
+
+/*
+handlerOpenAPIJSON is the handler function serving the OpenAPI description as
+JSON.
+*/
+func (r *rest) handlerOpenAPIJSON(rw http.ResponseWriter, req bunrouter.Request) error {
+	b, err := json.Marshal(r.servedDescription(req.Request))
+	if err != nil {
+		NewResponseError[NoMetadata](req.Request).
+			SetStatus(http.StatusInternalServerError).
+			Write(rw)
+
+		return nil
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(b)
+
+	return nil
+}
+
+/*
+handlerOpenAPIYAML is the handler function serving the OpenAPI description as
+YAML.
+*/
+func (r *rest) handlerOpenAPIYAML(rw http.ResponseWriter, req bunrouter.Request) error {
+	b, err := yaml.Marshal(r.servedDescription(req.Request))
+	if err != nil {
+		NewResponseError[NoMetadata](req.Request).
+			SetStatus(http.StatusInternalServerError).
+			Write(rw)
+
+		return nil
+	}
+
+	rw.Header().Set("Content-Type", "application/yaml")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(b)
+
+	return nil
+}
+
+/*
+handlerDocs is the handler function serving the documentation page.
+*/
+func (r *rest) handlerDocs(rw http.ResponseWriter, req bunrouter.Request) error {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(docsPage)
+
+	return nil
+}
+
+/*
+servedDescription returns a copy of the OpenAPI description to serve for the
+request passed, with the URLs of its servers rewritten to the scheme and host the
+client used to reach the server. The path of each server URL is preserved, so
+"https://api.tld/v1" is served as "http://localhost:8080/v1" in local
+development. A description declaring no server is served with the root of the
+host. Server URLs ending up identical are only served once.
+*/
+func (r *rest) servedDescription(req *http.Request) *openapi3.T {
+	doc := *r.oapidoc
+	scheme, host := integration.RequestOrigin(req)
+
+	declared := r.oapidoc.Servers
+	if len(declared) == 0 {
+		declared = openapi3.Servers{&openapi3.Server{URL: "/"}}
+	}
+
+	seen := make(map[string]struct{}, len(declared))
+	doc.Servers = make(openapi3.Servers, 0, len(declared))
+	for _, server := range declared {
+		u := url.URL{Path: "/"}
+		if parsed, err := url.Parse(server.URL); err == nil && parsed.Path != "" {
+			u.Path = parsed.Path
+		}
+
+		if host != "" {
+			u.Scheme = scheme
+			u.Host = host
+		}
+
+		rewritten := u.String()
+		if _, ok := seen[rewritten]; ok {
+			continue
+		}
+
+		seen[rewritten] = struct{}{}
+		doc.Servers = append(doc.Servers, &openapi3.Server{
+			URL:         rewritten,
+			Description: server.Description,
+		})
+	}
+
+	return &doc
+}
@@ -0,0 +1,210 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mountayaapp/helix.go/integration"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testDescriptionServed = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: 1.0.0
+servers:
+  - url: https://api.tld/v1
+    description: Production
+  - url: https://staging.api.tld/v1
+    description: Staging
+paths:
+  /users:
+    get:
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "./schemas.yaml#/User"
+`
+
+const testSchemasServed = `
+User:
+  type: object
+  properties:
+    email:
+      type: string
+`
+
+/*
+withTestDescriptionServed enables the OpenAPI description split across two files,
+served as the flags passed tell, with a user middleware rejecting every request
+it wraps.
+*/
+func withTestDescriptionServed(t *testing.T, serve bool, docs bool) func(cfg *Config) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "openapi.yaml"), []byte(testDescriptionServed), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "schemas.yaml"), []byte(testSchemasServed), 0o600))
+
+	return func(cfg *Config) {
+		cfg.Middleware = func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusUnauthorized)
+			})
+		}
+
+		cfg.OpenAPI = ConfigOpenAPI{
+			Enabled:     true,
+			Description: filepath.Join(dir, "openapi.yaml"),
+			Serve:       serve,
+			Docs:        docs,
+		}
+	}
+}
+
+func TestHandlerOpenAPI(t *testing.T) {
+	r := newTestRouter(t, withTestDescriptionServed(t, true, true))
+	expected := []any{
+		map[string]any{"url": "http://localhost:8080/v1", "description": "Production"},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:8080/openapi.json", nil)
+		rw := httptest.NewRecorder()
+		r.handler().ServeHTTP(rw, req)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+		assert.NotContains(t, rw.Body.String(), "schemas.yaml", "external references must be bundled")
+
+		var doc map[string]any
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &doc))
+		assert.Equal(t, expected, doc["servers"])
+		assert.Contains(t, doc["components"].(map[string]any)["schemas"], "schemas_User")
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:8080/openapi.yaml", nil)
+		rw := httptest.NewRecorder()
+		r.handler().ServeHTTP(rw, req)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "application/yaml", rw.Header().Get("Content-Type"))
+
+		var doc map[string]any
+		require.NoError(t, yaml.Unmarshal(rw.Body.Bytes(), &doc))
+		assert.Equal(t, expected, doc["servers"])
+	})
+
+	t.Run("docs", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:8080/docs", nil)
+		rw := httptest.NewRecorder()
+		r.handler().ServeHTTP(rw, req)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rw.Header().Get("Content-Type"))
+		assert.Contains(t, rw.Body.String(), `fetch("/openapi.json")`)
+	})
+
+	t.Run("user route still wrapped by middleware", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:8080/users", nil)
+		rw := httptest.NewRecorder()
+		r.handler().ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	})
+}
+
+func TestHandlerOpenAPI_TrustedProxy(t *testing.T) {
+	r := newTestRouter(t, withTestDescriptionServed(t, true, false))
+
+	// The Event is left disabled: the trusted proxies are honored regardless.
+	r.config.Proxies = integration.ConfigProxies{Trusted: []string{"10.0.0.0/8"}}
+	require.Empty(t, r.config.Proxies.Sanitize())
+
+	req := httptest.NewRequest(http.MethodGet, "http://internal:8080/openapi.json", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.tld")
+	rw := httptest.NewRecorder()
+	r.handler().ServeHTTP(rw, req)
+
+	require.Equal(t, http.StatusOK, rw.Code)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &doc))
+	assert.Equal(t, []any{
+		map[string]any{"url": "https://api.tld/v1", "description": "Production"},
+	}, doc["servers"])
+}
+
+func TestHandlerOpenAPI_Disabled(t *testing.T) {
+	r := newTestRouter(t, withTestDescriptionServed(t, false, false))
+
+	for _, path := range []string{pathOpenAPIJSON, pathOpenAPIYAML, pathDocs} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			rw := httptest.NewRecorder()
+			r.bun.ServeHTTP(rw, req)
+
+			assert.Equal(t, http.StatusNotFound, rw.Code)
+		})
+	}
+}
+
+func TestServedDescription_Servers(t *testing.T) {
+	r := newTestRouter(t, withTestDescriptionServed(t, true, false))
+
+	testcases := []struct {
+		name     string
+		servers  []string
+		host     string
+		expected []string
+	}{
+		{
+			name:     "no server declared",
+			host:     "localhost:8080",
+			expected: []string{"http://localhost:8080/"},
+		},
+		{
+			name:     "relative and templated servers",
+			servers:  []string{"/v2", "{scheme}://{host}/v1"},
+			host:     "api.tld",
+			expected: []string{"http://api.tld/v2", "http://api.tld/"},
+		},
+		{
+			name:     "request without host",
+			servers:  []string{"https://api.tld/v1"},
+			expected: []string{"/v1"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r.oapidoc.Servers = nil
+			for _, server := range tc.servers {
+				r.oapidoc.Servers = append(r.oapidoc.Servers, &openapi3.Server{URL: server})
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+			req.Host = tc.host
+
+			var actual []string
+			for _, server := range r.servedDescription(req).Servers {
+				actual = append(actual, server.URL)
+			}
+
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
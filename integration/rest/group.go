@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/uptrace/bunrouter"
+)
+
+/*
+Ensure *group complies to the REST type, so groups can be nested.
+*/
+var _ REST = (*group)(nil)
+
+/*
+group is a set of routes registered under a common path prefix, built on top of
+bunrouter's groups. It carries the default options and the middleware of its
+routes.
+*/
+type group struct {
+
+	// rest is the REST integration the group belongs to.
+	rest *rest
+
+	// bun is the underlying group, registering routes under the group's prefix.
+	bun *bunrouter.CompatGroup
+
+	// prefix is the full path prefix of the group's routes, including the ones of
+	// its parent groups.
+	prefix string
+
+	// opts are the default options of the group's routes, inherited from the
+	// parent group and applied before the route's own.
+	opts []RouteOption
+
+	// middleware is the middleware wrapping the group's routes, inherited from the
+	// parent group and added with Use.
+	middleware []func(next http.Handler) http.Handler
+}
+
+/*
+GET registers a handler for the GET method at path under the group's prefix,
+under the policy resolved from the group's options and opts.
+*/
+func (g *group) GET(path string, handler http.HandlerFunc, opts ...RouteOption) {
+	g.bun.GET(path, g.apply(http.MethodGet, path, handler, opts))
+}
+
+/*
+DELETE registers a handler for the DELETE method at path under the group's
+prefix, under the policy resolved from the group's options and opts.
+*/
+func (g *group) DELETE(path string, handler http.HandlerFunc, opts ...RouteOption) {
+	g.bun.DELETE(path, g.apply(http.MethodDelete, path, handler, opts))
+}
+
+/*
+PATCH registers a handler for the PATCH method at path under the group's prefix,
+under the policy resolved from the group's options and opts.
+*/
+func (g *group) PATCH(path string, handler http.HandlerFunc, opts ...RouteOption) {
+	g.bun.PATCH(path, g.apply(http.MethodPatch, path, handler, opts))
+}
+
+/*
+POST registers a handler for the POST method at path under the group's prefix,
+under the policy resolved from the group's options and opts.
+*/
+func (g *group) POST(path string, handler http.HandlerFunc, opts ...RouteOption) {
+	g.bun.POST(path, g.apply(http.MethodPost, path, handler, opts))
+}
+
+/*
+PUT registers a handler for the PUT method at path under the group's prefix,
+under the policy resolved from the group's options and opts.
+*/
+func (g *group) PUT(path string, handler http.HandlerFunc, opts ...RouteOption) {
+	g.bun.PUT(path, g.apply(http.MethodPut, path, handler, opts))
+}
+
+/*
+Group returns a nested group registering routes under prefix, appended to the
+group's own. It inherits the group's options, followed by the ones passed, and
+the middleware added with Use so far.
+*/
+func (g *group) Group(prefix string, opts ...RouteOption) REST {
+	return &group{
+		rest:       g.rest,
+		bun:        g.bun.NewGroup(prefix),
+		prefix:     joinPath(g.prefix, prefix),
+		opts:       append(slices.Clone(g.opts), opts...),
+		middleware: slices.Clone(g.middleware),
+	}
+}
+
+/*
+Use adds middleware wrapping the group's routes registered afterwards, after the
+middleware inherited from the parent group.
+*/
+func (g *group) Use(middleware ...func(next http.Handler) http.Handler) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+/*
+apply wraps handler with the group's middleware and the policy resolved from the
+group's options followed by the route's own.
+*/
+func (g *group) apply(method string, path string, handler http.HandlerFunc, opts []RouteOption) http.HandlerFunc {
+	return g.rest.applyRouteOptions(method, g.prefix+path, handler, g.middleware, append(slices.Clone(g.opts), opts...))
+}
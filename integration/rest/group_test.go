@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tagMiddleware returns a middleware appending name to the X-Trail response
+// header, so tests can assert which middleware wrapped a route and in which
+// order.
+func tagMiddleware(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Add("X-Trail", name)
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+func serveTest(r *rest, method string, target string) *httptest.ResponseRecorder {
+	rw := httptest.NewRecorder()
+	r.bun.ServeHTTP(rw, httptest.NewRequest(method, target, nil))
+
+	return rw
+}
+
+func TestGroup_Prefix(t *testing.T) {
+	r := newTestRouter(t)
+	ok := func(rw http.ResponseWriter, _ *http.Request) { rw.WriteHeader(http.StatusNoContent) }
+
+	v2 := r.Group("/v2")
+	v2.GET("/users", ok)
+	v2.Group("/admin").DELETE("/users/:id", ok)
+
+	assert.Equal(t, http.StatusNoContent, serveTest(r, http.MethodGet, "/v2/users").Code)
+	assert.Equal(t, http.StatusNoContent, serveTest(r, http.MethodDelete, "/v2/admin/users/1").Code)
+	assert.Equal(t, http.StatusNotFound, serveTest(r, http.MethodGet, "/users").Code)
+}
+
+func TestGroup_Middleware(t *testing.T) {
+	r := newTestRouter(t)
+	r.Use(tagMiddleware("root"))
+
+	handler := func(http.ResponseWriter, *http.Request) {}
+
+	admin := r.Group("/admin")
+	admin.Use(tagMiddleware("admin"))
+	admin.GET("/users/:id", handler)
+
+	audit := admin.Group("/audit")
+	audit.Use(tagMiddleware("audit"))
+	audit.GET("/events", handler)
+
+	// Middleware added after a route or a group is registered does not wrap it.
+	admin.Use(tagMiddleware("late"))
+	r.GET("/public", handler)
+
+	testcases := []struct {
+		target   string
+		expected []string
+	}{
+		{target: "/admin/users/1", expected: []string{"root", "admin"}},
+		{target: "/admin/audit/events", expected: []string{"root", "admin", "audit"}},
+		{target: "/public", expected: []string{"root"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.target, func(t *testing.T) {
+			rw := serveTest(r, http.MethodGet, tc.target)
+			assert.Equal(t, tc.expected, rw.Header().Values("X-Trail"))
+		})
+	}
+}
+
+func TestGroup_MiddlewareSeesParams(t *testing.T) {
+	r := newTestRouter(t)
+
+	var id string
+	admin := r.Group("/admin")
+	admin.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			params, _ := ParamsFromContext(req.Context())
+			id = params["id"]
+			next.ServeHTTP(rw, req)
+		})
+	})
+	admin.GET("/users/:id", func(http.ResponseWriter, *http.Request) {})
+
+	serveTest(r, http.MethodGet, "/admin/users/42")
+
+	assert.Equal(t, "42", id)
+}
+
+func TestGroup_DefaultOptions(t *testing.T) {
+	r := newTestRouter(t)
+	r.config.RequestTimeout = 30 * time.Second
+
+	deadlines := map[string]time.Duration{}
+	record := func(rw http.ResponseWriter, req *http.Request) {
+		deadline, ok := req.Context().Deadline()
+		if ok {
+			deadlines[req.URL.Path] = time.Until(deadline).Round(time.Second)
+		}
+	}
+
+	slow := r.Group("/reports", WithTimeout(2*time.Minute))
+	slow.GET("/daily", record)
+	slow.GET("/stream", record, WithoutTimeout())
+	slow.Group("/quick", WithTimeout(5*time.Second)).GET("/summary", record)
+	r.GET("/users", record)
+
+	for _, path := range []string{"/reports/daily", "/reports/stream", "/reports/quick/summary", "/users"} {
+		serveTest(r, http.MethodGet, path)
+	}
+
+	require.Len(t, deadlines, 3)
+	assert.Equal(t, 2*time.Minute, deadlines["/reports/daily"])
+	assert.Equal(t, 5*time.Second, deadlines["/reports/quick/summary"])
+	assert.Equal(t, 30*time.Second, deadlines["/users"])
+	assert.NotContains(t, deadlines, "/reports/stream")
+}
+
+// Every verb of a group registers under its prefix and carries its policy.
+func TestGroup_EveryVerbCarriesOptions(t *testing.T) {
+	for _, method := range []string{http.MethodGet, http.MethodDelete, http.MethodPatch, http.MethodPost, http.MethodPut} {
+		t.Run(method, func(t *testing.T) {
+			r := newTestRouter(t)
+			g := r.Group("/v1", WithTimeout(30*time.Second)).(*group)
+			register := map[string]func(string, http.HandlerFunc, ...RouteOption){
+				http.MethodGet:    g.GET,
+				http.MethodDelete: g.DELETE,
+				http.MethodPatch:  g.PATCH,
+				http.MethodPost:   g.POST,
+				http.MethodPut:    g.PUT,
+			}[method]
+
+			var ok bool
+			register("/verb", func(_ http.ResponseWriter, req *http.Request) {
+				_, ok = req.Context().Deadline()
+			})
+
+			serveTest(r, method, "/v1/verb")
+			assert.True(t, ok, "handler should observe a deadline")
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package rest
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+)
+
+/*
+Validator is implemented by the input of a typed handler needing validation
+beyond decoding, such as required fields or ranges. Validate is called once the
+input has been decoded, and returns one entry per violation. Paths of the
+entries should start with "request" and the location of the value, as for
+decoding errors. Validate can be declared on a value or a pointer receiver:
+
+	func (in CreateUserInput) Validate() []errorstack.Entry {
+	  var entries []errorstack.Entry
+	  if in.Email == "" {
+	    entries = append(entries, errorstack.Entry{
+	      Message: "Must be set",
+	      Path:    []any{"request", "body", "email"},
+	    })
+	  }
+
+	  return entries
+	}
+*/
+type Validator interface {
+	Validate() []errorstack.Entry
+}
+
+/*
+validatorOf returns the Validator implemented by the input pointed to by in,
+whether Validate is declared on a value or a pointer receiver.
+*/
+func validatorOf[In any](in *In) (Validator, bool) {
+	if validator, ok := any(in).(Validator); ok {
+		return validator, true
+	}
+
+	validator, ok := any(*in).(Validator)
+	return validator, ok
+}
+
+/*
+Locations an input field can be decoded from, in addition to the JSON body. The
+struct tag of a field is its location, and its value the name of the parameter.
+*/
+var inputLocations = []string{"path", "query", "header"}
+
+/*
+inputField is a field of a typed handler's input decoded from the path, query, or
+headers of the request.
+*/
+type inputField struct {
+	index    []int
+	location string
+	name     string
+	required bool
+}
+
+/*
+Handle adapts a typed function into an http.HandlerFunc to register on the REST
+API. For each request, it:
+
+  - decodes the JSON body, if any, into In;
+  - decodes the fields of In tagged with `path:"name"`, `query:"name"`, or
+    `header:"Name"` from the route's params, the query, and the headers. They
+    are only ever read from there, so a value decoded from the body into them
+    is dropped. Tag values accept a ",required" option, and slices receive
+    every value of a query parameter or header;
+  - calls Validate if In implements Validator;
+  - calls fn with the request's context and the input;
+  - writes the Out returned as the "data" of a 200 ResponseSuccess, or the
+    error returned with ResponseError.SetError, so *errorstack.Error values are
+    mapped to the status matching their code.
+
+Decoding and validation errors are written as a 400 ResponseError holding one
+VALIDATION_FAILED entry per violation, before fn is called. Their paths start
+with "request" and the location of the value, such as ["request", "query",
+"limit"] or ["request", "body", "email"].
+
+Fields of In can be strings, booleans, numbers, time.Duration, types
+implementing encoding.TextUnmarshaler, and pointers and slices of these. Example:
+
+	type GetUserInput struct {
+	  ID     string `path:"id,required"`
+	  Fields []string `query:"fields"`
+	}
+
+	router.GET("/users/:id", rest.Handle(func(ctx context.Context, in GetUserInput) (User, error) {
+	  return users.Get(ctx, in.ID)
+	}))
+
+Out can be NoData for writing no "data" object.
+*/
+func Handle[In any, Out any](fn func(ctx context.Context, in In) (Out, error)) http.HandlerFunc {
+	fields := inputFieldsOf(reflect.TypeFor[In]())
+
+	return func(rw http.ResponseWriter, req *http.Request) {
+		var in In
+		entries := decodeBody(req, &in)
+		entries = append(entries, decodeFields(req, reflect.ValueOf(&in).Elem(), fields)...)
+		if len(entries) == 0 {
+			if validator, ok := validatorOf(&in); ok {
+				entries = validator.Validate()
+			}
+		}
+
+		if len(entries) > 0 {
+			NewResponseError[NoMetadata](req).
+				SetStatus(http.StatusBadRequest).
+				SetValidations(entries...).
+				Write(rw)
+
+			return
+		}
+
+		out, err := fn(req.Context(), in)
+		if err != nil {
+			NewResponseError[NoMetadata](req).
+				SetError(err).
+				Write(rw)
+
+			return
+		}
+
+		res := NewResponseSuccess[NoMetadata, Out](req).SetStatus(http.StatusOK)
+		if _, ok := any(out).(NoData); !ok {
+			res.SetData(out)
+		}
+
+		res.Write(rw)
+	}
+}
+
+/*
+inputFieldsOf returns the fields of t to decode from the path, query, or headers
+of requests, including the ones promoted from embedded structs. Returns nil if t
+is not a struct.
+*/
+func inputFieldsOf(t reflect.Type) []inputField {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []inputField
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() {
+			continue
+		}
+
+		for _, location := range inputLocations {
+			tag, ok := field.Tag.Lookup(location)
+			if !ok {
+				continue
+			}
+
+			name, options, _ := strings.Cut(tag, ",")
+			fields = append(fields, inputField{
+				index:    field.Index,
+				location: location,
+				name:     name,
+				required: options == "required",
+			})
+		}
+	}
+
+	return fields
+}
+
+/*
+decodeBody decodes the JSON body of the request into in, if the request has a
+body. Returns validation entries if the body is not valid JSON, or if a value
+does not match the type of the field it is decoded into.
+*/
+func decodeBody(req *http.Request, in any) []errorstack.Entry {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	err := json.NewDecoder(req.Body).Decode(in)
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		path := []any{"request", "body"}
+		if typeErr.Field != "" {
+			path = appendPointer(path, strings.Split(typeErr.Field, "."))
+		}
+
+		return []errorstack.Entry{{
+			Message: "Must be of type " + jsonTypeOf(typeErr.Type),
+			Path:    path,
+		}}
+	}
+
+	return []errorstack.Entry{{
+		Message: "Must be valid JSON",
+		Path:    []any{"request", "body"},
+	}}
+}
+
+/*
+decodeFields decodes the fields of in tagged with a location from the request.
+They are zeroed first, so a value decoded from the body is never mistaken for
+one read from its location. Returns one validation entry per field missing or
+not valid.
+*/
+func decodeFields(req *http.Request, in reflect.Value, fields []inputField) []errorstack.Entry {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	for _, field := range fields {
+		if target, err := in.FieldByIndexErr(field.index); err == nil {
+			target.SetZero()
+		}
+	}
+
+	params, _ := ParamsFromContext(req.Context())
+	query := req.URL.Query()
+
+	var entries []errorstack.Entry
+	for _, field := range fields {
+		var values []string
+		switch field.location {
+		case "path":
+			if value, ok := params[field.name]; ok {
+				values = []string{value}
+			}
+
+		case "query":
+			values = query[field.name]
+
+		case "header":
+			values = req.Header.Values(field.name)
+		}
+
+		path := []any{"request", field.location, field.name}
+		if len(values) == 0 {
+			if field.required {
+				entries = append(entries, errorstack.Entry{
+					Message: "Must be set",
+					Path:    path,
+				})
+			}
+
+			continue
+		}
+
+		target, err := in.FieldByIndexErr(field.index)
+		if err != nil {
+			continue
+		}
+
+		if message := setValues(target, values); message != "" {
+			entries = append(entries, errorstack.Entry{
+				Message: message,
+				Path:    path,
+			})
+		}
+	}
+
+	return entries
+}
+
+/*
+setValues sets v from the raw values passed. Slices receive every value, other
+types the first one only. Returns the message of the validation entry to report
+if a value can not be parsed, or an empty string on success.
+*/
+func setValues(v reflect.Value, values []string) string {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if message := setValue(slice.Index(i), value); message != "" {
+				return message
+			}
+		}
+
+		v.Set(slice)
+		return ""
+	}
+
+	return setValue(v, values[0])
+}
+
+/*
+durationType is the reflect.Type of time.Duration, parsed with time.ParseDuration
+rather than as an integer.
+*/
+var durationType = reflect.TypeFor[time.Duration]()
+
+/*
+setValue sets v from the raw value passed. Returns the message of the validation
+entry to report if the value can not be parsed, or an empty string on success.
+*/
+func setValue(v reflect.Value, value string) string {
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if message := setValue(ptr.Elem(), value); message != "" {
+			return message
+		}
+
+		v.Set(ptr)
+		return ""
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(value)); err != nil {
+			return "Must be a valid value"
+		}
+
+		return ""
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return "Must be a duration"
+		}
+
+		v.SetInt(int64(d))
+		return ""
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "Must be a boolean"
+		}
+
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return "Must be an integer"
+		}
+
+		v.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return "Must be a non-negative integer"
+		}
+
+		v.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return "Must be a number"
+		}
+
+		v.SetFloat(f)
+
+	default:
+		return "Must be a supported type"
+	}
+
+	return ""
+}
+
+/*
+jsonTypeOf returns the JSON type matching the Go type passed, as reported in
+validation entries.
+*/
+func jsonTypeOf(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+
+	case reflect.Bool:
+		return "boolean"
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+
+	case reflect.Float32, reflect.Float64:
+		return "number"
+
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+
+	return "object"
+}
@@ -0,0 +1,255 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bunrouter"
+)
+
+type testHandleInput struct {
+	ID      int64         `path:"id,required"`
+	Fields  []string      `query:"fields"`
+	Limit   *int          `query:"limit"`
+	Timeout time.Duration `query:"timeout"`
+	Since   time.Time     `query:"since"`
+	Tenant  string        `header:"X-Tenant,required"`
+	Email   string        `json:"email"`
+	Items   []struct {
+		Quantity int `json:"quantity"`
+	} `json:"items"`
+}
+
+func (in testHandleInput) Validate() []errorstack.Entry {
+	if in.Email == "invalid" {
+		return []errorstack.Entry{{Message: "Must be a valid email", Path: []any{"request", "body", "email"}}}
+	}
+
+	return nil
+}
+
+/*
+serveHandle registers handler at pattern on a router, and serves the request
+passed.
+*/
+func serveHandle(pattern string, handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	router := bunrouter.New().Compat()
+	router.Handle(req.Method, pattern, handler)
+
+	rw := httptest.NewRecorder()
+	router.ServeHTTP(rw, req)
+
+	return rw
+}
+
+func TestHandle(t *testing.T) {
+	var received testHandleInput
+	handler := Handle(func(ctx context.Context, in testHandleInput) (map[string]any, error) {
+		received = in
+		return map[string]any{"id": in.ID}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/42?fields=name&fields=email&limit=10&timeout=5s&since=2026-01-02T00:00:00Z", strings.NewReader(`{"email":"john@example.com","items":[{"quantity":2}]}`))
+	req.Header.Set("X-Tenant", "acme")
+
+	rw := serveHandle("/users/:id", handler, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"data":{"id":42}}`, rw.Body.String())
+
+	assert.Equal(t, int64(42), received.ID)
+	assert.Equal(t, []string{"name", "email"}, received.Fields)
+	assert.Equal(t, 10, *received.Limit)
+	assert.Equal(t, 5*time.Second, received.Timeout)
+	assert.Equal(t, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), received.Since)
+	assert.Equal(t, "acme", received.Tenant)
+	assert.Equal(t, "john@example.com", received.Email)
+	assert.Equal(t, 2, received.Items[0].Quantity)
+}
+
+func TestHandle_LocationFieldsIgnoreBody(t *testing.T) {
+	var received testHandleInput
+	handler := Handle(func(ctx context.Context, in testHandleInput) (NoData, error) {
+		received = in
+		return NoData{}, nil
+	})
+
+	// Fields tagged with a location are only read from it, whatever the body
+	// holds for them.
+	req := httptest.NewRequest(http.MethodPost, "/users/42", strings.NewReader(`{"ID":7,"Tenant":"other","Fields":["secret"],"Limit":5,"email":"john@example.com"}`))
+	req.Header.Set("X-Tenant", "acme")
+
+	rw := serveHandle("/users/:id", handler, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, int64(42), received.ID)
+	assert.Equal(t, "acme", received.Tenant)
+	assert.Nil(t, received.Fields)
+	assert.Nil(t, received.Limit)
+	assert.Equal(t, "john@example.com", received.Email)
+}
+
+func TestHandle_InvalidInput(t *testing.T) {
+	testcases := []struct {
+		name     string
+		target   string
+		tenant   string
+		body     string
+		expected []errorstack.Entry
+	}{
+		{
+			name:   "invalid parameters",
+			target: "/users/abc?limit=ten&timeout=soon",
+			body:   `{}`,
+			expected: []errorstack.Entry{
+				{Message: "Must be an integer", Path: []any{"request", "path", "id"}},
+				{Message: "Must be an integer", Path: []any{"request", "query", "limit"}},
+				{Message: "Must be a duration", Path: []any{"request", "query", "timeout"}},
+				{Message: "Must be set", Path: []any{"request", "header", "X-Tenant"}},
+			},
+		},
+		{
+			name:   "invalid JSON",
+			target: "/users/1",
+			tenant: "acme",
+			body:   `{"email":`,
+			expected: []errorstack.Entry{
+				{Message: "Must be valid JSON", Path: []any{"request", "body"}},
+			},
+		},
+		{
+			name:   "mismatching JSON type",
+			target: "/users/1",
+			tenant: "acme",
+			body:   `{"items":[{"quantity":"two"}]}`,
+			expected: []errorstack.Entry{
+				{Message: "Must be of type integer", Path: []any{"request", "body", "items", 0, "quantity"}},
+			},
+		},
+		{
+			name:   "validator",
+			target: "/users/1",
+			tenant: "acme",
+			body:   `{"email":"invalid"}`,
+			expected: []errorstack.Entry{
+				{Message: "Must be a valid email", Path: []any{"request", "body", "email"}},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			handler := Handle(func(ctx context.Context, in testHandleInput) (NoData, error) {
+				called = true
+				return NoData{}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body))
+			if tc.tenant != "" {
+				req.Header.Set("X-Tenant", tc.tenant)
+			}
+
+			rw := serveHandle("/users/:id", handler, req)
+
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			assert.False(t, called, "handler must not be called")
+			assertEntries(t, rw.Body.Bytes(), errorstack.CodeValidationFailed, tc.expected)
+		})
+	}
+}
+
+func TestHandle_Error(t *testing.T) {
+	testcases := []struct {
+		name     string
+		err      error
+		status   int
+		expected string
+	}{
+		{
+			name:     "errorstack error",
+			err:      errorstack.New("User does not exist", errorstack.WithCode(errorstack.CodeNotFound)),
+			status:   http.StatusNotFound,
+			expected: `{"errors":[{"message":"User does not exist","extensions":{"code":"NOT_FOUND"}}]}`,
+		},
+		{
+			name:     "standard error",
+			err:      context.Canceled,
+			status:   http.StatusInternalServerError,
+			expected: `{"errors":[{"message":"Internal server error","extensions":{"code":"INTERNAL_ERROR"}}]}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := Handle(func(ctx context.Context, in NoData) (NoData, error) {
+				return NoData{}, tc.err
+			})
+
+			rw := serveHandle("/users", handler, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+			assert.Equal(t, tc.status, rw.Code)
+			assert.JSONEq(t, tc.expected, rw.Body.String())
+		})
+	}
+}
+
+func TestHandle_NoData(t *testing.T) {
+	handler := Handle(func(ctx context.Context, in NoData) (NoData, error) {
+		return NoData{}, nil
+	})
+
+	rw := serveHandle("/users", handler, httptest.NewRequest(http.MethodDelete, "/users", nil))
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"data":null}`, rw.Body.String())
+}
+
+type testPointerValidatedInput struct {
+	Email string `json:"email"`
+}
+
+func (in *testPointerValidatedInput) Validate() []errorstack.Entry {
+	if in.Email == "invalid" {
+		return []errorstack.Entry{{Message: "Must be a valid email", Path: []any{"request", "body", "email"}}}
+	}
+
+	return nil
+}
+
+func TestHandle_PointerReceiverValidator(t *testing.T) {
+	var called bool
+	handler := Handle(func(ctx context.Context, in testPointerValidatedInput) (NoData, error) {
+		called = true
+		return NoData{}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"email":"invalid"}`))
+	rw := serveHandle("/users", handler, req)
+
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	assert.False(t, called)
+	assert.Contains(t, rw.Body.String(), "Must be a valid email")
+}
+
+func TestHandle_NonStructInput(t *testing.T) {
+	var received []string
+	handler := Handle(func(ctx context.Context, in []string) (int, error) {
+		received = in
+		return len(in), nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/tags", strings.NewReader(`["a","b"]`))
+	rw := serveHandle("/tags", handler, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"data":2}`, rw.Body.String())
+	assert.Equal(t, []string{"a", "b"}, received)
+}
@@ -0,0 +1,357 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/event"
+	"github.com/mountayaapp/helix.go/integration/valkey"
+	"github.com/mountayaapp/helix.go/telemetry/log"
+
+	"github.com/uptrace/bunrouter"
+)
+
+/*
+headerIdempotencyKey is the request header holding the idempotency key chosen by
+the client, and headerIdempotentReplayed the response header set when a response
+is replayed.
+*/
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+)
+
+/*
+maxIdempotencyKeyLength is the maximum length of an idempotency key. Keys are
+typically UUIDs, so this leaves plenty of room while bounding what is stored.
+*/
+const maxIdempotencyKeyLength = 255
+
+/*
+idempotencyLease is how long the key is locked while the first request is being
+processed, so a key whose response is never stored — such as when the process is
+killed mid-request — is released shortly rather than after the whole TTL. The
+lease is renewed for as long as the handler runs, however long it takes. Once
+the response is written, it is stored for the TTL passed to WithIdempotency.
+*/
+const idempotencyLease = time.Minute
+
+/*
+idempotencyRecord is the value stored in Valkey for an idempotency key. It is
+first stored while the request is being processed, holding only the hash of the
+request, and then replaced with the response once written.
+*/
+type idempotencyRecord struct {
+	Done   bool        `json:"done"`
+	Hash   string      `json:"hash"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+/*
+WithIdempotency makes a route safe to retry, typically a POST creating a
+resource. When a request carries an Idempotency-Key header, the key is locked in
+Valkey with SetNX for a short lease before calling the handler, and the response
+written — status, headers, and body — is stored for ttl. Then, for a request
+with the same key:
+
+  - if the first request is still being processed, it is rejected with a 409;
+  - if its method, path, query, and body do not match the first request's, it is
+    rejected with a 422;
+  - otherwise, the stored response is replayed without calling the handler, with
+    the Idempotent-Replayed header set to "true".
+
+Requests without the header are handled as usual. Keys are scoped to the route
+and, when found in the Event, to the UserID, so clients can not replay each
+other's responses. Responses with a 5xx status are not stored and the key is
+released, so the request can be retried.
+
+The idempotency is applied as a route middleware, in the order it is passed along
+WithMiddleware. Pass it after the middleware setting the Event's UserID:
+
+	router.POST("/payments", createPayment, rest.WithMiddleware(auth), rest.WithIdempotency(store, 24*time.Hour))
+
+If store is nil or ttl is not positive, the route is not made idempotent and
+Start returns a validation error.
+*/
+func WithIdempotency(store valkey.Valkey, ttl time.Duration) RouteOption {
+	var entries []errorstack.Entry
+	if store == nil {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be set",
+			Path:    []any{"idempotency", "valkey"},
+		})
+	}
+
+	if ttl <= 0 {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be a positive duration",
+			Path:    []any{"idempotency", "ttl"},
+		})
+	}
+
+	if len(entries) > 0 {
+		return func(o *routeOptions) {
+			o.entries = append(o.entries, entries...)
+		}
+	}
+
+	return WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			value := req.Header.Get(headerIdempotencyKey)
+			if value == "" {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			if len(value) > maxIdempotencyKeyLength {
+				NewResponseError[NoMetadata](req).
+					SetStatus(http.StatusBadRequest).
+					SetValidations(errorstack.Entry{
+						Message: "Must be at most 255 characters",
+						Path:    []any{"request", "header", headerIdempotencyKey},
+					}).
+					Write(rw)
+
+				return
+			}
+
+			hash, err := hashRequest(req)
+			if err != nil {
+				NewResponseError[NoMetadata](req).
+					SetStatus(http.StatusBadRequest).
+					Write(rw)
+
+				return
+			}
+
+			serveIdempotent(store, ttl, min(idempotencyLease, ttl), idempotencyKey(req, value), hash, next, rw, req)
+		})
+	})
+}
+
+/*
+serveIdempotent locks the key passed for lease, renewed while next runs, and
+calls next, or replays the response stored for the key if it is already locked.
+*/
+func serveIdempotent(store valkey.Valkey, ttl time.Duration, lease time.Duration, key string, hash string, next http.Handler, rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	pending, _ := json.Marshal(idempotencyRecord{Hash: hash})
+	locked, err := store.SetNX(ctx, key, pending, lease)
+	if err != nil {
+		NewResponseError[NoMetadata](req).
+			SetStatus(http.StatusServiceUnavailable).
+			Write(rw)
+
+		return
+	}
+
+	if !locked {
+		replayIdempotent(store, key, hash, rw, req)
+		return
+	}
+
+	// Release the key unless the response is written, so a request failing with a
+	// 5xx — or a panic — can be retried with the same key.
+	var written bool
+	defer func() {
+		if !written {
+			store.Delete(context.WithoutCancel(ctx), []string{key})
+		}
+	}()
+
+	// The renewal is stopped before the response is stored, so it never changes
+	// the TTL of the response, and before the key is released.
+	stopRenewal := renewIdempotencyLease(ctx, store, key, lease)
+	defer stopRenewal()
+
+	// Only headers set by the handler and the inner middleware are stored, as they
+	// were sent by them. The ones already set, such as the CORS and rate limit
+	// headers, and the ones changed afterwards by the outer middleware, such as the
+	// Content-Encoding and ETag set by the compression one, are set again when the
+	// response is replayed.
+	before := rw.Header().Clone()
+	w := &responseWriter{
+		status:         http.StatusOK,
+		ResponseWriter: rw,
+		buf:            &bytes.Buffer{},
+	}
+
+	next.ServeHTTP(w, req)
+	stopRenewal()
+	if w.streaming || w.status >= http.StatusInternalServerError {
+		return
+	}
+
+	record := idempotencyRecord{
+		Done:   true,
+		Hash:   hash,
+		Status: w.status,
+		Header: make(http.Header),
+		Body:   w.buf.Bytes(),
+	}
+
+	sent := w.sent
+	if sent == nil {
+		sent = rw.Header()
+	}
+
+	for name, values := range sent {
+		if !slices.Equal(before[name], values) {
+			record.Header[name] = values
+		}
+	}
+
+	// The side effects of the handler already happened, so the key is not released
+	// even if the response can not be stored: retries are rejected with a 409
+	// until the lease expires, rather than running the handler a second time right
+	// away.
+	written = true
+	b, err := json.Marshal(record)
+	if err == nil {
+		err = store.Set(context.WithoutCancel(ctx), key, b, &valkey.OptionsSet{TTL: ttl})
+	}
+
+	if err != nil {
+		log.Error(ctx, "Failed to store idempotent response",
+			log.String("integration", identifier),
+			log.String("method", req.Method),
+			log.String("route", bunrouter.ParamsFromContext(ctx).Route()),
+			log.Int("status", w.status),
+			log.Err(err),
+		)
+	}
+}
+
+/*
+renewIdempotencyLease extends the lease of the key passed every half lease, until
+the function returned is called. The function returned waits for the renewal to
+stop, and can be called many times.
+*/
+func renewIdempotencyLease(ctx context.Context, store valkey.Valkey, key string, lease time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(lease / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				store.Expire(ctx, key, lease)
+			}
+		}
+	}()
+
+	return sync.OnceFunc(func() {
+		cancel()
+		<-done
+	})
+}
+
+/*
+replayIdempotent writes the response stored for the key passed, or rejects the
+request if the first one is still being processed or did not match.
+*/
+func replayIdempotent(store valkey.Valkey, key string, hash string, rw http.ResponseWriter, req *http.Request) {
+	var record idempotencyRecord
+	b, err := store.Get(req.Context(), key, nil)
+	if err == nil {
+		err = json.Unmarshal(b, &record)
+	}
+
+	// The key may have been released in the meantime, when the first request
+	// failed. The client is asked to retry, just like when it is still pending.
+	if err != nil {
+		writeIdempotencyConflict(rw, req)
+		return
+	}
+
+	if record.Hash != hash {
+		NewResponseError[NoMetadata](req).
+			SetStatus(http.StatusUnprocessableEntity).
+			SetValidations(errorstack.Entry{
+				Message: "Must not be reused for a different request",
+				Path:    []any{"request", "header", headerIdempotencyKey},
+			}).
+			Write(rw)
+
+		return
+	}
+
+	if !record.Done {
+		writeIdempotencyConflict(rw, req)
+		return
+	}
+
+	for name, values := range record.Header {
+		rw.Header()[name] = values
+	}
+
+	rw.Header().Set(headerIdempotentReplayed, "true")
+	rw.WriteHeader(record.Status)
+	rw.Write(record.Body)
+}
+
+/*
+writeIdempotencyConflict rejects a request with a 409, when a request with the
+same idempotency key is being processed. The client is asked to retry shortly.
+*/
+func writeIdempotencyConflict(rw http.ResponseWriter, req *http.Request) {
+	NewResponseError[NoMetadata](req).
+		SetError(errorstack.New("Request with the same idempotency key is being processed",
+			errorstack.WithCode(errorstack.CodeConflict),
+			errorstack.WithRetryAfter(time.Second),
+		)).
+		Write(rw)
+}
+
+/*
+idempotencyKey returns the key to store in Valkey for the idempotency key passed
+by the client. It is scoped to the route and to the UserID of the Event, if any,
+and hashed so the value chosen by the client is never stored as is.
+*/
+func idempotencyKey(req *http.Request, value string) string {
+	e, _ := event.EventFromContext(req.Context())
+	route := bunrouter.ParamsFromContext(req.Context()).Route()
+	sum := sha256.Sum256([]byte(e.UserID + "\x00" + value))
+
+	return "idempotency:" + req.Method + " " + route + ":" + hex.EncodeToString(sum[:])
+}
+
+/*
+hashRequest returns the hash of the method, path, query, and body of the request,
+to detect an idempotency key reused for a different request. The body is read in
+full, and replaced so the handler can still read it.
+*/
+func hashRequest(req *http.Request) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.URL.Path + "?" + req.URL.RawQuery + "\x00"))
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return "", err
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,378 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/integration/compression"
+	"github.com/mountayaapp/helix.go/integration/valkey"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIdempotencyValkey implements the methods of valkey.Valkey used for
+// idempotency with an in-memory map. Set fails with setErr when not nil. Other
+// methods are not implemented and panic if called.
+type fakeIdempotencyValkey struct {
+	valkey.Valkey
+
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	setErr error
+
+	mu       sync.Mutex
+	renewals []time.Duration
+}
+
+func (f *fakeIdempotencyValkey) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	if _, ok := f.values[key]; ok {
+		return false, nil
+	}
+
+	f.values[key] = value
+	f.setTTL(key, ttl)
+	return true, nil
+}
+
+func (f *fakeIdempotencyValkey) Get(ctx context.Context, key string, opts *valkey.OptionsGet) ([]byte, error) {
+	value, ok := f.values[key]
+	if !ok {
+		return nil, errorstack.New("Resource does not exist", errorstack.WithCode(errorstack.CodeNotFound))
+	}
+
+	return value, nil
+}
+
+func (f *fakeIdempotencyValkey) Set(ctx context.Context, key string, value []byte, opts *valkey.OptionsSet) error {
+	if f.setErr != nil {
+		return f.setErr
+	}
+
+	f.values[key] = value
+	if opts != nil {
+		f.setTTL(key, opts.TTL)
+	}
+
+	return nil
+}
+
+// setTTL records the TTL the key passed was last set with.
+func (f *fakeIdempotencyValkey) setTTL(key string, ttl time.Duration) {
+	if f.ttls == nil {
+		f.ttls = make(map[string]time.Duration)
+	}
+
+	f.ttls[key] = ttl
+}
+
+// Expire records the lease renewals, which happen in their own goroutine.
+func (f *fakeIdempotencyValkey) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.renewals = append(f.renewals, ttl)
+	return nil
+}
+
+// renewed returns the TTLs the lease was renewed with so far.
+func (f *fakeIdempotencyValkey) renewed() []time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.renewals)
+}
+
+func (f *fakeIdempotencyValkey) Delete(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		delete(f.values, key)
+	}
+
+	return nil
+}
+
+// handleTestPayments registers an idempotent route creating payments, answering
+// with the status passed. Returns the number of times its handler was called.
+func handleTestPayments(r *rest, store valkey.Valkey, status int) *int {
+	var calls int
+	r.POST("/payments", func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		rw.Header().Set("Location", "/payments/pay_1")
+		NewResponseSuccess[NoMetadata, map[string]string](req).
+			SetStatus(status).
+			SetData(map[string]string{"id": "pay_1"}).
+			Write(rw)
+	}, WithIdempotency(store, time.Hour))
+
+	return &calls
+}
+
+func servePayment(r *rest, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	rw := httptest.NewRecorder()
+	r.handler().ServeHTTP(rw, req)
+
+	return rw
+}
+
+func TestWithIdempotency_ReplaysResponse(t *testing.T) {
+	store := &fakeIdempotencyValkey{values: map[string][]byte{}}
+	r := newTestRouter(t)
+	calls := handleTestPayments(r, store, http.StatusCreated)
+
+	first := servePayment(r, "key-1", `{"amount":100}`)
+	second := servePayment(r, "key-1", `{"amount":100}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "/payments/pay_1", second.Header().Get("Location"))
+	assert.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+
+	// The key stored is hashed, so the value chosen by the client is not stored
+	// as is.
+	for key := range store.values {
+		assert.True(t, strings.HasPrefix(key, "idempotency:POST /payments:"))
+		assert.NotContains(t, key, "key-1")
+	}
+}
+
+func TestWithIdempotency_Compression(t *testing.T) {
+	store := &fakeIdempotencyValkey{values: map[string][]byte{}}
+	note := strings.Repeat("paid ", 500)
+
+	var calls int
+	r := newTestRouter(t)
+	r.config.Compression = compression.Config{Enabled: true}
+	require.Empty(t, r.config.Compression.Sanitize())
+
+	r.POST("/payments", func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		rw.Header().Set("ETag", `"pay_1"`)
+		NewResponseSuccess[NoMetadata, map[string]string](req).
+			SetStatus(http.StatusCreated).
+			SetData(map[string]string{"id": "pay_1", "note": note}).
+			Write(rw)
+	}, WithIdempotency(store, time.Hour))
+
+	var responses []*httptest.ResponseRecorder
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{"amount":100}`))
+		req.Header.Set("Idempotency-Key", "key-1")
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		rw := httptest.NewRecorder()
+		r.handler().ServeHTTP(rw, req)
+		responses = append(responses, rw)
+	}
+
+	// The response is stored as written by the handler, so the replay is
+	// compressed again rather than labeled as compressed.
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "true", responses[1].Header().Get("Idempotent-Replayed"))
+	for _, rw := range responses {
+		assert.Equal(t, http.StatusCreated, rw.Code)
+		assert.Equal(t, "gzip", rw.Header().Get("Content-Encoding"))
+		assert.Equal(t, `W/"pay_1"`, rw.Header().Get("ETag"))
+		assert.Equal(t, []string{"Accept-Encoding"}, rw.Header().Values("Vary"))
+
+		gr, err := gzip.NewReader(rw.Body)
+		require.NoError(t, err)
+
+		decoded, err := io.ReadAll(gr)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"data":{"id":"pay_1","note":"`+note+`"}}`, string(decoded))
+	}
+}
+
+func TestWithIdempotency_WithoutHeader(t *testing.T) {
+	store := &fakeIdempotencyValkey{values: map[string][]byte{}}
+	r := newTestRouter(t)
+	calls := handleTestPayments(r, store, http.StatusCreated)
+
+	servePayment(r, "", `{"amount":100}`)
+	servePayment(r, "", `{"amount":100}`)
+
+	assert.Equal(t, 2, *calls)
+	assert.Empty(t, store.values)
+}
+
+func TestWithIdempotency_DifferentBody(t *testing.T) {
+	store := &fakeIdempotencyValkey{values: map[string][]byte{}}
+	r := newTestRouter(t)
+	calls := handleTestPayments(r, store, http.StatusCreated)
+
+	servePayment(r, "key-1", `{"amount":100}`)
+	rw := servePayment(r, "key-1", `{"amount":200}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, rw.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"Must not be reused for a different request","path":["request","header","Idempotency-Key"],"extensions":{"code":"VALIDATION_FAILED"}}]}`, rw.Body.String())
+}
+
+func TestWithIdempotency_DifferentQuery(t *testing.T) {
+	store := &fakeIdempotencyValkey{values: map[string][]byte{}}
+	r := newTestRouter(t)
+	calls := handleTestPayments(r, store, http.StatusCreated)
+
+	servePayment(r, "key-1", `{"amount":100}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/payments?currency=usd", strings.NewReader(`{"amount":100}`))
+	req.Header.Set("Idempotency-Key", "key-1")
+	rw := httptest.NewRecorder()
+	r.handler().ServeHTTP(rw, req)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, rw.Code)
+}
+
+func TestWithIdempotency_LeaseThenTTL(t *testing.T) {
+	store := &fakeIdempotencyValkey{values: map[string][]byte{}}
+
+	// The key is locked for the lease while the handler runs, and the response
+	// stored for the TTL once written.
+	var leased []time.Duration
+	r := newTestRouter(t)
+	r.POST("/payments", func(rw http.ResponseWriter, req *http.Request) {
+		for _, ttl := range store.ttls {
+			leased = append(leased, ttl)
+		}
+
+		rw.WriteHeader(http.StatusCreated)
+	}, WithIdempotency(store, 24*time.Hour))
+
+	servePayment(r, "key-1", `{"amount":100}`)
+
+	assert.Equal(t, []time.Duration{idempotencyLease}, leased)
+	require.Len(t, store.ttls, 1)
+	for _, ttl := range store.ttls {
+		assert.Equal(t, 24*time.Hour, ttl)
+	}
+}
+
+func TestWithIdempotency_RenewsLease(t *testing.T) {
+	store := &fakeIdempotencyValkey{values: map[string][]byte{}}
+	lease := 20 * time.Millisecond
+
+	// The handler outlives the lease, which is renewed until it returns, and no
+	// longer once the response is stored.
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		time.Sleep(3 * lease)
+		rw.WriteHeader(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/payments", nil)
+	serveIdempotent(store, time.Hour, lease, "key-1", "hash", next, httptest.NewRecorder(), req)
+
+	renewed := store.renewed()
+	assert.GreaterOrEqual(t, len(renewed), 2)
+	for _, ttl := range renewed {
+		assert.Equal(t, lease, ttl)
+	}
+
+	time.Sleep(2 * lease)
+	assert.Len(t, store.renewed(), len(renewed))
+	assert.Equal(t, time.Hour, store.ttls["key-1"])
+}
+
+func TestWithIdempotency_StoreFailureKeepsKey(t *testing.T) {
+	store := &fakeIdempotencyValkey{
+		values: map[string][]byte{},
+		setErr: errors.New("connection refused"),
+	}
+
+	r := newTestRouter(t)
+	calls := handleTestPayments(r, store, http.StatusCreated)
+
+	// The first response reaches the client, but the key stays locked by its
+	// lease, so a retry does not run the handler a second time.
+	first := servePayment(r, "key-1", `{"amount":100}`)
+	second := servePayment(r, "key-1", `{"amount":100}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusConflict, second.Code)
+	assert.Len(t, store.values, 1)
+}
+
+func TestWithIdempotency_NotValid(t *testing.T) {
+	r := newTestRouter(t)
+	r.POST("/payments", func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusCreated)
+	}, WithIdempotency(nil, 0))
+
+	err := r.Start(context.Background())
+
+	assert.Equal(t, errorstack.NewValidation(
+		errorstack.Entry{Message: "Must be set", Path: []any{"routes", "POST /payments", "idempotency", "valkey"}},
+		errorstack.Entry{Message: "Must be a positive duration", Path: []any{"routes", "POST /payments", "idempotency", "ttl"}},
+	), err)
+}
+
+func TestWithIdempotency_PendingKey(t *testing.T) {
+	store := &fakeIdempotencyValkey{values: map[string][]byte{}}
+
+	// Retry from the handler, while the first request is still being processed.
+	var rw *httptest.ResponseRecorder
+	r := newTestRouter(t)
+	r.POST("/slow", func(w http.ResponseWriter, req *http.Request) {
+		retry := httptest.NewRequest(http.MethodPost, "/slow", strings.NewReader(`{}`))
+		retry.Header.Set("Idempotency-Key", "key-1")
+		rw = httptest.NewRecorder()
+		r.handler().ServeHTTP(rw, retry)
+
+		w.WriteHeader(http.StatusCreated)
+	}, WithIdempotency(store, time.Hour))
+
+	req := httptest.NewRequest(http.MethodPost, "/slow", strings.NewReader(`{}`))
+	req.Header.Set("Idempotency-Key", "key-1")
+	r.handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NotNil(t, rw)
+	assert.Equal(t, http.StatusConflict, rw.Code)
+	assert.Equal(t, "1", rw.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"errors":[{"message":"Request with the same idempotency key is being processed","extensions":{"code":"CONFLICT"}}]}`, rw.Body.String())
+}
+
+func TestWithIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	store := &fakeIdempotencyValkey{values: map[string][]byte{}}
+	r := newTestRouter(t)
+	calls := handleTestPayments(r, store, http.StatusServiceUnavailable)
+
+	servePayment(r, "key-1", `{"amount":100}`)
+	rw := servePayment(r, "key-1", `{"amount":100}`)
+
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+	assert.Empty(t, rw.Header().Get("Idempotent-Replayed"))
+	assert.Empty(t, store.values)
+}
+
+func TestWithIdempotency_KeyTooLong(t *testing.T) {
+	store := &fakeIdempotencyValkey{values: map[string][]byte{}}
+	r := newTestRouter(t)
+	calls := handleTestPayments(r, store, http.StatusCreated)
+
+	rw := servePayment(r, strings.Repeat("k", 256), `{"amount":100}`)
+
+	assert.Equal(t, 0, *calls)
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/mountayaapp/helix.go/errorstack"
 	"github.com/mountayaapp/helix.go/integration"
+	"github.com/mountayaapp/helix.go/service"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
@@ -23,34 +24,15 @@ func (r *rest) Name() string {
 }
 
 /*
-Start starts the HTTP server of the HTTP REST integration.
+Start starts the HTTP server of the HTTP REST integration. Returns a validation
+error without starting it if options of the routes registered are not valid.
 */
 func (r *rest) Start(ctx context.Context) error {
-
-	// Wrap the built-in HTTP handler with the one given by the user, if applicable.
-	// Skip user middleware for the health endpoint so it always responds without
-	// requiring authentication or other service-level checks.
-	var h http.Handler = r.bun
-	if r.config.Middleware != nil {
-		wrapped := r.config.Middleware(r.bun)
-		h = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-			if req.URL.Path == "/health" || req.URL.Path == "/ready" {
-				r.bun.ServeHTTP(rw, req)
-				return
-			}
-
-			wrapped.ServeHTTP(rw, req)
-		})
+	if len(r.entries) > 0 {
+		return errorstack.NewValidation(r.entries...)
 	}
 
-	// Wrap the handler previously built with the one designed for OpenTelemetry
-	// traces.
-	h = otelhttp.NewHandler(h, "",
-		otelhttp.WithMessageEvents(otelhttp.ReadEvents, otelhttp.WriteEvents),
-		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
-			return r.Method + " " + r.URL.Path
-		}),
-	)
+	h := r.handler()
 
 	// Create the HTTP server with the given configuration and the handler built.
 	// WriteTimeout is deliberately left unset: it is an absolute deadline on the
@@ -87,6 +69,86 @@ func (r *rest) Start(ctx context.Context) error {
 	return nil
 }
 
+/*
+handler returns the HTTP handler served by the HTTP server of the HTTP REST
+integration, wrapping the built-in one with the user's middleware, the panic
+recovery, the Event and trusted proxies middleware, the compression and CORS
+middleware, and the OpenTelemetry handler.
+*/
+func (r *rest) handler() http.Handler {
+
+	// Wrap the built-in HTTP handler with the one given by the user, if applicable.
+	// Skip user middleware for the built-in endpoints so they always respond
+	// without requiring authentication or other service-level checks.
+	var h http.Handler = r.bun
+	if r.config.Middleware != nil {
+		wrapped := r.config.Middleware(r.bun)
+		h = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if r.isBuiltIn(req.URL.Path) {
+				r.bun.ServeHTTP(rw, req)
+				return
+			}
+
+			wrapped.ServeHTTP(rw, req)
+		})
+	}
+
+	// Recover panics of the router and the user's middleware, writing a 500 in
+	// place of the response. Panics of routes are recovered by recoverRoute. This
+	// is applied inside the Event middleware so the panic is logged with the Event,
+	// and inside the OpenTelemetry handler so it is recorded on the request's span.
+	h = integration.RecoverPanic(h, r.logContext, rejectPanic)
+
+	// Build an Event from every incoming request, if enabled. This is applied
+	// outside of the user's middleware so it can read and enrich the Event, and
+	// inside the OpenTelemetry handler so the Event restored from the baggage
+	// propagated by an upstream service is already in the request context.
+	h = r.config.Event.Middleware(h)
+
+	// Make the trusted proxies available to the handlers and middleware finding
+	// the client's IP and origin, including the Event middleware, whether the
+	// Event is enabled or not.
+	h = r.config.Proxies.Middleware(h)
+
+	// Store the error format configured in every request context, so error
+	// responses written by handlers and by the router itself share it.
+	h = errorFormatMiddleware(r.config.ErrorFormat, h)
+
+	// Compress responses, if enabled. This is applied outside of the user's
+	// middleware so error responses are compressed as well, and inside the CORS
+	// middleware so preflight requests are answered without being buffered.
+	h = r.config.Compression.Middleware(h)
+
+	// Handle CORS, if enabled. This is applied outside of the user's middleware
+	// and before routing, so preflight requests are answered without requiring
+	// authentication, and so the CORS headers are set on every response,
+	// including errors written by the router and the user's middleware.
+	h = r.config.CORS.Middleware(h)
+
+	// Wrap the handler previously built with the one designed for OpenTelemetry
+	// traces.
+	h = otelhttp.NewHandler(h, "",
+		otelhttp.WithMessageEvents(otelhttp.ReadEvents, otelhttp.WriteEvents),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}),
+	)
+
+	return h
+}
+
+/*
+logContext returns a copy of ctx enriched with the Service's logger and tracer,
+so logs written from a request context are sent with the Service's ones.
+*/
+func (r *rest) logContext(ctx context.Context) context.Context {
+	if r.svc != nil {
+		ctx = service.Context(r.svc, ctx)
+	}
+
+	return ctx
+}
+
 /*
 Stop tries to gracefully stop the HTTP server.
 */
@@ -104,3 +166,23 @@ Status always returns a `200` status.
 func (r *rest) Status(ctx context.Context) (int, error) {
 	return 200, nil
 }
+
+/*
+isBuiltIn returns true if path is the one of an endpoint registered by the REST
+integration itself: the health endpoints, and the OpenAPI ones when enabled in
+Config.
+*/
+func (r *rest) isBuiltIn(path string) bool {
+	switch path {
+	case "/health", "/ready":
+		return true
+
+	case pathOpenAPIJSON, pathOpenAPIYAML:
+		return r.config.OpenAPI.Serve
+
+	case pathDocs:
+		return r.config.OpenAPI.Docs
+	}
+
+	return false
+}
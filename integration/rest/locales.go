@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"io/fs"
+
 	"github.com/mountayaapp/helix.go/internal/locales"
 
 	"golang.org/x/text/language"
@@ -22,6 +24,7 @@ Supported status code:
   - [http.StatusNotFound]
   - [http.StatusMethodNotAllowed]
   - [http.StatusConflict]
+  - [http.StatusPreconditionFailed]
   - [http.StatusRequestEntityTooLarge]
   - [http.StatusTooManyRequests]
   - [http.StatusInternalServerError]
@@ -40,6 +43,7 @@ Example:
 		http.StatusNotFound:              "<locale>",
 		http.StatusMethodNotAllowed:      "<locale>",
 		http.StatusConflict:              "<locale>",
+		http.StatusPreconditionFailed:    "<locale>",
 		http.StatusRequestEntityTooLarge: "<locale>",
 		http.StatusTooManyRequests:       "<locale>",
 		http.StatusInternalServerError:   "<locale>",
@@ -52,3 +56,62 @@ Example:
 func AddOrEditLanguage(lang language.Tag, m map[int]string) {
 	locales.AddOrEditLanguage(lang, m)
 }
+
+/*
+AddOrEditMessages allows a service to add or edit the translations of the error
+entries carrying a message ID, set with errorstack.WithMessageID. Templates are
+keyed by message ID and may reference the entry's parameters with {name}
+placeholders. When no translation exists for the client's language, the entry's
+own message is kept as the English fallback. The catalog is shared with the
+GraphQL integration.
+
+Example:
+
+	rest.AddOrEditMessages(language.French, map[string]string{
+		"must_be_set": "Doit être renseigné",
+		"min_length":  "Doit contenir au moins {min} caractères",
+	})
+*/
+func AddOrEditMessages(lang language.Tag, m map[string]string) {
+	locales.AddOrEditMessages(lang, m)
+}
+
+/*
+LoadLanguages allows a service to add or edit languages from catalog files
+instead of Go maps, loading every file of fsys matching the glob pattern passed.
+fsys can be an embed.FS or a directory opened with os.DirFS. Each file holds the
+catalog of the language it is named after (e.g. "fr.yaml", "pt-BR.json"), as
+JSON or YAML. Messages keyed by message ID may have plural forms, selected by the "count" parameter of the entry.
+The catalog is shared with the GraphQL and MCP integrations.
+
+Example, with locales/fr.yaml:
+
+	statuses:
+	  400: Requête invalide
+	messages:
+	  must_be_set: Doit être renseigné
+	  items_selected:
+	    one: "{count} élément sélectionné"
+	    other: "{count} éléments sélectionnés"
+
+And:
+
+	//go:embed locales/*.yaml
+	var catalogs embed.FS
+
+	err := rest.LoadLanguages(catalogs, "locales/*.yaml")
+*/
+func LoadLanguages(fsys fs.FS, pattern string) error {
+	return locales.LoadFS(fsys, pattern)
+}
+
+/*
+AddBuiltInLanguages adds the translations of the default per-status error
+messages shipped with helix.go for the languages passed: French, German,
+Spanish, Italian, Portuguese, and Japanese. All of them are added when no
+language is passed. Translations set previously for the same status codes are
+replaced, so custom ones should be set after calling this function.
+*/
+func AddBuiltInLanguages(langs ...language.Tag) error {
+	return locales.AddBuiltInLanguages(langs...)
+}
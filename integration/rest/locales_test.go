@@ -3,10 +3,12 @@ package rest
 import (
 	"net/http"
 	"testing"
+	"testing/fstest"
 
 	"github.com/mountayaapp/helix.go/internal/locales"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/text/language"
 )
 
@@ -37,3 +39,17 @@ func httpReqWithHeader(lang string) *http.Request {
 	req.Header.Set("Accept-Language", lang)
 	return req
 }
+
+func TestLoadLanguages_DelegatesToSharedCatalog(t *testing.T) {
+	fsys := fstest.MapFS{
+		"locales/yo.yaml": {Data: []byte("statuses:\n  400: rest-loaded-marker\n")},
+	}
+
+	require.NoError(t, LoadLanguages(fsys, "locales/*.yaml"))
+	assert.Equal(t, "rest-loaded-marker", locales.Message(httpReqWithHeader("yo"), http.StatusBadRequest))
+}
+
+func TestAddBuiltInLanguages_DelegatesToSharedCatalog(t *testing.T) {
+	require.NoError(t, AddBuiltInLanguages(language.Japanese))
+	assert.Equal(t, "リクエストが無効です", locales.Message(httpReqWithHeader("ja"), http.StatusBadRequest))
+}
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"net/url"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/mountayaapp/helix.go/errorstack"
 	"github.com/mountayaapp/helix.go/integration"
+	"github.com/mountayaapp/helix.go/telemetry/log"
 	"github.com/mountayaapp/helix.go/telemetry/trace"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -45,6 +47,22 @@ type responseWriter struct {
 	// written and is neither buffered nor validated against the OpenAPI
 	// description, which only describes finite bodies.
 	streaming bool
+
+	// held reports whether the response is held back until validated against the
+	// OpenAPI description, so an invalid one can be replaced. The status code and
+	// body are only written to the client on release. A streamed response is
+	// released as soon as it is detected.
+	held bool
+
+	// header is a copy of the headers set before the response was held, such as
+	// the CORS ones set by outer middleware. They are restored when the response
+	// held is discarded, so the response written in its place keeps them.
+	header http.Header
+
+	// sent is a copy of the headers as they were when the status code was written
+	// to the underlying http.ResponseWriter, before outer middleware — such as the
+	// compression one — could change them. It is nil until then.
+	sent http.Header
 }
 
 /*
@@ -55,6 +73,38 @@ func (rw *responseWriter) Unwrap() http.ResponseWriter {
 	return rw.ResponseWriter
 }
 
+/*
+FlushError flushes the response written so far to the client, as called by
+http.ResponseController. It is a no-op while the response is held, so a flush
+never bypasses the buffer of a response yet to be validated.
+*/
+func (rw *responseWriter) FlushError() error {
+	if !rw.streaming {
+		rw.detectStreaming()
+	}
+
+	if rw.streaming {
+		rw.release()
+	}
+
+	if rw.held {
+		return nil
+	}
+
+	if rw.sent == nil {
+		rw.writeHeader(rw.status)
+	}
+
+	return http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+/*
+Flush implements http.Flusher. See FlushError.
+*/
+func (rw *responseWriter) Flush() {
+	rw.FlushError()
+}
+
 /*
 detectStreaming flags the response as an incremental stream when the handler sets a
 text/event-stream Content-Type. It is idempotent and cheap to call before a write.
@@ -77,22 +127,94 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	// unbounded stream would grow the buffer without limit, and its body can not
 	// be validated against a finite OpenAPI schema.
 	if rw.streaming {
+		rw.release()
 		return rw.ResponseWriter.Write(b)
 	}
 
+	if rw.held {
+		return rw.buf.Write(b)
+	}
+
+	if rw.sent == nil {
+		rw.writeHeader(rw.status)
+	}
+
 	rw.ResponseWriter.Write(b)
 	return rw.buf.Write(b)
 }
 
 /*
-WriteHeader sends an HTTP response header with the provided status code.
+WriteHeader sends an HTTP response header with the provided status code. It is
+only stored if the response is held, until released.
 */
 func (rw *responseWriter) WriteHeader(status int) {
 	rw.status = status
 	rw.detectStreaming()
+	if rw.held {
+		if rw.streaming {
+			rw.release()
+		}
+
+		return
+	}
+
+	rw.writeHeader(status)
+}
+
+/*
+writeHeader writes the status code to the underlying http.ResponseWriter, keeping
+a copy of the headers sent along the first time.
+*/
+func (rw *responseWriter) writeHeader(status int) {
+	if rw.sent == nil {
+		rw.sent = rw.Header().Clone()
+	}
+
 	rw.ResponseWriter.WriteHeader(status)
 }
 
+/*
+hold holds the response back until released or discarded, keeping a copy of the
+headers set so far.
+*/
+func (rw *responseWriter) hold() {
+	rw.held = true
+	rw.header = rw.Header().Clone()
+}
+
+/*
+release writes the status code and body held back so far to the client, and stops
+holding the response. It is a no-op if the response is not held.
+*/
+func (rw *responseWriter) release() {
+	if !rw.held {
+		return
+	}
+
+	rw.held = false
+	rw.writeHeader(rw.status)
+	rw.ResponseWriter.Write(rw.buf.Bytes())
+}
+
+/*
+discard drops the status code, headers, and body held back so far, so another
+response can be written to the client in place of the one held. Headers set
+before the response was held are restored. It is a no-op if the response is not
+held.
+*/
+func (rw *responseWriter) discard() {
+	if !rw.held {
+		return
+	}
+
+	rw.held = false
+	rw.buf.Reset()
+	clear(rw.Header())
+	for key, values := range rw.header {
+		rw.Header()[key] = values
+	}
+}
+
 /*
 middlewareValidation is the HTTP middleware to validate a request/response against
 the OpenAPI description passed in the integration's config.
@@ -116,7 +238,7 @@ func (r *rest) middlewareValidation(next bunrouter.HandlerFunc) bunrouter.Handle
 		// Try to find the route in the OpenAPI description. If the path is not found
 		// or if the method is not allowed, it's already catched by the router itself
 		// so there's no need to handle this here.
-		r, params, err := r.oapirouter.FindRoute(req.Request)
+		route, params, err := r.oapirouter.FindRoute(req.Request)
 		if err != nil {
 			spanReq.RecordError("failed to find route", err)
 			spanReq.End()
@@ -130,7 +252,7 @@ func (r *rest) middlewareValidation(next bunrouter.HandlerFunc) bunrouter.Handle
 			Request:     req.Request,
 			PathParams:  params,
 			QueryParams: req.URL.Query(),
-			Route:       r,
+			Route:       route,
 			Options: &openapi3filter.Options{
 				MultiError:         true,
 				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
@@ -143,9 +265,38 @@ func (r *rest) middlewareValidation(next bunrouter.HandlerFunc) bunrouter.Handle
 			return err.Reason
 		})
 
+		// Validate the request against the OpenAPI description. If the request does
+		// not respect the description, an error is recorded. In enforce mode, the
+		// request is rejected with the violations found, before the handler and the
+		// response validation. Otherwise, the request is still forwarded to the
+		// handler, which makes validation observational, matching the behavior of
+		// response validation.
+		err = openapi3filter.ValidateRequest(ctx, in)
+		if err != nil {
+			spanReq.RecordError("failed to validate request", err)
+			if r.config.OpenAPI.RequestValidation == ValidationModeEnforce {
+				spanReq.End()
+
+				NewResponseError[NoMetadata](req.Request).
+					SetStatus(http.StatusBadRequest).
+					SetValidations(requestValidationEntries(err)...).
+					Write(w)
+
+				return nil
+			}
+		}
+
+		// Hold the response back until validated in enforce mode, so an invalid one
+		// can be replaced before reaching the client.
+		if r.config.OpenAPI.ResponseValidation == ValidationModeEnforce {
+			rw.hold()
+		}
+
 		// Whatever happens next, make sure to validate the response returned, just
 		// like we did for the request. If the response is not valid, an error is
-		// recorded but the response is still returned to the client.
+		// recorded. In enforce mode, the violations are logged and the response is
+		// replaced with a 500 error describing them. Otherwise, the response is still
+		// returned to the client.
 		defer func() {
 
 			// A streamed response is flushed incrementally and has no finite body to
@@ -160,7 +311,7 @@ func (r *rest) middlewareValidation(next bunrouter.HandlerFunc) bunrouter.Handle
 				RequestValidationInput: in,
 				Status:                 rw.status,
 				Header:                 rw.Header(),
-				Body:                   io.NopCloser(rw.buf),
+				Body:                   io.NopCloser(bytes.NewReader(rw.buf.Bytes())),
 				Options: &openapi3filter.Options{
 					MultiError:            true,
 					IncludeResponseStatus: true,
@@ -174,22 +325,18 @@ func (r *rest) middlewareValidation(next bunrouter.HandlerFunc) bunrouter.Handle
 			err = openapi3filter.ValidateResponse(ctx, out)
 			if err != nil {
 				spanRes.RecordError("failed to validate response", err)
+				if rw.held {
+					r.rejectResponse(ctx, w, req.Request, route, rw, err)
+				}
 			}
 
+			rw.release()
 			spanRes.End()
 		}()
 
-		// Validate the request against the OpenAPI description. If the request does
-		// not respect the description, an error is recorded but the request is still
-		// forwarded to the handler. This makes validation observational, matching the
-		// behavior of response validation.
-		err = openapi3filter.ValidateRequest(ctx, in)
-		if err != nil {
-			spanReq.RecordError("failed to validate request", err)
-		}
-
-		// If we made it here it means the request is valid. We can close the span
-		// and move to the next HTTP handler function.
+		// If we made it here it means the request is valid, or that validation is
+		// observational. We can close the span and move to the next HTTP handler
+		// function.
 		spanReq.End()
 		next(rw, req)
 
@@ -197,10 +344,33 @@ func (r *rest) middlewareValidation(next bunrouter.HandlerFunc) bunrouter.Handle
 	}
 }
 
+/*
+rejectResponse logs the error returned when validating a held response against
+the OpenAPI description, along with the route and status code of the response.
+It then discards the response held, and writes in its place a 500 error holding
+one entry per violation.
+*/
+func (r *rest) rejectResponse(ctx context.Context, w http.ResponseWriter, req *http.Request, route *routers.Route, rw *responseWriter, err error) {
+	log.Error(r.logContext(ctx), "Response does not match the OpenAPI description",
+		log.String("integration", identifier),
+		log.String("method", req.Method),
+		log.String("route", route.Path),
+		log.Int("status", rw.status),
+		log.Err(err),
+	)
+
+	rw.discard()
+
+	res := NewResponseError[NoMetadata](req).SetStatus(http.StatusInternalServerError)
+	res.err = &errorstack.Error{Entries: responseValidationEntries(err)}
+	res.Write(w)
+}
+
 /*
 buildRouterOpenAPI tries to build the router for validating requests and responses
-against the OpenAPI description. It returns validation entries in case the
-description can not be loaded or if it's not valid.
+against the OpenAPI description. The description is kept for serving if enabled
+in Config. It returns validation entries in case the description can not be
+loaded or if it's not valid.
 */
 func (r *rest) buildRouterOpenAPI() (routers.Router, []errorstack.Entry) {
 	loader := openapi3.NewLoader()
@@ -224,6 +394,15 @@ func (r *rest) buildRouterOpenAPI() (routers.Router, []errorstack.Entry) {
 		}}
 	}
 
+	// Keep a copy of the description to serve if enabled in Config, before its
+	// servers are cleared below. External references are bundled under its
+	// components so the description served is self-contained.
+	if r.config.OpenAPI.Serve {
+		doc.InternalizeRefs(context.Background(), nil)
+		served := *doc
+		r.oapidoc = &served
+	}
+
 	// Clear server URLs so the gorillamux router matches any host. Without this,
 	// FindRoute fails in local development because the request host (e.g.
 	// localhost:8080) doesn't match the host declared in the spec.
@@ -195,10 +195,96 @@ func TestResponseWriter_StreamingSupportsFlush(t *testing.T) {
 
 	_, _ = rw.Write([]byte("data: tick\n\n"))
 
-	// Unwrap lets http.ResponseController reach the underlying Flusher, so an SSE
-	// handler can flush each event as it is produced.
+	// http.ResponseController reaches the underlying Flusher, so an SSE handler
+	// can flush each event as it is produced.
 	err := http.NewResponseController(rw).Flush()
 
 	require.NoError(t, err)
 	assert.True(t, rec.Flushed)
 }
+
+func TestResponseWriter_HeldIgnoresFlush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := newTestResponseWriter(rec)
+	rw.held = true
+
+	rw.WriteHeader(http.StatusCreated)
+	_, err := rw.Write([]byte(`{"data":null}`))
+	require.NoError(t, err)
+
+	// A flush must not bypass the buffer of a response held for validation.
+	err = http.NewResponseController(rw).Flush()
+
+	require.NoError(t, err)
+	assert.False(t, rec.Flushed)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, `{"data":null}`, rw.buf.String())
+}
+
+func TestResponseWriter_HeldUntilReleased(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := newTestResponseWriter(rec)
+	rw.held = true
+
+	payload := `{"data":null}`
+	rw.WriteHeader(http.StatusCreated)
+	_, err := rw.Write([]byte(payload))
+	require.NoError(t, err)
+
+	// A held response is only buffered, and reaches the client once released.
+	assert.False(t, rec.Flushed)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, payload, rw.buf.String())
+
+	rw.release()
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, payload, rec.Body.String())
+}
+
+func TestResponseWriter_HeldDiscarded(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := newTestResponseWriter(rec)
+	rw.held = true
+	rw.Header().Set("Content-Type", "application/json")
+
+	_, _ = rw.Write([]byte(`{"data":null}`))
+	rw.discard()
+	rw.release()
+
+	// Nothing of a discarded response reaches the client, not even its headers.
+	assert.Empty(t, rec.Body.String())
+	assert.Empty(t, rec.Header())
+}
+
+func TestResponseWriter_HeldDiscardedKeepsHeadersSetBefore(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := newTestResponseWriter(rec)
+	rw.Header().Set("Access-Control-Allow-Origin", "https://app.example.com")
+	rw.hold()
+	rw.Header().Set("Content-Type", "application/json")
+
+	_, _ = rw.Write([]byte(`{"data":null}`))
+	rw.discard()
+
+	// Headers set by outer middleware before the response was held, such as the
+	// CORS ones, are kept for the response written in its place.
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Content-Type"))
+}
+
+func TestResponseWriter_HeldReleasedWhenStreaming(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := newTestResponseWriter(rec)
+	rw.held = true
+	rw.Header().Set("Content-Type", "text/event-stream")
+
+	event := "data: hello\n\n"
+	_, err := rw.Write([]byte(event))
+
+	// A streamed response is never held, so each event reaches the client as
+	// written.
+	require.NoError(t, err)
+	assert.False(t, rw.held)
+	assert.Equal(t, event, rec.Body.String())
+}
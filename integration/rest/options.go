@@ -1,7 +1,10 @@
 package rest
 
 import (
+	"net/http"
 	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
 )
 
 /*
@@ -22,6 +25,19 @@ type routeOptions struct {
 	// timeout is the request budget for the route. Zero, like any non-positive
 	// value, means the route runs without a deadline.
 	timeout time.Duration
+
+	// maxBodySize is the request body size limit for the route, in bytes. Zero,
+	// like any non-positive value, means the route's request body is unbounded.
+	maxBodySize int64
+
+	// middleware wraps the route's handler, the first one being the outermost.
+	// Unlike the timeout, it accumulates across options.
+	middleware []func(next http.Handler) http.Handler
+
+	// entries are the validation entries of the options not valid, with paths
+	// relative to the route. Routes are registered without returning an error,
+	// so they are collected and reported by Start.
+	entries []errorstack.Entry
 }
 
 /*
@@ -50,14 +66,45 @@ func WithoutTimeout() RouteOption {
 	}
 }
 
+/*
+WithMaxBodySize bounds the size of a single route's request body, in bytes,
+overriding Config.MaxRequestBodyBytes — lower for a route taking small payloads,
+higher for one accepting file uploads. A request whose body exceeds it is rejected
+with a 413, before the OpenAPI validation and the route's middleware read it.
+
+A non-positive size waives the limit.
+*/
+func WithMaxBodySize(n int64) RouteOption {
+	return func(o *routeOptions) {
+		o.maxBodySize = n
+	}
+}
+
+/*
+WithMiddleware wraps a single route with middleware, such as auth scopes,
+caching, or auditing. It runs once the route has matched, so ParamsFromContext
+is available, and within the route's request budget. The first middleware passed
+is the outermost.
+
+Middleware accumulates rather than overrides: the one passed as default options
+of a group wraps the one passed to the route itself, and both run inside the
+middleware added with Use.
+*/
+func WithMiddleware(middleware ...func(next http.Handler) http.Handler) RouteOption {
+	return func(o *routeOptions) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
 /*
 resolveRouteOptions applies opts in order over the defaults Config carries, and
-returns the request budget the route runs under. A non-positive result means the
-route is left unbounded.
+returns the policy the route runs under. A non-positive timeout or body size
+means the route is left unbounded.
 */
-func (r *rest) resolveRouteOptions(opts []RouteOption) time.Duration {
+func (r *rest) resolveRouteOptions(opts []RouteOption) routeOptions {
 	resolved := routeOptions{
-		timeout: r.config.RequestTimeout,
+		timeout:     r.config.RequestTimeout,
+		maxBodySize: r.config.MaxRequestBodyBytes,
 	}
 
 	for _, opt := range opts {
@@ -66,5 +113,5 @@ func (r *rest) resolveRouteOptions(opts []RouteOption) time.Duration {
 		}
 	}
 
-	return resolved.timeout
+	return resolved
 }
@@ -66,7 +66,7 @@ func TestResolveRouteOptions(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			r := &rest{config: &Config{RequestTimeout: tc.config}}
 
-			assert.Equal(t, tc.expected, r.resolveRouteOptions(tc.opts))
+			assert.Equal(t, tc.expected, r.resolveRouteOptions(tc.opts).timeout)
 		})
 	}
 }
@@ -76,7 +76,7 @@ func TestResolveRouteOptions(t *testing.T) {
 func deadlineFor(t *testing.T, config time.Duration, opts ...RouteOption) (time.Time, bool) {
 	t.Helper()
 
-	r := newTestRouter()
+	r := newTestRouter(t)
 	r.config.RequestTimeout = config
 
 	var deadline time.Time
@@ -120,7 +120,7 @@ func TestRoute_NoBudget_LeavesNoDeadline(t *testing.T) {
 }
 
 func TestRoute_Budget_CancelsHandlerContextWhenSpent(t *testing.T) {
-	r := newTestRouter()
+	r := newTestRouter(t)
 
 	var err error
 	r.GET("/slow", func(_ http.ResponseWriter, req *http.Request) {
@@ -137,7 +137,7 @@ func TestRoute_Budget_CancelsHandlerContextWhenSpent(t *testing.T) {
 // The budget must be released as soon as the handler returns, so a fast handler
 // on a generous budget does not hold a timer for the whole duration.
 func TestRoute_Budget_CancelsOnceHandlerReturns(t *testing.T) {
-	r := newTestRouter()
+	r := newTestRouter(t)
 
 	var ctx context.Context
 	r.GET("/fast", func(_ http.ResponseWriter, req *http.Request) {
@@ -166,7 +166,7 @@ func TestRoute_EveryVerbCarriesOptions(t *testing.T) {
 
 	for _, tc := range testcases {
 		t.Run(tc.method, func(t *testing.T) {
-			r := newTestRouter()
+			r := newTestRouter(t)
 
 			var ok bool
 			tc.register(r, "/verb", func(_ http.ResponseWriter, req *http.Request) {
@@ -180,3 +180,55 @@ func TestRoute_EveryVerbCarriesOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestRoute_WithMiddleware_Order(t *testing.T) {
+	r := newTestRouter(t)
+	r.Use(tagMiddleware("use"))
+
+	g := r.Group("/v1", WithMiddleware(tagMiddleware("group")))
+	g.GET("/users", func(http.ResponseWriter, *http.Request) {}, WithMiddleware(tagMiddleware("first"), tagMiddleware("second")), WithMiddleware(tagMiddleware("third")))
+
+	rw := serveTest(r, http.MethodGet, "/v1/users")
+
+	assert.Equal(t, []string{"use", "group", "first", "second", "third"}, rw.Header().Values("X-Trail"))
+}
+
+// Middleware runs after routing and within the budget, so it sees both the
+// route's params and the deadline.
+func TestRoute_WithMiddleware_SeesParamsAndBudget(t *testing.T) {
+	r := newTestRouter(t)
+
+	var id string
+	var ok bool
+	r.GET("/users/:id", func(http.ResponseWriter, *http.Request) {}, WithTimeout(30*time.Second), WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			params, _ := ParamsFromContext(req.Context())
+			id = params["id"]
+			_, ok = req.Context().Deadline()
+			next.ServeHTTP(rw, req)
+		})
+	}))
+
+	serveTest(r, http.MethodGet, "/users/42")
+
+	assert.Equal(t, "42", id)
+	assert.True(t, ok, "middleware should observe a deadline")
+}
+
+func TestRoute_WithMiddleware_ShortCircuits(t *testing.T) {
+	r := newTestRouter(t)
+
+	called := false
+	r.POST("/admin", func(http.ResponseWriter, *http.Request) {
+		called = true
+	}, WithMiddleware(func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			NewResponseError[NoMetadata](req).SetStatus(http.StatusForbidden).Write(rw)
+		})
+	}))
+
+	rw := serveTest(r, http.MethodPost, "/admin")
+
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+	assert.False(t, called, "handler must not be called")
+}
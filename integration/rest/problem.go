@@ -0,0 +1,224 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/internal/locales"
+)
+
+/*
+ErrorFormat is the format of the error responses written with ResponseError.
+*/
+type ErrorFormat string
+
+/*
+Error formats supported by the REST integration:
+
+  - ErrorFormatErrors writes the GraphQL-spec {"errors":[…]} envelope as
+    application/json, shared with the GraphQL and MCP integrations.
+  - ErrorFormatProblem writes an RFC 9457 problem as application/problem+json.
+  - ErrorFormatNegotiate writes an RFC 9457 problem when the Accept header of
+    the request prefers application/problem+json over application/json, and the
+    {"errors":[…]} envelope otherwise.
+*/
+const (
+	ErrorFormatErrors    ErrorFormat = "errors"
+	ErrorFormatProblem   ErrorFormat = "problem"
+	ErrorFormatNegotiate ErrorFormat = "negotiate"
+)
+
+/*
+Content types of the error formats.
+*/
+const (
+	contentTypeJSON    = "application/json"
+	contentTypeProblem = "application/problem+json"
+)
+
+/*
+problemTypeDefault is the "type" member of every problem. As per RFC 9457, it
+indicates the problem has no additional semantics beyond the status code, so the
+"title" member is the status' reason phrase. The machine-readable code of each
+entry is carried by the "errors" member instead.
+*/
+const problemTypeDefault = "about:blank"
+
+/*
+problemMembers are the members of a problem defined by RFC 9457, plus the
+"errors" extension member. Top-level extensions of an *errorstack.Error with one
+of these keys are not serialized, so they can not override them.
+*/
+var problemMembers = []string{"type", "title", "status", "detail", "instance", "errors"}
+
+/*
+errorFormatKey is the context key of the ErrorFormat configured for the REST
+API, stored in every request context by errorFormatMiddleware.
+*/
+type errorFormatKey struct{}
+
+/*
+errorFormatMiddleware stores the ErrorFormat passed in the context of every
+request, so ResponseError — which only knows the request — writes errors in the
+format configured for the REST API.
+*/
+func errorFormatMiddleware(format ErrorFormat, next http.Handler) http.Handler {
+	if format == "" || format == ErrorFormatErrors {
+		return next
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), errorFormatKey{}, format)
+		next.ServeHTTP(rw, req.WithContext(ctx))
+	})
+}
+
+/*
+errorFormatOf returns the error format to use for the request passed, resolving
+ErrorFormatNegotiate from its Accept header. Returns ErrorFormatErrors for a nil
+request or when no format is found in its context.
+*/
+func errorFormatOf(req *http.Request) ErrorFormat {
+	if req == nil {
+		return ErrorFormatErrors
+	}
+
+	format, _ := req.Context().Value(errorFormatKey{}).(ErrorFormat)
+	switch format {
+	case ErrorFormatProblem:
+		return ErrorFormatProblem
+
+	case ErrorFormatNegotiate:
+		if prefersProblem(req.Header.Values("Accept")) {
+			return ErrorFormatProblem
+		}
+	}
+
+	return ErrorFormatErrors
+}
+
+/*
+prefersProblem returns true if the Accept header values passed give
+application/problem+json a strictly positive quality, greater than or equal to
+the one of application/json. Wildcards are ignored: a client accepting anything
+gets the default format.
+*/
+func prefersProblem(accept []string) bool {
+	var problemQ, jsonQ float64
+	for _, value := range accept {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+
+			q := 1.0
+			if raw, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(raw, 64); err != nil {
+					continue
+				}
+			}
+
+			switch mediaType {
+			case contentTypeProblem:
+				problemQ = max(problemQ, q)
+			case contentTypeJSON:
+				jsonQ = max(jsonQ, q)
+			}
+		}
+	}
+
+	return problemQ > 0 && problemQ >= jsonQ
+}
+
+/*
+problem is the RFC 9457 representation of an *errorstack.Error.
+*/
+type problem struct {
+	Type     string          `json:"type"`
+	Title    string          `json:"title"`
+	Status   int             `json:"status"`
+	Detail   string          `json:"detail,omitempty"`
+	Instance string          `json:"instance,omitempty"`
+	Errors   json.RawMessage `json:"errors"`
+}
+
+/*
+marshalProblem serializes stack into an RFC 9457 problem for the request and
+status code passed:
+
+  - "type" is "about:blank", and "title" the reason phrase of the status code;
+  - "detail" is the message of the single entry, or the localized message of the
+    status code when there are several entries (e.g. validations);
+  - "instance" is the path of the request;
+  - "errors" holds the entries, serialized as in the GraphQL-spec envelope.
+
+Top-level extensions of stack are set as extension members of the problem, in
+lexical order, after the members above.
+*/
+func marshalProblem(req *http.Request, status int, stack *errorstack.Error) ([]byte, error) {
+	envelope, err := stack.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded struct {
+		Errors json.RawMessage `json:"errors"`
+	}
+
+	if err := json.Unmarshal(envelope, &decoded); err != nil {
+		return nil, err
+	}
+
+	p := problem{
+		Type:   problemTypeDefault,
+		Title:  http.StatusText(status),
+		Status: status,
+		Errors: decoded.Errors,
+	}
+
+	if len(stack.Entries) == 1 {
+		p.Detail = stack.Entries[0].Message
+	} else {
+		p.Detail = locales.Message(req, status)
+	}
+
+	if req != nil {
+		p.Instance = req.URL.Path
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil || len(stack.Extensions) == 0 {
+		return b, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(b[:len(b)-1])
+	for _, key := range slices.Sorted(maps.Keys(stack.Extensions)) {
+		if slices.Contains(problemMembers, key) {
+			continue
+		}
+
+		value, err := json.Marshal(stack.Extensions[key])
+		if err != nil {
+			return nil, err
+		}
+
+		name, _ := json.Marshal(key)
+		buf.WriteByte(',')
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
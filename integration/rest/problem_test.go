@@ -0,0 +1,156 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+requestWithErrorFormat returns a request to path holding the ErrorFormat passed
+in its context, as errorFormatMiddleware does.
+*/
+func requestWithErrorFormat(format ErrorFormat, path string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	return req.WithContext(context.WithValue(req.Context(), errorFormatKey{}, format))
+}
+
+func TestPrefersProblem(t *testing.T) {
+	testcases := []struct {
+		name     string
+		accept   []string
+		expected bool
+	}{
+		{name: "no header", expected: false},
+		{name: "JSON only", accept: []string{"application/json"}, expected: false},
+		{name: "wildcard", accept: []string{"*/*"}, expected: false},
+		{name: "problem only", accept: []string{"application/problem+json"}, expected: true},
+		{name: "problem first", accept: []string{"application/problem+json, application/json"}, expected: true},
+		{name: "JSON preferred", accept: []string{"application/problem+json;q=0.5, application/json"}, expected: false},
+		{name: "problem preferred", accept: []string{"application/json;q=0.5, application/problem+json"}, expected: true},
+		{name: "problem refused", accept: []string{"application/problem+json;q=0"}, expected: false},
+		{name: "several header values", accept: []string{"text/html", "application/problem+json"}, expected: true},
+		{name: "invalid quality is ignored", accept: []string{"application/problem+json;q=high"}, expected: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, prefersProblem(tc.accept))
+		})
+	}
+}
+
+func TestErrorFormatOf(t *testing.T) {
+	negotiated := requestWithErrorFormat(ErrorFormatNegotiate, "/")
+	negotiated.Header.Set("Accept", "application/problem+json")
+
+	assert.Equal(t, ErrorFormatErrors, errorFormatOf(nil))
+	assert.Equal(t, ErrorFormatErrors, errorFormatOf(httptest.NewRequest(http.MethodGet, "/", nil)))
+	assert.Equal(t, ErrorFormatProblem, errorFormatOf(requestWithErrorFormat(ErrorFormatProblem, "/")))
+	assert.Equal(t, ErrorFormatErrors, errorFormatOf(requestWithErrorFormat(ErrorFormatNegotiate, "/")))
+	assert.Equal(t, ErrorFormatProblem, errorFormatOf(negotiated))
+}
+
+func TestResponseError_Write_Problem(t *testing.T) {
+	req := requestWithErrorFormat(ErrorFormatProblem, "/users/123")
+	rw := httptest.NewRecorder()
+
+	NewResponseError[NoMetadata](req).
+		SetStatus(http.StatusNotFound).
+		Write(rw)
+
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	assert.Equal(t, "application/problem+json", rw.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "Resource does not exist",
+		"instance": "/users/123",
+		"errors": [{"message": "Resource does not exist", "extensions": {"code": "NOT_FOUND"}}]
+	}`, rw.Body.String())
+}
+
+func TestResponseError_Write_ProblemValidations(t *testing.T) {
+	req := requestWithErrorFormat(ErrorFormatProblem, "/users")
+	rw := httptest.NewRecorder()
+
+	NewResponseError[NoMetadata](req).
+		SetStatus(http.StatusBadRequest).
+		SetValidations(
+			errorstack.Entry{Message: "Must be a valid email address", Path: []any{"request", "body", "email"}},
+			errorstack.Entry{Message: "Must be set", Path: []any{"request", "body", "name"}},
+		).
+		Write(rw)
+
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "Request is invalid",
+		"instance": "/users",
+		"errors": [
+			{"message": "Must be a valid email address", "path": ["request", "body", "email"], "extensions": {"code": "VALIDATION_FAILED"}},
+			{"message": "Must be set", "path": ["request", "body", "name"], "extensions": {"code": "VALIDATION_FAILED"}}
+		]
+	}`, rw.Body.String())
+}
+
+func TestResponseError_MarshalProblemJSON_Extensions(t *testing.T) {
+	type metadata struct {
+		RequestID string `json:"request_id"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	res := NewResponseError[metadata](req).
+		SetStatus(http.StatusConflict).
+		SetMetadata(metadata{RequestID: "req_123"})
+
+	res.err.SetExtension("status", "ignored").SetExtension("retry", true)
+
+	b, err := res.MarshalProblemJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Conflict",
+		"status": 409,
+		"detail": "Resource conflicts with current state",
+		"instance": "/orders",
+		"errors": [{"message": "Resource conflicts with current state", "extensions": {"code": "CONFLICT"}}],
+		"metadata": {"request_id": "req_123"},
+		"retry": true
+	}`, string(b))
+}
+
+func TestRouter_Handler_ErrorFormatNegotiate(t *testing.T) {
+	r := newTestRouter(t)
+	r.config.ErrorFormat = ErrorFormatNegotiate
+
+	testcases := []struct {
+		name        string
+		accept      string
+		contentType string
+	}{
+		{name: "default format", accept: "application/json", contentType: "application/json"},
+		{name: "problem requested", accept: "application/problem+json", contentType: "application/problem+json"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+			req.Header.Set("Accept", tc.accept)
+			rw := httptest.NewRecorder()
+			r.handler().ServeHTTP(rw, req)
+
+			assert.Equal(t, http.StatusNotFound, rw.Code)
+			assert.Equal(t, tc.contentType, rw.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package rest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/event"
+	"github.com/mountayaapp/helix.go/integration"
+	"github.com/mountayaapp/helix.go/integration/valkey"
+	"github.com/mountayaapp/helix.go/telemetry/trace"
+
+	"github.com/uptrace/bunrouter"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	spanRateLimit = humanized + ": Rate limit"
+)
+
+/*
+Trace attributes set on the rate limit span, recording the decision made for the
+request.
+*/
+var (
+	attrKeyRateLimitName      = attribute.Key(identifier + ".rate_limit.name")
+	attrKeyRateLimitLimit     = attribute.Key(identifier + ".rate_limit.limit")
+	attrKeyRateLimitRemaining = attribute.Key(identifier + ".rate_limit.remaining")
+	attrKeyRateLimitAllowed   = attribute.Key(identifier + ".rate_limit.allowed")
+	attrKeyRateLimitFailOpen  = attribute.Key(identifier + ".rate_limit.fail_open")
+)
+
+/*
+rateLimitScript applies the Generic Cell Rate Algorithm (GCRA) atomically for the
+key passed. It stores a single value per key — the theoretical arrival time of
+the next request — so a limit is shared by every replica without any counter to
+reset. Time is read from the server, so replicas never disagree because of clock
+skew.
+
+ARGV holds the emission interval, which is the period divided by the limit, and
+the tolerance, which is the period, both in microseconds. It returns whether the
+request is allowed, the number of requests remaining, the delay before the
+request can be retried, and the delay before the limit is fully reset, delays
+being in microseconds.
+*/
+const rateLimitScript = `
+local now = redis.call("TIME")
+now = tonumber(now[1]) * 1000000 + tonumber(now[2])
+
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1])) or now
+if tat < now then
+  tat = now
+end
+
+local next_tat = tat + interval
+local allow_at = next_tat - tolerance
+if now < allow_at then
+  return {0, 0, allow_at - now, tat - now}
+end
+
+redis.call("SET", KEYS[1], string.format("%d", next_tat), "PX", math.ceil((next_tat - now) / 1000))
+return {1, math.floor((now + tolerance - next_tat) / interval), 0, next_tat - now}
+`
+
+/*
+RateLimitKey returns the key identifying the caller of a request, so each caller
+has its own limit. An empty key falls back to the IP of the client.
+*/
+type RateLimitKey func(req *http.Request) string
+
+/*
+RateLimit configures the rate limit of a route, set with WithRateLimit.
+*/
+type RateLimit struct {
+
+	// Valkey is the Valkey integration storing the state of the limit, so it is
+	// shared across replicas. It must implement valkey.Scripter, as every Valkey
+	// returned by valkey.Connect does. Required.
+	Valkey valkey.Valkey
+
+	// Limit is the number of requests a caller can make per Period. Required.
+	Limit int
+
+	// Period is the window over which Limit applies. Required.
+	Period time.Duration
+
+	// Key identifies the caller of a request. Use one of RateLimitByIP,
+	// RateLimitByUserID, RateLimitByTenantID, or RateLimitByHeader, or any
+	// custom function.
+	//
+	// Default:
+	//
+	//   RateLimitByIP
+	Key RateLimitKey
+
+	// Name identifies the limit in Valkey. Routes sharing a name share the same
+	// limit for a given caller.
+	//
+	// Default:
+	//
+	//   "<METHOD> <route>", such as "POST /users/:id"
+	Name string
+
+	// Prefix is the prefix of the keys stored in Valkey.
+	//
+	// Default:
+	//
+	//   "ratelimit:"
+	Prefix string
+
+	// FailClosed rejects requests with a 503 when the limit can not be checked,
+	// such as when Valkey is unreachable. By default, requests are allowed in
+	// such case, so an outage of Valkey does not take the API down with it.
+	FailClosed bool
+
+	// scripter is Valkey as a valkey.Scripter, set by WithRateLimit.
+	scripter valkey.Scripter
+}
+
+/*
+rateLimitDecision is the decision made by the rate limit script for a request.
+*/
+type rateLimitDecision struct {
+	allowed    bool
+	remaining  int64
+	retryAfter time.Duration
+	reset      time.Duration
+}
+
+/*
+WithRateLimit limits the number of requests each caller can make to a route, as
+configured by limit. The state of the limit is stored in Valkey, so it applies
+across every replica of the service. Every response carries the RateLimit-Limit,
+RateLimit-Remaining, and RateLimit-Reset headers. When a caller exceeds the
+limit, the request is rejected with a 429 ResponseError and a Retry-After header,
+before the handler is called. The decision is recorded on the trace.
+
+The rate limit is applied as a route middleware, in the order it is passed along
+WithMiddleware. Pass it after the middleware setting the Event's UserID or
+TenantID when limiting by one of them:
+
+	router.POST("/messages", handler, rest.WithMiddleware(auth), rest.WithRateLimit(rest.RateLimit{
+	  Valkey: store,
+	  Limit:  100,
+	  Period: time.Minute,
+	  Key:    rest.RateLimitByUserID,
+	}))
+
+The limit is validated when the route is registered. If it has no Valkey
+integration able to run scripts, or if its Limit or Period is not positive, the
+route is not limited
+and Start returns a validation error, so a misconfigured limit is caught before
+serving any request rather than when the route is requested.
+*/
+func WithRateLimit(limit RateLimit) RouteOption {
+	entries := limit.sanitize()
+	if len(entries) > 0 {
+		return func(o *routeOptions) {
+			o.entries = append(o.entries, entries...)
+		}
+	}
+
+	limit.scripter = limit.Valkey.(valkey.Scripter)
+	if limit.Key == nil {
+		limit.Key = RateLimitByIP
+	}
+
+	if limit.Prefix == "" {
+		limit.Prefix = "ratelimit:"
+	}
+
+	return WithMiddleware(limit.middleware)
+}
+
+/*
+sanitize validates the rate limit. Returns validation entries if it is not valid,
+with paths relative to the route.
+*/
+func (limit RateLimit) sanitize() []errorstack.Entry {
+	var entries []errorstack.Entry
+	if limit.Valkey == nil {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be set",
+			Path:    []any{"rate_limit", "valkey"},
+		})
+	} else if _, ok := limit.Valkey.(valkey.Scripter); !ok {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must implement valkey.Scripter",
+			Path:    []any{"rate_limit", "valkey"},
+		})
+	}
+
+	if limit.Limit <= 0 {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be greater than 0",
+			Path:    []any{"rate_limit", "limit"},
+		})
+	}
+
+	if limit.Period <= 0 {
+		entries = append(entries, errorstack.Entry{
+			Message: "Must be a positive duration",
+			Path:    []any{"rate_limit", "period"},
+		})
+	}
+
+	return entries
+}
+
+/*
+middleware returns the HTTP middleware enforcing the rate limit.
+*/
+func (limit RateLimit) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx, span := trace.Start(req.Context(), trace.SpanKindInternal, spanRateLimit)
+
+		name := limit.Name
+		if name == "" {
+			name = req.Method + " " + bunrouter.ParamsFromContext(ctx).Route()
+		}
+
+		span.SetAttributes(
+			attrKeyRateLimitName.String(name),
+			attrKeyRateLimitLimit.Int(limit.Limit),
+		)
+
+		key := limit.Key(req)
+		if key == "" {
+			key = RateLimitByIP(req)
+		}
+
+		decision, err := limit.decide(ctx, limit.Prefix+name+":"+key)
+		if err != nil {
+			span.RecordError("failed to check rate limit", err)
+			span.SetAttributes(attrKeyRateLimitFailOpen.Bool(!limit.FailClosed))
+			span.End()
+
+			if limit.FailClosed {
+				NewResponseError[NoMetadata](req).
+					SetStatus(http.StatusServiceUnavailable).
+					Write(rw)
+
+				return
+			}
+
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		span.SetAttributes(
+			attrKeyRateLimitAllowed.Bool(decision.allowed),
+			attrKeyRateLimitRemaining.Int64(decision.remaining),
+		)
+
+		span.End()
+
+		rw.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Limit))
+		rw.Header().Set("RateLimit-Remaining", strconv.FormatInt(decision.remaining, 10))
+		rw.Header().Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(decision.reset), 10))
+		rw.Header().Set("RateLimit-Policy", strconv.Itoa(limit.Limit)+";w="+strconv.FormatInt(ceilSeconds(limit.Period), 10))
+
+		if !decision.allowed {
+			NewResponseError[NoMetadata](req).
+				SetStatus(http.StatusTooManyRequests).
+				SetRetryAfter(decision.retryAfter).
+				Write(rw)
+
+			return
+		}
+
+		next.ServeHTTP(rw, req)
+	})
+}
+
+/*
+decide runs the rate limit script for the key passed, and returns the decision
+made. Returns an error if the script failed.
+*/
+func (limit RateLimit) decide(ctx context.Context, key string) (rateLimitDecision, error) {
+	var decision rateLimitDecision
+	interval := limit.Period.Microseconds() / int64(limit.Limit)
+	reply, err := limit.scripter.Eval(ctx, rateLimitScript, []string{key}, []string{
+		strconv.FormatInt(max(interval, 1), 10),
+		strconv.FormatInt(limit.Period.Microseconds(), 10),
+	})
+
+	if err != nil {
+		return decision, err
+	}
+
+	values, ok := reply.([]any)
+	if !ok || len(values) != 4 {
+		return decision, errorstack.New("Rate limit script returned an unexpected reply")
+	}
+
+	ints := make([]int64, len(values))
+	for i, value := range values {
+		if ints[i], ok = value.(int64); !ok {
+			return decision, errorstack.New("Rate limit script returned an unexpected reply")
+		}
+	}
+
+	decision.allowed = ints[0] == 1
+	decision.remaining = ints[1]
+	decision.retryAfter = time.Duration(ints[2]) * time.Microsecond
+	decision.reset = time.Duration(ints[3]) * time.Microsecond
+
+	return decision, nil
+}
+
+/*
+ceilSeconds returns d in seconds, rounded up so a client waiting for as long is
+never early.
+*/
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+/*
+RateLimitByIP identifies the caller by the IP of the client, as returned by
+integration.ClientIP, honoring the trusted proxies of the server. The IP of the
+Event is never used, since the Event can be restored from the baggage sent by
+the client, who could then pick its own key.
+*/
+func RateLimitByIP(req *http.Request) string {
+	return "ip:" + integration.ClientIP(req)
+}
+
+/*
+RateLimitByUserID identifies the caller by the UserID of the Event found in the
+request context. Requests with no UserID fall back to the IP of the client.
+
+The Event can be restored from the baggage sent by the client, so the UserID is
+only to be trusted when set by a middleware authenticating the caller, passed
+before WithRateLimit.
+*/
+func RateLimitByUserID(req *http.Request) string {
+	e, _ := event.EventFromContext(req.Context())
+	if e.UserID == "" {
+		return ""
+	}
+
+	return "user:" + e.UserID
+}
+
+/*
+RateLimitByTenantID identifies the caller by the TenantID of the Event found in
+the request context. Requests with no TenantID fall back to the IP of the client.
+
+The Event can be restored from the baggage sent by the client, so the TenantID is
+only to be trusted when set by a middleware authenticating the caller, passed
+before WithRateLimit.
+*/
+func RateLimitByTenantID(req *http.Request) string {
+	e, _ := event.EventFromContext(req.Context())
+	if e.TenantID == "" {
+		return ""
+	}
+
+	return "tenant:" + e.TenantID
+}
+
+/*
+RateLimitByHeader identifies the caller by the value of the header passed, such
+as an API key. The value is hashed, so secrets are never stored in Valkey as is.
+Requests without the header fall back to the IP of the client.
+*/
+func RateLimitByHeader(name string) RateLimitKey {
+	return func(req *http.Request) string {
+		value := req.Header.Get(name)
+		if value == "" {
+			return ""
+		}
+
+		sum := sha256.Sum256([]byte(value))
+		return "header:" + hex.EncodeToString(sum[:])
+	}
+}
@@ -0,0 +1,357 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/event"
+	"github.com/mountayaapp/helix.go/integration"
+	"github.com/mountayaapp/helix.go/integration/valkey"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	valkeygo "github.com/valkey-io/valkey-go"
+)
+
+// fakeRateLimitValkey implements valkey.Scripter by applying the same GCRA as
+// rateLimitScript in memory, with a frozen clock. Methods of valkey.Valkey are
+// not implemented and panic if called.
+type fakeRateLimitValkey struct {
+	valkey.Valkey
+
+	now  int64
+	tats map[string]int64
+	keys []string
+	err  error
+}
+
+func (f *fakeRateLimitValkey) Eval(ctx context.Context, script string, keys []string, args []string) (any, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	interval, _ := strconv.ParseInt(args[0], 10, 64)
+	tolerance, _ := strconv.ParseInt(args[1], 10, 64)
+
+	f.keys = append(f.keys, keys[0])
+	tat := max(f.tats[keys[0]], f.now)
+	next := tat + interval
+	allowAt := next - tolerance
+	if f.now < allowAt {
+		return []any{int64(0), int64(0), allowAt - f.now, tat - f.now}, nil
+	}
+
+	f.tats[keys[0]] = next
+	return []any{int64(1), (f.now + tolerance - next) / interval, int64(0), next - f.now}, nil
+}
+
+// scriptValkey implements valkey.Scripter by running the script on a Valkey
+// client, so the rate limit script itself is tested against the in-memory server
+// of miniredis. Methods of valkey.Valkey are not implemented and panic if called.
+type scriptValkey struct {
+	valkey.Valkey
+
+	client valkeygo.Client
+}
+
+func (s *scriptValkey) Eval(ctx context.Context, script string, keys []string, args []string) (any, error) {
+	return valkeygo.NewLuaScript(script).Exec(ctx, s.client, keys, args).ToAny()
+}
+
+// newScriptValkey starts a miniredis server with its clock frozen at now, and
+// returns it along with a scriptValkey connected to it.
+func newScriptValkey(t *testing.T, now time.Time) (*miniredis.Miniredis, *scriptValkey) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	server.SetTime(now)
+
+	client, err := valkeygo.NewClient(valkeygo.ClientOption{
+		InitAddress:  []string{server.Addr()},
+		DisableCache: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	return server, &scriptValkey{client: client}
+}
+
+// serveRateLimited registers a single route limited by limit, and serves the
+// number of requests passed. Returns the recorder of each response.
+func serveRateLimited(t *testing.T, limit RateLimit, requests int, setup func(req *http.Request)) []*httptest.ResponseRecorder {
+	t.Helper()
+
+	r := newTestRouter(t)
+	r.POST("/messages/:id", func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusCreated)
+	}, WithRateLimit(limit))
+
+	recs := make([]*httptest.ResponseRecorder, requests)
+	for i := range recs {
+		req := httptest.NewRequest(http.MethodPost, "/messages/42", nil)
+		req.RemoteAddr = "203.0.113.7:52100"
+		if setup != nil {
+			setup(req)
+		}
+
+		recs[i] = httptest.NewRecorder()
+		r.handler().ServeHTTP(recs[i], req)
+	}
+
+	return recs
+}
+
+func TestWithRateLimit_AllowsUpToLimit(t *testing.T) {
+	store := &fakeRateLimitValkey{now: 1_000_000, tats: map[string]int64{}}
+	recs := serveRateLimited(t, RateLimit{
+		Valkey: store,
+		Limit:  3,
+		Period: time.Minute,
+	}, 4, nil)
+
+	for i, rec := range recs[:3] {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "3", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, strconv.Itoa(2-i), rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, strconv.Itoa(20*(i+1)), rec.Header().Get("RateLimit-Reset"))
+		assert.Equal(t, "3;w=60", rec.Header().Get("RateLimit-Policy"))
+	}
+
+	limited := recs[3]
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "0", limited.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "20", limited.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"errors":[{"message":"Rate limit has been exceeded","extensions":{"code":"TOO_MANY_REQUESTS"}}]}`, limited.Body.String())
+
+	// The limit is named after the route by default, and the caller identified by
+	// the IP of the client.
+	assert.Equal(t, "ratelimit:POST /messages/:id:ip:203.0.113.7", store.keys[0])
+}
+
+func TestWithRateLimit_Script(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	server, store := newScriptValkey(t, now)
+
+	limit := RateLimit{
+		Valkey: store,
+		Limit:  3,
+		Period: time.Minute,
+		Name:   "messages",
+	}
+
+	recs := serveRateLimited(t, limit, 4, nil)
+	for i, rec := range recs[:3] {
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, strconv.Itoa(2-i), rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, strconv.Itoa(20*(i+1)), rec.Header().Get("RateLimit-Reset"))
+	}
+
+	assert.Equal(t, http.StatusTooManyRequests, recs[3].Code)
+	assert.Equal(t, "20", recs[3].Header().Get("Retry-After"))
+
+	// The theoretical arrival time is stored as an integer number of microseconds,
+	// expiring once the limit is fully reset.
+	key := "ratelimit:messages:ip:203.0.113.7"
+	stored, err := server.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.FormatInt(now.Add(time.Minute).UnixMicro(), 10), stored)
+	assert.Equal(t, time.Minute, server.TTL(key))
+
+	// Once the period elapsed, the state has expired and the caller has its full
+	// limit again.
+	server.SetTime(now.Add(time.Minute))
+	server.FastForward(time.Minute)
+	assert.False(t, server.Exists(key))
+
+	recs = serveRateLimited(t, limit, 1, nil)
+	assert.Equal(t, http.StatusCreated, recs[0].Code)
+	assert.Equal(t, "2", recs[0].Header().Get("RateLimit-Remaining"))
+}
+
+func TestWithRateLimit_NotValid(t *testing.T) {
+	testcases := []struct {
+		name     string
+		limit    RateLimit
+		expected []errorstack.Entry
+	}{
+		{
+			name:  "missing Valkey",
+			limit: RateLimit{Limit: 1, Period: time.Second},
+			expected: []errorstack.Entry{
+				{Message: "Must be set", Path: []any{"routes", "POST /messages/:id", "rate_limit", "valkey"}},
+			},
+		},
+		{
+			name:  "Valkey not a Scripter",
+			limit: RateLimit{Valkey: struct{ valkey.Valkey }{}, Limit: 1, Period: time.Second},
+			expected: []errorstack.Entry{
+				{Message: "Must implement valkey.Scripter", Path: []any{"routes", "POST /messages/:id", "rate_limit", "valkey"}},
+			},
+		},
+		{
+			name:  "limit not positive",
+			limit: RateLimit{Valkey: &fakeRateLimitValkey{}, Period: time.Second},
+			expected: []errorstack.Entry{
+				{Message: "Must be greater than 0", Path: []any{"routes", "POST /messages/:id", "rate_limit", "limit"}},
+			},
+		},
+		{
+			name:  "period not positive",
+			limit: RateLimit{Valkey: &fakeRateLimitValkey{}, Limit: 1, Period: -time.Second},
+			expected: []errorstack.Entry{
+				{Message: "Must be a positive duration", Path: []any{"routes", "POST /messages/:id", "rate_limit", "period"}},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRouter(t)
+			r.POST("/messages/:id", func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusCreated)
+			}, WithRateLimit(tc.limit))
+
+			err := r.Start(context.Background())
+
+			assert.Equal(t, errorstack.NewValidation(tc.expected...), err)
+		})
+	}
+}
+
+func TestWithRateLimit_Key(t *testing.T) {
+	testcases := []struct {
+		name     string
+		key      RateLimitKey
+		setup    func(req *http.Request)
+		expected string
+	}{
+		{
+			name: "by IP ignores the Event",
+			key:  RateLimitByIP,
+			setup: func(req *http.Request) {
+				*req = *req.WithContext(event.ContextWithEvent(req.Context(), event.Event{IP: "198.51.100.1"}))
+			},
+			expected: "ratelimit:messages:ip:203.0.113.7",
+		},
+		{
+			name: "by user ID",
+			key:  RateLimitByUserID,
+			setup: func(req *http.Request) {
+				*req = *req.WithContext(event.ContextWithEvent(req.Context(), event.Event{UserID: "user_1"}))
+			},
+			expected: "ratelimit:messages:user:user_1",
+		},
+		{
+			name:     "by user ID falls back to IP",
+			key:      RateLimitByUserID,
+			expected: "ratelimit:messages:ip:203.0.113.7",
+		},
+		{
+			name: "by tenant ID",
+			key:  RateLimitByTenantID,
+			setup: func(req *http.Request) {
+				*req = *req.WithContext(event.ContextWithEvent(req.Context(), event.Event{TenantID: "acme"}))
+			},
+			expected: "ratelimit:messages:tenant:acme",
+		},
+		{
+			name: "by hashed header",
+			key:  RateLimitByHeader("X-API-Key"),
+			setup: func(req *http.Request) {
+				req.Header.Set("X-API-Key", "secret")
+			},
+			expected: "ratelimit:messages:header:2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeRateLimitValkey{tats: map[string]int64{}}
+			serveRateLimited(t, RateLimit{
+				Valkey: store,
+				Limit:  10,
+				Period: time.Second,
+				Key:    tc.key,
+				Name:   "messages",
+			}, 1, tc.setup)
+
+			require.Len(t, store.keys, 1)
+			assert.Equal(t, tc.expected, store.keys[0])
+		})
+	}
+}
+
+func TestWithRateLimit_KeyByIPFromTrustedProxy(t *testing.T) {
+	store := &fakeRateLimitValkey{tats: map[string]int64{}}
+
+	// The Event is left disabled: the client's IP is found with the trusted
+	// proxies regardless, so clients do not share the bucket of the proxy.
+	r := newTestRouter(t)
+	r.config.Proxies = integration.ConfigProxies{Trusted: []string{"10.0.0.0/8"}}
+	require.Empty(t, r.config.Proxies.Sanitize())
+
+	r.POST("/messages/:id", func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusCreated)
+	}, WithRateLimit(RateLimit{
+		Valkey: store,
+		Limit:  10,
+		Period: time.Second,
+		Name:   "messages",
+	}))
+
+	for _, client := range []string{"203.0.113.7", "198.51.100.1"} {
+		req := httptest.NewRequest(http.MethodPost, "/messages/42", nil)
+		req.RemoteAddr = "10.0.0.1:52100"
+		req.Header.Set("X-Forwarded-For", client)
+		r.handler().ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, []string{
+		"ratelimit:messages:ip:203.0.113.7",
+		"ratelimit:messages:ip:198.51.100.1",
+	}, store.keys)
+}
+
+func TestWithRateLimit_StoreFailure(t *testing.T) {
+	testcases := []struct {
+		name           string
+		limit          RateLimit
+		expectedStatus int
+	}{
+		{
+			name: "fails open by default",
+			limit: RateLimit{
+				Valkey: &fakeRateLimitValkey{err: errors.New("connection refused")},
+				Limit:  1,
+				Period: time.Second,
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "fails closed when configured",
+			limit: RateLimit{
+				Valkey:     &fakeRateLimitValkey{err: errors.New("connection refused")},
+				Limit:      1,
+				Period:     time.Second,
+				FailClosed: true,
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			recs := serveRateLimited(t, tc.limit, 1, nil)
+
+			assert.Equal(t, tc.expectedStatus, recs[0].Code)
+			assert.Empty(t, recs[0].Header().Get("RateLimit-Limit"))
+		})
+	}
+}
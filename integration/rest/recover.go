@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/mountayaapp/helix.go/integration"
+
+	"github.com/uptrace/bunrouter"
+)
+
+/*
+recoverRoute returns the handler of a route wrapped so its panics are recovered
+with integration.RecoverPanic. bunrouter recovers the panics of route handlers
+itself, turning them into errors it ignores: without this, the client would get
+an empty 200, and the panic would be neither recorded nor logged.
+*/
+func (r *rest) recoverRoute(handler http.HandlerFunc) http.HandlerFunc {
+	return integration.RecoverPanic(handler, r.logContext, rejectPanic).ServeHTTP
+}
+
+/*
+middlewareAbort is the HTTP middleware panicking again with http.ErrAbortHandler
+when a route aborted the request with it, so the request is aborted by net/http.
+bunrouter turns the panic of a route handler into the error it returns, which
+would otherwise leave the client with a response looking complete.
+*/
+func (r *rest) middlewareAbort(next bunrouter.HandlerFunc) bunrouter.HandlerFunc {
+	return func(rw http.ResponseWriter, req bunrouter.Request) error {
+		err := next(rw, req)
+		if err == http.ErrAbortHandler {
+			panic(err)
+		}
+
+		return err
+	}
+}
+
+/*
+rejectPanic writes the 500 ResponseError in place of the response of a request
+whose handling panicked.
+*/
+func rejectPanic(rw http.ResponseWriter, req *http.Request) {
+	NewResponseError[NoMetadata](req).
+		SetStatus(http.StatusInternalServerError).
+		Write(rw)
+}
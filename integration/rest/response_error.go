@@ -2,11 +2,17 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
 	"maps"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/mountayaapp/helix.go/errorstack"
 	"github.com/mountayaapp/helix.go/internal/locales"
+
+	"golang.org/x/text/language"
 )
 
 var _ json.Marshaler = (*ResponseError[any])(nil)
@@ -21,13 +27,16 @@ ResponseError builds a GraphQL-spec error response for HTTP status codes 3xx,
 
 Validations supersede the seeded fallback entry: calling SetValidations
 replaces errors[] with one entry per validation. SetMetadata folds the typed
-metadata under top-level extensions.metadata.
+metadata under top-level extensions.metadata. Entries carrying a message ID (see
+errorstack.WithMessageID) are localized at write time, from the "lang" cookie
+or the "Accept-Language" header of the request.
 */
 type ResponseError[Metadata any] struct {
 	request    *http.Request
 	statusCode int
 	err        *errorstack.Error
 	metadata   *Metadata
+	retryAfter time.Duration
 }
 
 /*
@@ -62,6 +71,43 @@ func (res *ResponseError[Metadata]) SetStatus(status int) *ResponseError[Metadat
 	return res
 }
 
+/*
+SetError sets the response from err, such as an error returned by one of the
+integrations. If an *errorstack.Error is found in err's chain, the status code is
+mapped from the code of its first entry (e.g. 404 for CodeNotFound) and its
+entries are written as is. Errors without an *errorstack.Error in their chain,
+and errors mapped to a 5xx status code, are written as the status' localized
+message instead, so details of internal failures never reach clients. The
+retry delay of the error, if any, is written as the Retry-After header.
+*/
+func (res *ResponseError[Metadata]) SetError(err error) *ResponseError[Metadata] {
+	var stack *errorstack.Error
+	if !errors.As(err, &stack) || len(stack.Entries) == 0 {
+		return res.SetStatus(http.StatusInternalServerError)
+	}
+
+	res.retryAfter = stack.RetryAfter()
+
+	status := errorstack.CodeToHTTPStatus(errorstack.CodeOf(stack))
+	if status >= http.StatusInternalServerError {
+		return res.SetStatus(status)
+	}
+
+	res.statusCode = status
+	res.err = stack
+	return res
+}
+
+/*
+SetRetryAfter sets the minimum delay the client should wait before retrying the
+request, written as the Retry-After header in seconds. Typically used along
+http.StatusTooManyRequests or http.StatusServiceUnavailable.
+*/
+func (res *ResponseError[Metadata]) SetRetryAfter(d time.Duration) *ResponseError[Metadata] {
+	res.retryAfter = d
+	return res
+}
+
 /*
 SetMetadata sets the typed metadata folded under top-level extensions.metadata.
 */
@@ -82,11 +128,21 @@ func (res *ResponseError[Metadata]) SetValidations(entries ...errorstack.Entry)
 }
 
 /*
-Write writes the ResponseError to the ResponseWriter. Falls back to a constant
+Write writes the ResponseError to the ResponseWriter, in the error format
+configured with Config.ErrorFormat: either the GraphQL-spec envelope as
+application/json, or an RFC 9457 problem as application/problem+json. The
+Retry-After header is set when a retry delay is known. Falls back to a constant
 INTERNAL_ERROR envelope if marshaling fails.
 */
 func (res *ResponseError[Metadata]) Write(rw http.ResponseWriter) {
-	b, err := json.Marshal(res)
+	contentType := contentTypeJSON
+	marshal := res.MarshalJSON
+	if errorFormatOf(res.request) == ErrorFormatProblem {
+		contentType = contentTypeProblem
+		marshal = res.MarshalProblemJSON
+	}
+
+	b, err := marshal()
 	if err != nil {
 		rw.Header().Set("Content-Type", "application/json")
 		rw.WriteHeader(http.StatusInternalServerError)
@@ -94,7 +150,11 @@ func (res *ResponseError[Metadata]) Write(rw http.ResponseWriter) {
 		return
 	}
 
-	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Content-Type", contentType)
+	if res.retryAfter > 0 {
+		rw.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(res.retryAfter.Seconds())), 10))
+	}
+
 	rw.WriteHeader(res.statusCode)
 	rw.Write(b)
 }
@@ -107,6 +167,27 @@ routed through errorstack.Error.MarshalJSON so the schema invariant
 appended without going through the public constructors.
 */
 func (res *ResponseError[Metadata]) MarshalJSON() ([]byte, error) {
+	return res.stack().MarshalJSON()
+}
+
+/*
+MarshalProblemJSON serializes the response into an RFC 9457 problem: the
+"type", "title", "status", "detail", and "instance" members, plus an "errors"
+extension member carrying the entries — with their paths and codes — exactly as
+in the GraphQL-spec envelope. Top-level extensions, including the typed
+metadata, are set as extension members of the problem. See marshalProblem for
+the mapping.
+*/
+func (res *ResponseError[Metadata]) MarshalProblemJSON() ([]byte, error) {
+	return marshalProblem(res.request, res.statusCode, res.stack())
+}
+
+/*
+stack returns the *errorstack.Error to serialize: the one built by the setters,
+with entries localized for the request and the typed metadata folded under
+top-level extensions.metadata.
+*/
+func (res *ResponseError[Metadata]) stack() *errorstack.Error {
 	stack := res.err
 	if stack == nil {
 		stack = errorstack.New(
@@ -115,11 +196,25 @@ func (res *ResponseError[Metadata]) MarshalJSON() ([]byte, error) {
 		)
 	}
 
+	stack = cloneErrorWithLocalizedEntries(stack, locales.GetPreferredLanguage(res.request))
 	if res.metadata != nil {
 		stack = cloneErrorWithMetadata(stack, res.metadata)
 	}
 
-	return stack.MarshalJSON()
+	return stack
+}
+
+/*
+cloneErrorWithLocalizedEntries returns a shallow clone of err where the entries
+carrying a message ID are localized for lang, keeping their English message as
+fallback. Like cloneErrorWithMetadata, the clone leaves the caller's
+*errorstack.Error untouched.
+*/
+func cloneErrorWithLocalizedEntries(err *errorstack.Error, lang language.Tag) *errorstack.Error {
+	return &errorstack.Error{
+		Entries:    locales.Localize(lang, err.Entries),
+		Extensions: err.Extensions,
+	}
 }
 
 /*
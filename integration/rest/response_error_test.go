@@ -2,14 +2,18 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/mountayaapp/helix.go/errorstack"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
 )
 
 func TestNewResponseError(t *testing.T) {
@@ -121,6 +125,126 @@ func TestResponseError_SetStatus(t *testing.T) {
 	}
 }
 
+func TestResponseError_SetError(t *testing.T) {
+	cause := errors.New("no rows in result set")
+
+	testcases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expected       string
+	}{
+		{
+			name:           "not found",
+			err:            errorstack.Wrap(cause, "Resource does not exist", errorstack.WithCode(errorstack.CodeNotFound)),
+			expectedStatus: http.StatusNotFound,
+			expected:       `{"errors":[{"message":"Resource does not exist","extensions":{"code":"NOT_FOUND"}}]}`,
+		},
+		{
+			name:           "wrapped conflict",
+			err:            fmt.Errorf("create user: %w", errorstack.New("Resource already exists", errorstack.WithCode(errorstack.CodeConflict))),
+			expectedStatus: http.StatusConflict,
+			expected:       `{"errors":[{"message":"Resource already exists","extensions":{"code":"CONFLICT"}}]}`,
+		},
+		{
+			name:           "validation",
+			err:            errorstack.NewValidation(errorstack.Entry{Message: "Must be set", Path: []any{"body", "email"}}),
+			expectedStatus: http.StatusBadRequest,
+			expected:       `{"errors":[{"message":"Must be set","path":["body","email"],"extensions":{"code":"VALIDATION_FAILED"}}]}`,
+		},
+		{
+			name:           "internal error hides details",
+			err:            errorstack.Wrap(cause, "Failed to execute query"),
+			expectedStatus: http.StatusInternalServerError,
+			expected:       `{"errors":[{"message":"Internal server error","extensions":{"code":"INTERNAL_ERROR"}}]}`,
+		},
+		{
+			name:           "gateway timeout",
+			err:            errorstack.Wrap(cause, "Query timed out", errorstack.WithCode(errorstack.CodeGatewayTimeout)),
+			expectedStatus: http.StatusGatewayTimeout,
+			expected:       `{"errors":[{"message":"Upstream gateway timed out","extensions":{"code":"GATEWAY_TIMEOUT"}}]}`,
+		},
+		{
+			name:           "plain error",
+			err:            cause,
+			expectedStatus: http.StatusInternalServerError,
+			expected:       `{"errors":[{"message":"Internal server error","extensions":{"code":"INTERNAL_ERROR"}}]}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rw := httptest.NewRecorder()
+
+			NewResponseError[NoMetadata](req).SetError(tc.err).Write(rw)
+
+			assert.Equal(t, tc.expectedStatus, rw.Code)
+			assert.JSONEq(t, tc.expected, rw.Body.String())
+		})
+	}
+}
+
+func TestResponseError_RetryAfter(t *testing.T) {
+	testcases := []struct {
+		name           string
+		build          func(res *ResponseError[NoMetadata]) *ResponseError[NoMetadata]
+		expectedStatus int
+		expectedHeader string
+	}{
+		{
+			name: "SetRetryAfter",
+			build: func(res *ResponseError[NoMetadata]) *ResponseError[NoMetadata] {
+				return res.SetStatus(http.StatusTooManyRequests).SetRetryAfter(30 * time.Second)
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedHeader: "30",
+		},
+		{
+			name: "rounded up to the second",
+			build: func(res *ResponseError[NoMetadata]) *ResponseError[NoMetadata] {
+				return res.SetStatus(http.StatusTooManyRequests).SetRetryAfter(1500 * time.Millisecond)
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedHeader: "2",
+		},
+		{
+			name: "SetError with 5xx status",
+			build: func(res *ResponseError[NoMetadata]) *ResponseError[NoMetadata] {
+				return res.SetError(errorstack.New("Database is unavailable",
+					errorstack.WithCode(errorstack.CodeServiceUnavailable),
+					errorstack.WithRetryAfter(time.Minute),
+				))
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedHeader: "60",
+		},
+		{
+			name: "SetError non retryable",
+			build: func(res *ResponseError[NoMetadata]) *ResponseError[NoMetadata] {
+				return res.SetError(errorstack.New("Card was declined",
+					errorstack.WithCode(errorstack.CodePaymentRequired),
+					errorstack.WithRetryable(false),
+				))
+			},
+			expectedStatus: http.StatusPaymentRequired,
+			expectedHeader: "",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rw := httptest.NewRecorder()
+
+			tc.build(NewResponseError[NoMetadata](req)).Write(rw)
+
+			assert.Equal(t, tc.expectedStatus, rw.Code)
+			assert.Equal(t, tc.expectedHeader, rw.Header().Get("Retry-After"))
+		})
+	}
+}
+
 func TestResponseError_SetMetadata(t *testing.T) {
 	type metadata struct {
 		RequestID string `json:"request_id"`
@@ -172,6 +296,60 @@ func TestResponseError_SetValidations(t *testing.T) {
 	}
 }
 
+func TestResponseError_SetValidations_LocalizedByMessageID(t *testing.T) {
+	AddOrEditMessages(language.Afrikaans, map[string]string{
+		"min_length": "Moet ten minste {min} karakters lank wees",
+	})
+
+	validations := []errorstack.Entry{
+		{
+			Message:   "Must be at least 8 characters long",
+			Path:      []any{"request", "body", "password"},
+			MessageID: "min_length",
+			Params:    map[string]any{"min": 8},
+		},
+		{
+			Message:   "Must be set",
+			Path:      []any{"request", "body", "name"},
+			MessageID: "must_be_set",
+		},
+	}
+
+	testcases := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{
+			name:     "translated",
+			header:   "af",
+			expected: `{"errors":[{"message":"Moet ten minste 8 karakters lank wees","path":["request","body","password"],"extensions":{"code":"VALIDATION_FAILED"}},{"message":"Must be set","path":["request","body","name"],"extensions":{"code":"VALIDATION_FAILED"}}]}`,
+		},
+		{
+			name:     "English fallback",
+			header:   "en",
+			expected: `{"errors":[{"message":"Must be at least 8 characters long","path":["request","body","password"],"extensions":{"code":"VALIDATION_FAILED"}},{"message":"Must be set","path":["request","body","name"],"extensions":{"code":"VALIDATION_FAILED"}}]}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", tc.header)
+			rw := httptest.NewRecorder()
+
+			NewResponseError[NoMetadata](req).
+				SetStatus(http.StatusBadRequest).
+				SetValidations(validations...).
+				Write(rw)
+
+			assert.JSONEq(t, tc.expected, rw.Body.String())
+		})
+	}
+
+	assert.Equal(t, "Must be at least 8 characters long", validations[0].Message, "validations passed must not be mutated")
+}
+
 func TestResponseError_Write(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	rw := httptest.NewRecorder()
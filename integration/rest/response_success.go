@@ -3,6 +3,7 @@ package rest
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 )
 
 var _ json.Marshaler = (*ResponseSuccess[any, any])(nil)
@@ -25,10 +26,12 @@ type Response struct {
 ResponseSuccess is the JSON object every HTTP responses shall return.
 */
 type ResponseSuccess[Metadata any, Data any] struct {
-	request    *http.Request
-	statusCode int
-	metadata   *Metadata
-	data       *Data
+	request      *http.Request
+	statusCode   int
+	metadata     *Metadata
+	data         *Data
+	etag         string
+	lastModified time.Time
 }
 
 /*
@@ -80,7 +83,33 @@ func (res *ResponseSuccess[Metadata, Data]) SetData(data Data) *ResponseSuccess[
 }
 
 /*
-Write writes the ResponseSuccess to the ResponseWriter.
+SetETag sets the version of the resource returned, written as the ETag header.
+It takes precedence over the hash of the envelope generated for routes
+registered with WithETag. When set, a 200 response to a GET or HEAD request
+matching the client's If-None-Match header is written as a 304 with no body.
+*/
+func (res *ResponseSuccess[Metadata, Data]) SetETag(version string) *ResponseSuccess[Metadata, Data] {
+	res.etag = version
+
+	return res
+}
+
+/*
+SetLastModified sets the time the resource returned was last modified, written
+as the Last-Modified header. When set, a 200 response to a GET or HEAD request
+not modified since the client's If-Modified-Since header is written as a 304
+with no body.
+*/
+func (res *ResponseSuccess[Metadata, Data]) SetLastModified(t time.Time) *ResponseSuccess[Metadata, Data] {
+	res.lastModified = t
+
+	return res
+}
+
+/*
+Write writes the ResponseSuccess to the ResponseWriter. The ETag and
+Last-Modified headers are set when known, and the response is written as a 304
+with no body when the client already has it. See SetETag and WithETag.
 */
 func (res *ResponseSuccess[Metadata, Data]) Write(rw http.ResponseWriter) {
 	b, err := json.Marshal(res)
@@ -91,11 +120,49 @@ func (res *ResponseSuccess[Metadata, Data]) Write(rw http.ResponseWriter) {
 		return
 	}
 
+	if res.writeValidators(rw, b) {
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(res.statusCode)
 	rw.Write(b)
 }
 
+/*
+writeValidators sets the ETag and Last-Modified headers of a 2xx response, if
+known. Returns true if the response is not modified for the client, in which
+case it must be written as a 304 with no body.
+*/
+func (res *ResponseSuccess[Metadata, Data]) writeValidators(rw http.ResponseWriter, body []byte) bool {
+	if res.statusCode < http.StatusOK || res.statusCode >= http.StatusMultipleChoices {
+		return false
+	}
+
+	var etag string
+	switch {
+	case res.etag != "":
+		etag = formatETag(res.etag)
+	case etagEnabled(res.request):
+		etag = hashETag(body)
+	}
+
+	if etag != "" {
+		rw.Header().Set("ETag", etag)
+	}
+
+	if !res.lastModified.IsZero() {
+		rw.Header().Set("Last-Modified", res.lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if res.statusCode != http.StatusOK || res.request == nil {
+		return false
+	}
+
+	return notModified(res.request, etag, res.lastModified)
+}
+
 /*
 MarshalJSON serializes the response into the GraphQL-spec envelope, folding
 the typed metadata under top-level extensions.metadata when present.
@@ -3,10 +3,12 @@ package rest
 import (
 	"context"
 	"net/http"
+	"slices"
 
 	"github.com/mountayaapp/helix.go/errorstack"
 	"github.com/mountayaapp/helix.go/service"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/routers"
 	"github.com/uptrace/bunrouter"
 	"github.com/uptrace/bunrouter/extra/bunrouterotel"
@@ -17,6 +19,11 @@ import (
 REST exposes the HTTP REST API functions. Every verb accepts RouteOption
 variadically, so a route declares its own policy where its path is declared —
 the only place the route is known before the router has matched anything.
+
+Group returns a REST registering routes under a path prefix, with the options
+passed as defaults for its routes, and Use adds middleware to the routes
+registered afterwards. Groups can be nested, and inherit the prefix, options,
+and middleware of their parent.
 */
 type REST interface {
 	GET(path string, handler http.HandlerFunc, opts ...RouteOption)
@@ -24,6 +31,8 @@ type REST interface {
 	PATCH(path string, handler http.HandlerFunc, opts ...RouteOption)
 	POST(path string, handler http.HandlerFunc, opts ...RouteOption)
 	PUT(path string, handler http.HandlerFunc, opts ...RouteOption)
+	Group(prefix string, opts ...RouteOption) REST
+	Use(middleware ...func(next http.Handler) http.Handler)
 }
 
 /*
@@ -49,6 +58,23 @@ type rest struct {
 	// oapirouter is the OpenAPI router used to validate requests and responses
 	// against the OpenAPI description passed in Config.
 	oapirouter routers.Router
+
+	// middleware is the middleware added with Use, wrapping every route registered
+	// afterwards, including the ones of groups created afterwards.
+	middleware []func(next http.Handler) http.Handler
+
+	// oapidoc is the OpenAPI description served when enabled in Config, with its
+	// external references bundled and the servers it declares.
+	oapidoc *openapi3.T
+
+	// maxBodySizes is the request body size limit each route resolved to, keyed
+	// by routeKey. It is read before the route's handler is reached, so the body
+	// is already limited when validated against the OpenAPI description.
+	maxBodySizes map[string]int64
+
+	// entries are the validation entries collected from the options of the routes
+	// registered, returned by Start before serving any request.
+	entries []errorstack.Entry
 }
 
 /*
@@ -102,7 +128,7 @@ GET registers a handler for the GET method at path, under the policy resolved
 from opts.
 */
 func (r *rest) GET(path string, handler http.HandlerFunc, opts ...RouteOption) {
-	r.bun.GET(path, r.applyRouteOptions(handler, opts))
+	r.bun.GET(path, r.applyRouteOptions(http.MethodGet, path, handler, r.middleware, opts))
 }
 
 /*
@@ -110,7 +136,7 @@ DELETE registers a handler for the DELETE method at path, under the policy
 resolved from opts.
 */
 func (r *rest) DELETE(path string, handler http.HandlerFunc, opts ...RouteOption) {
-	r.bun.DELETE(path, r.applyRouteOptions(handler, opts))
+	r.bun.DELETE(path, r.applyRouteOptions(http.MethodDelete, path, handler, r.middleware, opts))
 }
 
 /*
@@ -118,7 +144,7 @@ PATCH registers a handler for the PATCH method at path, under the policy
 resolved from opts.
 */
 func (r *rest) PATCH(path string, handler http.HandlerFunc, opts ...RouteOption) {
-	r.bun.PATCH(path, r.applyRouteOptions(handler, opts))
+	r.bun.PATCH(path, r.applyRouteOptions(http.MethodPatch, path, handler, r.middleware, opts))
 }
 
 /*
@@ -126,7 +152,7 @@ POST registers a handler for the POST method at path, under the policy resolved
 from opts.
 */
 func (r *rest) POST(path string, handler http.HandlerFunc, opts ...RouteOption) {
-	r.bun.POST(path, r.applyRouteOptions(handler, opts))
+	r.bun.POST(path, r.applyRouteOptions(http.MethodPost, path, handler, r.middleware, opts))
 }
 
 /*
@@ -134,36 +160,97 @@ PUT registers a handler for the PUT method at path, under the policy resolved
 from opts.
 */
 func (r *rest) PUT(path string, handler http.HandlerFunc, opts ...RouteOption) {
-	r.bun.PUT(path, r.applyRouteOptions(handler, opts))
+	r.bun.PUT(path, r.applyRouteOptions(http.MethodPut, path, handler, r.middleware, opts))
+}
+
+/*
+Group returns a REST registering routes under prefix. The options passed are
+applied to every route of the group before the route's own, and the middleware
+added with Use so far wraps them.
+*/
+func (r *rest) Group(prefix string, opts ...RouteOption) REST {
+	return &group{
+		rest:       r,
+		bun:        r.bun.NewGroup(prefix),
+		prefix:     joinPath("", prefix),
+		opts:       slices.Clone(opts),
+		middleware: slices.Clone(r.middleware),
+	}
 }
 
 /*
-applyRouteOptions wraps a handler with the policy its route resolved to. A route
-that ends up with no budget is handed to the underlying router untouched, so the
-unbounded case costs nothing per request.
-
-The budget is applied here rather than in Config.Middleware because middleware
-wraps the whole router and therefore runs before any route has matched, where
-only the concrete request path is known — recognising a route there would mean
-maintaining a second copy of the routing table.
+Use adds middleware wrapping every route registered afterwards. Unlike
+Config.Middleware, it runs once the route has matched, so ParamsFromContext is
+available. The first middleware passed is the outermost.
 */
-func (r *rest) applyRouteOptions(handler http.HandlerFunc, opts []RouteOption) http.HandlerFunc {
-	timeout := r.resolveRouteOptions(opts)
+func (r *rest) Use(middleware ...func(next http.Handler) http.Handler) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+/*
+applyRouteOptions wraps a handler with the middleware and the policy its route
+resolved to. A route that ends up with no middleware and no budget is handed to
+the underlying router with only the panic recovery, so the unbounded case costs
+next to nothing per request.
+
+The budget and the middleware are applied here rather than in Config.Middleware
+because the latter wraps the whole router and therefore runs before any route
+has matched, where only the concrete request path is known — recognising a route
+there would mean maintaining a second copy of the routing table.
+
+Layers are ordered, from the outermost to the innermost: the request budget, the
+middleware added with Use, the middleware added with WithMiddleware, and the
+handler. Every middleware therefore runs within the budget, and sees the route's
+params with ParamsFromContext.
+
+Panics of every layer are recovered by recoverRoute, wrapping them all.
+
+The body size limit is only recorded here, keyed by the route's method and full
+path: it is enforced by middlewareBodyLimit, since the OpenAPI validation reads
+the body before any of these layers runs.
+
+Validation entries of the options are collected with the route's method and full
+path prepended to their paths, and reported by Start.
+*/
+func (r *rest) applyRouteOptions(method string, path string, handler http.HandlerFunc, middleware []func(next http.Handler) http.Handler, opts []RouteOption) http.HandlerFunc {
+	resolved := r.resolveRouteOptions(opts)
+	if r.maxBodySizes == nil {
+		r.maxBodySizes = make(map[string]int64)
+	}
+
+	r.maxBodySizes[routeKey(method, path)] = resolved.maxBodySize
+	for _, entry := range resolved.entries {
+		entry.Path = append([]any{"routes", routeKey(method, path)}, entry.Path...)
+		r.entries = append(r.entries, entry)
+	}
+
+	middleware = append(slices.Clone(middleware), resolved.middleware...)
+	if len(middleware) > 0 {
+		var h http.Handler = handler
+		for i := len(middleware) - 1; i >= 0; i-- {
+			h = middleware[i](h)
+		}
+
+		handler = h.ServeHTTP
+	}
+
+	timeout := resolved.timeout
 	if timeout <= 0 {
-		return handler
+		return r.recoverRoute(handler)
 	}
 
-	return func(rw http.ResponseWriter, req *http.Request) {
+	return r.recoverRoute(func(rw http.ResponseWriter, req *http.Request) {
 		ctx, cancel := context.WithTimeout(req.Context(), timeout)
 		defer cancel()
 
 		handler(rw, req.WithContext(ctx))
-	}
+	})
 }
 
 /*
 buildRouter tries to build the HTTP router. It comes with opinionated handlers
-for 404 and 405 HTTP errors, as well as for the health endpoint.
+for 404 and 405 HTTP errors, as well as for the health endpoint and the OpenAPI
+ones when enabled.
 */
 func (r *rest) buildRouter() (*bunrouter.CompatRouter, []errorstack.Entry) {
 	opts := []bunrouter.Option{
@@ -171,6 +258,8 @@ func (r *rest) buildRouter() (*bunrouter.CompatRouter, []errorstack.Entry) {
 		bunrouter.Use(bunrouterotel.NewMiddleware(bunrouterotel.WithClientIP())),
 		bunrouter.WithNotFoundHandler(r.handlerNotFound),
 		bunrouter.WithMethodNotAllowedHandler(r.handlerMethodNotAllowed),
+		bunrouter.WithMiddleware(r.middlewareAbort),
+		bunrouter.WithMiddleware(r.middlewareBodyLimit),
 	}
 
 	if r.config.OpenAPI.Enabled {
@@ -181,5 +270,14 @@ func (r *rest) buildRouter() (*bunrouter.CompatRouter, []errorstack.Entry) {
 	router.Router.GET("/health", r.handlerLiveness)
 	router.Router.GET("/ready", r.handlerReadiness)
 
+	if r.config.OpenAPI.Serve {
+		router.Router.GET(pathOpenAPIJSON, r.handlerOpenAPIJSON)
+		router.Router.GET(pathOpenAPIYAML, r.handlerOpenAPIYAML)
+	}
+
+	if r.config.OpenAPI.Docs {
+		router.Router.GET(pathDocs, r.handlerDocs)
+	}
+
 	return router, nil
 }
@@ -1,34 +1,54 @@
 package rest
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/event"
+	"github.com/mountayaapp/helix.go/integration"
+	"github.com/mountayaapp/helix.go/integration/compression"
+
+	"github.com/klauspost/compress/gzip"
 	"github.com/stretchr/testify/assert"
-	"github.com/uptrace/bunrouter"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
-func newTestRouter() *rest {
+/*
+newTestRouter returns a REST integration with its router built like New does,
+after the options passed have edited its config.
+*/
+func newTestRouter(t *testing.T, options ...func(cfg *Config)) *rest {
+	t.Helper()
+
 	r := &rest{
 		config: &Config{},
 	}
 
-	router := bunrouter.New(
-		bunrouter.WithNotFoundHandler(r.handlerNotFound),
-		bunrouter.WithMethodNotAllowedHandler(r.handlerMethodNotAllowed),
-	).Compat()
+	for _, option := range options {
+		option(r.config)
+	}
 
-	router.Router.GET("/health", r.handlerLiveness)
-	router.Router.GET("/ready", r.handlerReadiness)
+	var entries []errorstack.Entry
+	if r.config.OpenAPI.Enabled {
+		r.oapirouter, entries = r.buildRouterOpenAPI()
+		require.Empty(t, entries)
+	}
 
-	r.bun = router
+	r.bun, entries = r.buildRouter()
+	require.Empty(t, entries)
 
 	return r
 }
 
 func TestRouter_Liveness_ReturnsOK(t *testing.T) {
-	r := newTestRouter()
+	r := newTestRouter(t)
 
 	req := httptest.NewRequest(http.MethodGet, "/health", nil)
 	rw := httptest.NewRecorder()
@@ -40,7 +60,7 @@ func TestRouter_Liveness_ReturnsOK(t *testing.T) {
 }
 
 func TestRouter_Readiness_CustomReady(t *testing.T) {
-	r := newTestRouter()
+	r := newTestRouter(t)
 	r.config.Readiness = func(req *http.Request) int {
 		return http.StatusOK
 	}
@@ -55,7 +75,7 @@ func TestRouter_Readiness_CustomReady(t *testing.T) {
 }
 
 func TestRouter_Readiness_WithCustomReadiness(t *testing.T) {
-	r := newTestRouter()
+	r := newTestRouter(t)
 	r.config.Readiness = func(req *http.Request) int {
 		return http.StatusServiceUnavailable
 	}
@@ -76,7 +96,7 @@ func TestRouter_UnknownRoute_ReturnsNotFound(t *testing.T) {
 		"/api/test",
 	}
 
-	r := newTestRouter()
+	r := newTestRouter(t)
 
 	for _, route := range routes {
 		t.Run("GET "+route, func(t *testing.T) {
@@ -99,7 +119,7 @@ func TestRouter_NonAllowedMethod_ReturnsMethodNotAllowed(t *testing.T) {
 		http.MethodDelete,
 	}
 
-	r := newTestRouter()
+	r := newTestRouter(t)
 
 	for _, method := range methods {
 		t.Run(method+" /health", func(t *testing.T) {
@@ -113,3 +133,280 @@ func TestRouter_NonAllowedMethod_ReturnsMethodNotAllowed(t *testing.T) {
 		})
 	}
 }
+
+func TestRouter_Handler_EventAvailableToMiddleware(t *testing.T) {
+	r := newTestRouter(t)
+	r.config.Event = integration.ConfigEvent{Enabled: true}
+
+	var e event.Event
+	var found bool
+	r.config.Middleware = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			e, found = event.EventFromContext(req.Context())
+			next.ServeHTTP(rw, req)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/unknown?utm_source=newsletter", nil)
+	req.RemoteAddr = "203.0.113.7:52100"
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15")
+	rw := httptest.NewRecorder()
+	r.handler().ServeHTTP(rw, req)
+
+	assert.True(t, found)
+	assert.Equal(t, "203.0.113.7", e.IP)
+	assert.Equal(t, "newsletter", e.Campaign.Source)
+	assert.Equal(t, "macOS", e.OS.Name)
+	assert.Equal(t, "/unknown", e.Page.Path)
+}
+
+func TestRouter_Handler_EventFromBaggageTakesPrecedence(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.Baggage{}, propagation.TraceContext{}))
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	r := newTestRouter(t)
+	r.config.Event = integration.ConfigEvent{Enabled: true}
+
+	var e event.Event
+	r.config.Middleware = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			e, _ = event.EventFromContext(req.Context())
+			next.ServeHTTP(rw, req)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	req.RemoteAddr = "10.0.0.3:52100"
+	req.Header.Set("User-Agent", "upstream-service/1.0")
+	req.Header.Set("baggage", "event.name=subscribed,event.ip=203.0.113.7")
+	rw := httptest.NewRecorder()
+	r.handler().ServeHTTP(rw, req)
+
+	assert.Equal(t, "subscribed", e.Name)
+	assert.Equal(t, "203.0.113.7", e.IP)
+	assert.Equal(t, "upstream-service/1.0", e.UserAgent)
+}
+
+func TestRouter_Handler_EventDisabled(t *testing.T) {
+	r := newTestRouter(t)
+
+	var found bool
+	r.config.Middleware = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			_, found = event.EventFromContext(req.Context())
+			next.ServeHTTP(rw, req)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	rw := httptest.NewRecorder()
+	r.handler().ServeHTTP(rw, req)
+
+	assert.False(t, found)
+}
+
+func TestRouter_Handler_CORSPreflightSkipsMiddleware(t *testing.T) {
+	r := newTestRouter(t)
+	r.config.CORS = integration.ConfigCORS{
+		Enabled:        true,
+		AllowedOrigins: []string{"https://app.example.com"},
+	}
+
+	require.Empty(t, r.config.CORS.Sanitize())
+
+	var called bool
+	r.config.Middleware = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			called = true
+			rw.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodOptions, "/users", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rw := httptest.NewRecorder()
+	r.handler().ServeHTTP(rw, req)
+
+	// Preflight requests are answered before routing and before the user's
+	// middleware, so they never require authentication.
+	assert.False(t, called)
+	assert.Equal(t, http.StatusNoContent, rw.Code)
+	assert.Equal(t, "https://app.example.com", rw.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestRouter_Handler_CORSHeadersOnErrors(t *testing.T) {
+	r := newTestRouter(t)
+	r.config.CORS = integration.ConfigCORS{
+		Enabled:        true,
+		AllowedOrigins: []string{"https://*.example.com"},
+	}
+
+	require.Empty(t, r.config.CORS.Sanitize())
+
+	req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rw := httptest.NewRecorder()
+	r.handler().ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	assert.Equal(t, "https://app.example.com", rw.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", rw.Header().Get("Vary"))
+}
+
+func TestRouter_Handler_CompressionWithResponseValidation(t *testing.T) {
+	roles := strings.Repeat(`"admin",`, 200)
+	body := `{"email":"john@example.com","roles":[` + roles + `"owner"]}`
+
+	testcases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "valid response is compressed",
+			body:           body,
+			expectedStatus: http.StatusOK,
+			expectedBody:   body,
+		},
+		{
+			name:           "invalid response is replaced",
+			body:           `{"roles":[` + roles + `"owner"]}`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRouter(t, withTestDescription(t, ValidationModeObserve, ValidationModeEnforce))
+			handleTestProfile(r, http.StatusOK, tc.body)
+			r.config.Compression = compression.Config{
+				Enabled: true,
+			}
+
+			require.Empty(t, r.config.Compression.Sanitize())
+
+			req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rw := httptest.NewRecorder()
+			r.handler().ServeHTTP(rw, req)
+
+			// The response held back for validation is compressed once released,
+			// and the error response replacing an invalid one is small enough to be
+			// written as is.
+			assert.Equal(t, tc.expectedStatus, rw.Code)
+			assert.Equal(t, "Accept-Encoding", rw.Header().Get("Vary"))
+			if tc.expectedBody == "" {
+				assert.Empty(t, rw.Header().Get("Content-Encoding"))
+				assert.Empty(t, rw.Header().Get("X-Custom"))
+				return
+			}
+
+			assert.Equal(t, "gzip", rw.Header().Get("Content-Encoding"))
+			assert.Equal(t, "value", rw.Header().Get("X-Custom"))
+
+			gr, err := gzip.NewReader(rw.Body)
+			require.NoError(t, err)
+
+			decoded, err := io.ReadAll(gr)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expectedBody, string(decoded))
+		})
+	}
+}
+
+func TestRouter_Handler_RecoversPanic(t *testing.T) {
+	testcases := []struct {
+		name       string
+		path       string
+		middleware func(next http.Handler) http.Handler
+		opts       []RouteOption
+	}{
+		{
+			name: "panic in route handler",
+			path: "/panic",
+		},
+		{
+			name: "panic in route handler within request budget",
+			path: "/panic",
+			opts: []RouteOption{WithTimeout(time.Second)},
+		},
+		{
+			name: "panic in user's middleware",
+			path: "/unknown",
+			middleware: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					if req.URL.Path == "/unknown" {
+						panic("boom")
+					}
+
+					next.ServeHTTP(rw, req)
+				})
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRouter(t, func(cfg *Config) {
+				cfg.ErrorFormat = ErrorFormatProblem
+				cfg.Middleware = tc.middleware
+				cfg.CORS = integration.ConfigCORS{
+					Enabled:        true,
+					AllowedOrigins: []string{"https://app.example.com"},
+				}
+
+				require.Empty(t, cfg.CORS.Sanitize())
+			})
+
+			r.GET("/panic", func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("X-Custom", "value")
+				panic("boom")
+			}, tc.opts...)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Origin", "https://app.example.com")
+			rw := httptest.NewRecorder()
+
+			// The panic is recovered and replaced by the 500 in the error format
+			// configured, with the CORS headers set so the client can read it.
+			require.NotPanics(t, func() {
+				r.handler().ServeHTTP(rw, req)
+			})
+
+			assert.Equal(t, http.StatusInternalServerError, rw.Code)
+			assert.Equal(t, "https://app.example.com", rw.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "application/problem+json", rw.Header().Get("Content-Type"))
+			assert.Empty(t, rw.Header().Get("X-Custom"))
+			assert.JSONEq(t, `{
+				"type": "about:blank",
+				"title": "Internal Server Error",
+				"status": 500,
+				"detail": "Internal server error",
+				"instance": "`+tc.path+`",
+				"errors": [{"message": "Internal server error", "extensions": {"code": "INTERNAL_ERROR"}}]
+			}`, rw.Body.String())
+		})
+	}
+}
+
+func TestRouter_Handler_PanicAfterWriteAbortsRequest(t *testing.T) {
+	r := newTestRouter(t)
+	r.GET("/stream", func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("partial"))
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	rw := httptest.NewRecorder()
+
+	// The response can not be replaced once written, so the request is aborted
+	// even though bunrouter recovers the panic of route handlers.
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		r.handler().ServeHTTP(rw, req)
+	})
+	assert.Equal(t, "partial", rw.Body.String())
+}
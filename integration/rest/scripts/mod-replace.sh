@@ -1,4 +1,5 @@
 #!/usr/bin/env bash
 
 go mod edit \
-  -replace github.com/mountayaapp/helix.go=../../
+  -replace github.com/mountayaapp/helix.go=../../ \
+  -replace github.com/mountayaapp/helix.go/integration/valkey=../valkey
@@ -0,0 +1,196 @@
+package rest
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+	"github.com/mountayaapp/helix.go/integration"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+/*
+ValidationMode is how the REST integration reacts when a request does not match
+the OpenAPI description.
+*/
+type ValidationMode string
+
+/*
+Validation modes supported by the REST integration:
+
+  - ValidationModeObserve records the validation errors on the trace, and still
+    calls the handler.
+  - ValidationModeEnforce records the validation errors on the trace, and rejects
+    the request with a 400 before the handler is called. For responses, the
+    validation errors are also logged, and the response is replaced with a 500.
+*/
+const (
+	ValidationModeObserve ValidationMode = "observe"
+	ValidationModeEnforce ValidationMode = "enforce"
+)
+
+/*
+requestValidationEntries converts the error returned by kin-openapi when a
+request does not match the OpenAPI description into validation entries, one per
+violation. The path of each entry starts with "request", followed by where the
+violation is located — "path", "query", "header", "cookie", or "body" — and the
+name of the parameter or the JSON path within the body. For example:
+
+	["request", "query", "limit"]
+	["request", "body", "email"]
+	["request", "body", "items", 0, "quantity"]
+*/
+func requestValidationEntries(err error) []errorstack.Entry {
+	var entries []errorstack.Entry
+	for _, e := range flattenValidationError(err) {
+		var reqErr *openapi3filter.RequestError
+		if !errors.As(e, &reqErr) {
+			entries = append(entries, errorstack.Entry{
+				Message: integration.NormalizeErrorMessage(e),
+				Path:    []any{"request"},
+			})
+
+			continue
+		}
+
+		path := []any{"request"}
+		switch {
+		case reqErr.Parameter != nil:
+			path = append(path, reqErr.Parameter.In, reqErr.Parameter.Name)
+		case reqErr.RequestBody != nil:
+			path = append(path, "body")
+		}
+
+		causes := flattenValidationError(reqErr.Err)
+		if len(causes) == 0 {
+			entries = append(entries, errorstack.Entry{
+				Message: normalizeReason(reqErr.Reason),
+				Path:    path,
+			})
+
+			continue
+		}
+
+		for _, cause := range causes {
+			entry := errorstack.Entry{
+				Message: normalizeReason(reqErr.Reason),
+				Path:    path,
+			}
+
+			var schemaErr *openapi3.SchemaError
+			switch {
+			case errors.As(cause, &schemaErr):
+				entry.Message = normalizeReason(schemaErr.Reason)
+				entry.Path = appendPointer(path, schemaErr.JSONPointer())
+
+			case reqErr.Reason == "":
+				entry.Message = integration.NormalizeErrorMessage(cause)
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+/*
+responseValidationEntries converts the error returned by kin-openapi when a
+response does not match the OpenAPI description into entries carrying
+CodeInternalError, one per violation. The path of each entry starts with
+"response", followed by "body" and the JSON path within the body for schema
+violations of the body. For example:
+
+	["response", "body", "user", "email"]
+*/
+func responseValidationEntries(err error) []errorstack.Entry {
+	var entries []errorstack.Entry
+	for _, e := range flattenValidationError(err) {
+		var resErr *openapi3filter.ResponseError
+		if !errors.As(e, &resErr) {
+			entries = append(entries, responseEntry(integration.NormalizeErrorMessage(e), "response"))
+			continue
+		}
+
+		causes := flattenValidationError(resErr.Err)
+		if !strings.HasPrefix(resErr.Reason, "response body") || len(causes) == 0 {
+			entries = append(entries, responseEntry(integration.NormalizeErrorMessage(resErr), "response"))
+			continue
+		}
+
+		for _, cause := range causes {
+			var schemaErr *openapi3.SchemaError
+			if !errors.As(cause, &schemaErr) {
+				entries = append(entries, responseEntry(normalizeReason(resErr.Reason+": "+cause.Error()), "response", "body"))
+				continue
+			}
+
+			entries = append(entries, responseEntry(normalizeReason(schemaErr.Reason), appendPointer([]any{"response", "body"}, schemaErr.JSONPointer())...))
+		}
+	}
+
+	return entries
+}
+
+/*
+responseEntry returns an entry carrying CodeInternalError for a response
+validation error.
+*/
+func responseEntry(message string, path ...any) errorstack.Entry {
+	return errorstack.Entry{
+		Message:    message,
+		Path:       path,
+		Extensions: map[string]any{"code": errorstack.CodeInternalError},
+	}
+}
+
+/*
+flattenValidationError returns the errors held by err, expanding nested
+openapi3.MultiError. Returns nil if err is nil.
+*/
+func flattenValidationError(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	multi, ok := err.(openapi3.MultiError)
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, e := range multi {
+		errs = append(errs, flattenValidationError(e)...)
+	}
+
+	return errs
+}
+
+/*
+appendPointer returns a copy of path with the segments of a JSON pointer
+appended. Segments made of digits are array indexes, and are appended as int.
+*/
+func appendPointer(path []any, pointer []string) []any {
+	out := make([]any, 0, len(path)+len(pointer))
+	out = append(out, path...)
+	for _, segment := range pointer {
+		if index, err := strconv.Atoi(segment); err == nil && index >= 0 {
+			out = append(out, index)
+		} else {
+			out = append(out, segment)
+		}
+	}
+
+	return out
+}
+
+/*
+normalizeReason returns the reason of a kin-openapi error with its first letter
+in upper case, as for every other entry message.
+*/
+func normalizeReason(reason string) string {
+	return integration.NormalizeErrorMessage(errors.New(reason))
+}
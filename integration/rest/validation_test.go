@@ -0,0 +1,299 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDescription = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /users/{id}:
+    post:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            maximum: 100
+        - name: X-Tenant
+          in: header
+          required: true
+          schema:
+            type: string
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [email]
+              properties:
+                email:
+                  type: string
+                  format: email
+                items:
+                  type: array
+                  items:
+                    type: object
+                    properties:
+                      quantity:
+                        type: integer
+                        minimum: 1
+      responses:
+        "200":
+          description: OK
+  /profile:
+    get:
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [email]
+                properties:
+                  email:
+                    type: string
+                  roles:
+                    type: array
+                    items:
+                      type: string
+`
+
+/*
+withTestDescription enables the validation of requests and responses against
+testDescription, in the modes passed.
+*/
+func withTestDescription(t *testing.T, reqMode ValidationMode, resMode ValidationMode) func(cfg *Config) {
+	description := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, os.WriteFile(description, []byte(testDescription), 0o600))
+
+	return func(cfg *Config) {
+		cfg.OpenAPI = ConfigOpenAPI{
+			Enabled:            true,
+			Description:        description,
+			RequestValidation:  reqMode,
+			ResponseValidation: resMode,
+		}
+	}
+}
+
+/*
+handleTestUsers registers a handler for the only path of testDescription. The
+handler reports whether it has been called.
+*/
+func handleTestUsers(r *rest) *bool {
+	called := false
+	r.POST("/users/:id", func(rw http.ResponseWriter, req *http.Request) {
+		called = true
+		NewResponseSuccess[NoMetadata, NoData](req).SetStatus(http.StatusOK).Write(rw)
+	})
+
+	return &called
+}
+
+/*
+handleTestProfile registers a handler for GET /profile writing the status and JSON
+body passed.
+*/
+func handleTestProfile(r *rest, status int, body string) {
+	r.GET("/profile", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Header().Set("X-Custom", "value")
+		rw.WriteHeader(status)
+		rw.Write([]byte(body))
+	})
+}
+
+/*
+assertEntries asserts the error response body passed holds the entries expected,
+in any order, each carrying the code passed. kin-openapi validates the properties
+of an object in no particular order.
+*/
+func assertEntries(t *testing.T, body []byte, code string, expected []errorstack.Entry) {
+	t.Helper()
+
+	var stack errorstack.Error
+	require.NoError(t, stack.UnmarshalJSON(body))
+
+	actual := make([]errorstack.Entry, 0, len(stack.Entries))
+	for _, entry := range stack.Entries {
+		assert.Equal(t, code, entry.Extensions["code"])
+		actual = append(actual, errorstack.Entry{Message: entry.Message, Path: entry.Path})
+	}
+
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func TestMiddlewareValidation_Enforce(t *testing.T) {
+	testcases := []struct {
+		name     string
+		target   string
+		header   bool
+		body     string
+		expected []errorstack.Entry
+	}{
+		{
+			name:   "valid request",
+			target: "/users/1?limit=10",
+			header: true,
+			body:   `{"email":"john@example.com","items":[{"quantity":1}]}`,
+		},
+		{
+			name:   "missing required property",
+			target: "/users/1",
+			header: true,
+			body:   `{}`,
+			expected: []errorstack.Entry{
+				{Message: `Property "email" is missing`, Path: []any{"request", "body", "email"}},
+			},
+		},
+		{
+			name:   "invalid nested property",
+			target: "/users/1",
+			header: true,
+			body:   `{"email":"john@example.com","items":[{"quantity":0}]}`,
+			expected: []errorstack.Entry{
+				{Message: "Number must be at least 1", Path: []any{"request", "body", "items", 0, "quantity"}},
+			},
+		},
+		{
+			name:   "invalid parameters",
+			target: "/users/abc?limit=1000",
+			body:   `{"email":"john@example.com"}`,
+			expected: []errorstack.Entry{
+				{Message: "Value abc: an invalid integer: invalid syntax", Path: []any{"request", "path", "id"}},
+				{Message: "Number must be at most 100", Path: []any{"request", "query", "limit"}},
+				{Message: "Value is required but missing", Path: []any{"request", "header", "X-Tenant"}},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRouter(t, withTestDescription(t, ValidationModeEnforce, ValidationModeObserve))
+			called := handleTestUsers(r)
+
+			req := httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.header {
+				req.Header.Set("X-Tenant", "acme")
+			}
+
+			rw := httptest.NewRecorder()
+			r.bun.ServeHTTP(rw, req)
+
+			if tc.expected == nil {
+				assert.Equal(t, http.StatusOK, rw.Code)
+				assert.True(t, *called)
+				return
+			}
+
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			assert.False(t, *called, "handler must not be called")
+
+			assertEntries(t, rw.Body.Bytes(), errorstack.CodeValidationFailed, tc.expected)
+		})
+	}
+}
+
+func TestMiddlewareValidation_Observe(t *testing.T) {
+	r := newTestRouter(t, withTestDescription(t, ValidationModeObserve, ValidationModeObserve))
+	called := handleTestUsers(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/users/abc", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	rw := httptest.NewRecorder()
+	r.bun.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.True(t, *called, "handler must be called")
+}
+
+func TestMiddlewareValidation_EnforceResponse(t *testing.T) {
+	testcases := []struct {
+		name     string
+		status   int
+		body     string
+		expected []errorstack.Entry
+	}{
+		{
+			name:   "valid response",
+			status: http.StatusOK,
+			body:   `{"email":"john@example.com","roles":["admin"]}`,
+		},
+		{
+			name:   "invalid body",
+			status: http.StatusOK,
+			body:   `{"roles":["admin",1]}`,
+			expected: []errorstack.Entry{
+				{Message: `Property "email" is missing`, Path: []any{"response", "body", "email"}},
+				{Message: "Value must be a string", Path: []any{"response", "body", "roles", 1}},
+			},
+		},
+		{
+			name:   "undocumented status",
+			status: http.StatusCreated,
+			body:   `{"email":"john@example.com"}`,
+			expected: []errorstack.Entry{
+				{Message: "Status is not supported", Path: []any{"response"}},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRouter(t, withTestDescription(t, ValidationModeObserve, ValidationModeEnforce))
+			handleTestProfile(r, tc.status, tc.body)
+
+			req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+			rw := httptest.NewRecorder()
+			r.bun.ServeHTTP(rw, req)
+
+			if tc.expected == nil {
+				assert.Equal(t, tc.status, rw.Code)
+				assert.Equal(t, "value", rw.Header().Get("X-Custom"))
+				assert.JSONEq(t, tc.body, rw.Body.String())
+				return
+			}
+
+			assert.Equal(t, http.StatusInternalServerError, rw.Code)
+			assert.Empty(t, rw.Header().Get("X-Custom"), "headers of the invalid response must be discarded")
+
+			assertEntries(t, rw.Body.Bytes(), errorstack.CodeInternalError, tc.expected)
+		})
+	}
+}
+
+func TestMiddlewareValidation_ObserveResponse(t *testing.T) {
+	body := `{"roles":["admin"]}`
+	r := newTestRouter(t, withTestDescription(t, ValidationModeObserve, ValidationModeObserve))
+	handleTestProfile(r, http.StatusOK, body)
+
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	rw := httptest.NewRecorder()
+	r.bun.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, body, rw.Body.String())
+}
@@ -50,13 +50,17 @@ func NewActivity[Input, Result any](name string, opts workflow.ActivityOptions)
 /*
 Register registers the implementation with the worker. The primary value of this
 wrapper is ensuring the function signature matches the defined Activity contract
-at compile time.
+at compile time. An *errorstack.Error returned by impl is converted into a
+Temporal ApplicationError honoring its retry hints.
 */
 func (d *activityDefinition[Input, Result]) Register(
 	worker Worker,
 	impl func(ctx context.Context, input Input) (Result, error),
 ) {
-	worker.registerActivity(impl, activity.RegisterOptions{
+	worker.registerActivity(func(ctx context.Context, input Input) (Result, error) {
+		result, err := impl(ctx, input)
+		return result, toApplicationError(err)
+	}, activity.RegisterOptions{
 		Name: d.name,
 	})
 }
@@ -126,13 +130,17 @@ func NewRepeatableActivity[Input, Config, Result any](name string, opts workflow
 /*
 Register registers the implementation with the worker. The primary value of this
 wrapper is ensuring the worker function signature matches the defined Activity
-contract at compile time.
+contract at compile time. An *errorstack.Error returned by impl is converted into
+a Temporal ApplicationError honoring its retry hints.
 */
 func (d *repeatableActivityDefinition[Input, Config, Result]) Register(
 	worker Worker,
 	impl func(ctx context.Context, input Input, config Config) (Result, error),
 ) {
-	worker.registerActivity(impl, activity.RegisterOptions{
+	worker.registerActivity(func(ctx context.Context, input Input, config Config) (Result, error) {
+		result, err := impl(ctx, input, config)
+		return result, toApplicationError(err)
+	}, activity.RegisterOptions{
 		Name: d.name,
 	})
 }
@@ -0,0 +1,58 @@
+package temporal
+
+import (
+	"errors"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+/*
+toApplicationError converts an *errorstack.Error returned by an activity and set
+with retry hints (errorstack.WithRetryable or errorstack.WithRetryAfter) into a
+Temporal ApplicationError, so they are honored by the retry policy of the
+activity:
+
+  - the type of the ApplicationError is the code of the error, so workflows can
+    match it with temporal.RetryPolicy.NonRetryableErrorTypes or
+    ApplicationError.Type;
+  - it is non-retryable if the error is set with
+    errorstack.WithRetryable(false);
+  - its next retry delay is the one set with errorstack.WithRetryAfter.
+
+The *errorstack.Error is set as the details of the ApplicationError, so
+workflows can restore it by passing a **errorstack.Error to
+ApplicationError.Details.
+
+Errors that are not *errorstack.Error, that are set without retry hints, or that
+already are an ApplicationError are returned as is. This keeps the type Temporal
+gives them, which NonRetryableErrorTypes are matched against.
+*/
+func toApplicationError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	var stack *errorstack.Error
+	if !errors.As(err, &stack) {
+		return err
+	}
+
+	retryable, known := stack.Retryable()
+	if !known {
+		return err
+	}
+
+	return temporal.NewApplicationErrorWithOptions(err.Error(), errorstack.CodeOf(stack), temporal.ApplicationErrorOptions{
+		NonRetryable:   !retryable,
+		NextRetryDelay: stack.RetryAfter(),
+		Cause:          stack.Unwrap(),
+		Details:        []any{stack},
+	})
+}
@@ -0,0 +1,106 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestToApplicationError(t *testing.T) {
+	cause := errors.New("card_declined")
+
+	testcases := []struct {
+		name                 string
+		err                  error
+		expectedType         string
+		expectedNonRetryable bool
+		expectedDelay        time.Duration
+	}{
+		{
+			name:         "retryable",
+			err:          errorstack.Wrap(cause, "Failed to charge card", errorstack.WithRetryable(true)),
+			expectedType: errorstack.CodeInternalError,
+		},
+		{
+			name:                 "non retryable",
+			err:                  errorstack.Wrap(cause, "Card was declined", errorstack.WithCode(errorstack.CodePaymentRequired), errorstack.WithRetryable(false)),
+			expectedType:         errorstack.CodePaymentRequired,
+			expectedNonRetryable: true,
+		},
+		{
+			name:          "retry after",
+			err:           errorstack.Wrap(cause, "Rate limit has been exceeded", errorstack.WithCode(errorstack.CodeTooManyRequests), errorstack.WithRetryAfter(time.Minute)),
+			expectedType:  errorstack.CodeTooManyRequests,
+			expectedDelay: time.Minute,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := toApplicationError(tc.err)
+
+			var appErr *temporal.ApplicationError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, tc.expectedType, appErr.Type())
+			assert.Equal(t, tc.expectedNonRetryable, appErr.NonRetryable())
+			assert.Equal(t, tc.expectedDelay, appErr.NextRetryDelay())
+			assert.ErrorIs(t, err, cause)
+
+			var details *errorstack.Error
+			require.NoError(t, appErr.Details(&details))
+			assert.Equal(t, tc.expectedType, errorstack.CodeOf(details))
+		})
+	}
+}
+
+func TestToApplicationError_Passthrough(t *testing.T) {
+	assert.NoError(t, toApplicationError(nil))
+
+	plain := errors.New("connection refused")
+	assert.Same(t, plain, toApplicationError(plain))
+
+	withoutHints := errorstack.Wrap(plain, "Failed to charge card")
+	assert.Same(t, withoutHints, toApplicationError(withoutHints))
+
+	appErr := temporal.NewNonRetryableApplicationError("Invalid input", "INVALID", nil)
+	assert.Same(t, appErr, toApplicationError(appErr))
+}
+
+/*
+registeringWorker is a Worker capturing the activities registered.
+*/
+type registeringWorker struct {
+	activities map[string]any
+}
+
+func (w *registeringWorker) registerWorkflow(any, workflow.RegisterOptions) {}
+
+func (w *registeringWorker) registerActivity(a any, opts activity.RegisterOptions) {
+	w.activities[opts.Name] = a
+}
+
+func TestActivity_Register_ConvertsErrors(t *testing.T) {
+	w := &registeringWorker{activities: map[string]any{}}
+
+	NewActivity[string, string]("charge", workflow.ActivityOptions{}).Register(w, func(ctx context.Context, input string) (string, error) {
+		return "", errorstack.New("Card was declined", errorstack.WithRetryable(false))
+	})
+
+	impl, ok := w.activities["charge"].(func(context.Context, string) (string, error))
+	require.True(t, ok)
+
+	_, err := impl(t.Context(), "card_123")
+
+	var appErr *temporal.ApplicationError
+	require.ErrorAs(t, err, &appErr)
+	assert.True(t, appErr.NonRetryable())
+}
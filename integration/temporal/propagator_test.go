@@ -2,12 +2,14 @@ package temporal
 
 import (
 	"context"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/mountayaapp/helix.go/event"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/noop"
 	commonpb "go.temporal.io/api/common/v1"
@@ -287,3 +289,42 @@ func TestPropagator_Inject_NoSpanInContext(t *testing.T) {
 	assert.Equal(t, input.Name, output.Name)
 	assert.Equal(t, input.UserID, output.UserID)
 }
+
+type testOrderExtension struct {
+	ID string `json:"id,omitempty" baggage:"id"`
+}
+
+/*
+TestMain registers the Event extensions once for the whole package, since the
+registry of extensions cannot be reset.
+*/
+func TestMain(m *testing.M) {
+	if err := event.RegisterExtension[testOrderExtension]("temporal_test_order"); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestPropagator_InjectDeserialize_Extension(t *testing.T) {
+	input := event.Event{Name: "checkout"}
+	require.NoError(t, event.SetExtension(&input, testOrderExtension{ID: "ord_123"}))
+
+	ctx := event.ContextWithEvent(t.Context(), input)
+	_, span := newNoopSpan(ctx)
+	ctx = context.WithValue(ctx, spanCtxKey, span)
+
+	headers := newTestHeaderStore()
+	p := &custompropagator{cachedCtx: context.Background()}
+	require.NoError(t, p.Inject(ctx, headers))
+
+	payload, ok := headers.Get(event.Key)
+	require.True(t, ok)
+
+	var output event.Event
+	require.NoError(t, converter.GetDefaultDataConverter().FromPayload(payload, &output))
+
+	order, ok := event.ExtensionOf[testOrderExtension](output)
+	assert.True(t, ok)
+	assert.Equal(t, "ord_123", order.ID)
+}
@@ -0,0 +1,193 @@
+package integration
+
+import (
+	"strings"
+
+	"github.com/mountayaapp/helix.go/event"
+)
+
+/*
+windowsVersions maps the Windows NT versions advertised in User-Agent headers to
+their marketing versions.
+*/
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+/*
+botTokens are lowercase substrings identifying crawlers and other automated
+clients in User-Agent headers.
+*/
+var botTokens = []string{"bot", "crawler", "spider", "slurp", "headless"}
+
+/*
+osFromUserAgent returns the OS details found in a User-Agent header value. It
+only recognizes the major platforms, and is deliberately lenient: any detail it
+cannot find is left empty.
+*/
+func osFromUserAgent(ua string) event.OS {
+	var os event.OS
+
+	switch {
+	case strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPad") || strings.Contains(ua, "iPod"):
+		os.Name = "iOS"
+		os.Version = versionAfter(ua, " OS ")
+
+	case strings.Contains(ua, "Android"):
+		os.Name = "Android"
+		os.Version = versionAfter(ua, "Android ")
+
+	case strings.Contains(ua, "Windows NT"):
+		os.Name = "Windows"
+		nt := versionAfter(ua, "Windows NT ")
+		if v, ok := windowsVersions[nt]; ok {
+			os.Version = v
+		} else {
+			os.Version = nt
+		}
+
+	case strings.Contains(ua, "Mac OS X"):
+		os.Name = "macOS"
+		os.Version = versionAfter(ua, "Mac OS X ")
+
+	case strings.Contains(ua, "CrOS"):
+		os.Name = "ChromeOS"
+
+	case strings.Contains(ua, "Linux"):
+		os.Name = "Linux"
+	}
+
+	switch {
+	case strings.Contains(ua, "x86_64"), strings.Contains(ua, "x64"), strings.Contains(ua, "Win64"), strings.Contains(ua, "WOW64"), strings.Contains(ua, "amd64"):
+		os.Arch = "amd64"
+	case strings.Contains(ua, "aarch64"), strings.Contains(ua, "arm64"):
+		os.Arch = "arm64"
+	case strings.Contains(ua, "i686"), strings.Contains(ua, "i386"):
+		os.Arch = "386"
+	}
+
+	return os
+}
+
+/*
+deviceFromUserAgent returns the device details found in a User-Agent header
+value. Type is one of "bot", "mobile", "tablet", or "desktop", and is left empty
+when the User-Agent is not recognized.
+*/
+func deviceFromUserAgent(ua string) event.Device {
+	var device event.Device
+	if ua == "" {
+		return device
+	}
+
+	lower := strings.ToLower(ua)
+	for _, token := range botTokens {
+		if strings.Contains(lower, token) {
+			device.Type = "bot"
+			return device
+		}
+	}
+
+	switch {
+	case strings.Contains(ua, "iPad"):
+		device.Type = "tablet"
+		device.Manufacturer = "Apple"
+		device.Model = "iPad"
+
+	case strings.Contains(ua, "iPhone"):
+		device.Type = "mobile"
+		device.Manufacturer = "Apple"
+		device.Model = "iPhone"
+
+	case strings.Contains(ua, "iPod"):
+		device.Type = "mobile"
+		device.Manufacturer = "Apple"
+		device.Model = "iPod"
+
+	case strings.Contains(ua, "Android"):
+		device.Type = "tablet"
+		if strings.Contains(ua, "Mobile") {
+			device.Type = "mobile"
+		}
+
+		device.Model = androidModel(ua)
+		switch {
+		case strings.HasPrefix(device.Model, "Pixel"):
+			device.Manufacturer = "Google"
+		case strings.HasPrefix(device.Model, "SM-"):
+			device.Manufacturer = "Samsung"
+		}
+
+	case strings.Contains(ua, "Macintosh"):
+		device.Type = "desktop"
+		device.Manufacturer = "Apple"
+		device.Model = "Mac"
+
+	case strings.Contains(ua, "Windows"), strings.Contains(ua, "CrOS"), strings.Contains(ua, "Linux"), strings.Contains(ua, "X11"):
+		device.Type = "desktop"
+	}
+
+	return device
+}
+
+/*
+androidModel returns the device model advertised by an Android User-Agent, which
+is the token following the Android version in the platform section:
+
+	Mozilla/5.0 (Linux; Android 14; Pixel 8 Build/AP1A.240405.002) …
+
+Browsers sending reduced User-Agents replace it with a literal "K", in which
+case an empty string is returned.
+*/
+func androidModel(ua string) string {
+	start := strings.Index(ua, "Android")
+	if start < 0 {
+		return ""
+	}
+
+	platform := ua[start:]
+	if end := strings.Index(platform, ")"); end >= 0 {
+		platform = platform[:end]
+	}
+
+	parts := strings.Split(platform, ";")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	model := strings.TrimSpace(parts[1])
+	if i := strings.Index(model, " Build/"); i >= 0 {
+		model = model[:i]
+	}
+
+	if model == "K" {
+		return ""
+	}
+
+	return model
+}
+
+/*
+versionAfter returns the version found right after marker in ua. Components may
+be separated by dots or underscores (as Apple platforms do), and are always
+returned separated by dots.
+*/
+func versionAfter(ua, marker string) string {
+	i := strings.Index(ua, marker)
+	if i < 0 {
+		return ""
+	}
+
+	rest := ua[i+len(marker):]
+	end := 0
+	for end < len(rest) && (rest[end] >= '0' && rest[end] <= '9' || rest[end] == '.' || rest[end] == '_') {
+		end++
+	}
+
+	return strings.ReplaceAll(strings.TrimRight(rest[:end], "._"), "_", ".")
+}
@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/mountayaapp/helix.go/event"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOSAndDeviceFromUserAgent(t *testing.T) {
+	testcases := []struct {
+		name   string
+		ua     string
+		os     event.OS
+		device event.Device
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:   "iPhone Safari",
+			ua:     "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			os:     event.OS{Name: "iOS", Version: "17.2.1"},
+			device: event.Device{Type: "mobile", Manufacturer: "Apple", Model: "iPhone"},
+		},
+		{
+			name:   "iPad Safari",
+			ua:     "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			os:     event.OS{Name: "iOS", Version: "16.6"},
+			device: event.Device{Type: "tablet", Manufacturer: "Apple", Model: "iPad"},
+		},
+		{
+			name:   "Android Chrome on Pixel",
+			ua:     "Mozilla/5.0 (Linux; Android 14; Pixel 8 Build/AP1A.240405.002) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36",
+			os:     event.OS{Name: "Android", Version: "14"},
+			device: event.Device{Type: "mobile", Manufacturer: "Google", Model: "Pixel 8"},
+		},
+		{
+			name:   "Android tablet on Samsung",
+			ua:     "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			os:     event.OS{Name: "Android", Version: "13"},
+			device: event.Device{Type: "tablet", Manufacturer: "Samsung", Model: "SM-X700"},
+		},
+		{
+			name:   "Android reduced User-Agent",
+			ua:     "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36",
+			os:     event.OS{Name: "Android", Version: "10"},
+			device: event.Device{Type: "mobile"},
+		},
+		{
+			name:   "Windows Chrome",
+			ua:     "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			os:     event.OS{Name: "Windows", Version: "10", Arch: "amd64"},
+			device: event.Device{Type: "desktop"},
+		},
+		{
+			name:   "macOS Safari",
+			ua:     "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+			os:     event.OS{Name: "macOS", Version: "10.15.7"},
+			device: event.Device{Type: "desktop", Manufacturer: "Apple", Model: "Mac"},
+		},
+		{
+			name:   "macOS Firefox",
+			ua:     "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0",
+			os:     event.OS{Name: "macOS", Version: "14.4"},
+			device: event.Device{Type: "desktop", Manufacturer: "Apple", Model: "Mac"},
+		},
+		{
+			name:   "Linux Firefox",
+			ua:     "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			os:     event.OS{Name: "Linux", Arch: "amd64"},
+			device: event.Device{Type: "desktop"},
+		},
+		{
+			name:   "ChromeOS",
+			ua:     "Mozilla/5.0 (X11; CrOS aarch64 15633.69.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+			os:     event.OS{Name: "ChromeOS", Arch: "arm64"},
+			device: event.Device{Type: "desktop"},
+		},
+		{
+			name:   "Googlebot",
+			ua:     "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			device: event.Device{Type: "bot"},
+		},
+		{
+			name: "unknown client",
+			ua:   "curl/8.5.0",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.os, osFromUserAgent(tc.ua))
+			assert.Equal(t, tc.device, deviceFromUserAgent(tc.ua))
+		})
+	}
+}
@@ -25,6 +25,13 @@ type Config struct {
 
 	// TLSConfig configures TLS to communicate with the Valkey server.
 	TLS integration.ConfigTLS `json:"tls"`
+
+	// Envelope enables propagating the Event and the W3C trace context through
+	// pub/sub and streams. Publish and XAdd embed them alongside the message, and
+	// Subscribe handlers and StreamEntry.Start restore them in the context with a
+	// consumer span linked to the producer's. Both producers and consumers of a
+	// channel or stream must enable it.
+	Envelope bool `json:"envelope"`
 }
 
 /*
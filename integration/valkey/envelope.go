@@ -0,0 +1,152 @@
+package valkey
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/mountayaapp/helix.go/event"
+	"github.com/mountayaapp/helix.go/telemetry/trace"
+
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+/*
+envelopeField is the reserved stream field holding the metadata of an entry
+appended when envelopes are enabled. It is removed from the fields returned by
+XRange.
+*/
+const envelopeField = "helix:metadata"
+
+/*
+traceContext is the W3C Trace Context propagator used to carry the producer's
+span context in envelopes. The Event is carried separately as a flat map, so the
+globally registered propagator — which may also carry the Baggage — is not used.
+*/
+var traceContext = propagation.TraceContext{}
+
+/*
+envelope is the shape of a message published on a channel when envelopes are
+enabled. Payload is the message as passed to Publish.
+*/
+type envelope struct {
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Payload  []byte            `json:"payload"`
+}
+
+/*
+metadataFromContext returns the metadata to embed in an envelope: the W3C trace
+context of the span found in ctx, and the flat-mapped Event found in ctx, if
+any. Returns nil if there is nothing to propagate.
+*/
+func metadataFromContext(ctx context.Context) map[string]string {
+	metadata := make(map[string]string)
+	traceContext.Inject(ctx, propagation.MapCarrier(metadata))
+
+	if e, ok := event.EventFromContext(ctx); ok {
+		for k, v := range event.ToFlatMap(e) {
+			metadata[k] = v
+		}
+	}
+
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	return metadata
+}
+
+/*
+startConsumer restores the Event found in metadata in a copy of ctx, and starts
+a consumer span linked to the producer's span found in metadata, if any. The
+span must be ended by the caller.
+*/
+func startConsumer(ctx context.Context, name string, metadata map[string]string) (context.Context, *trace.Span) {
+	for k := range metadata {
+		if strings.HasPrefix(k, event.Key+".") {
+			ctx = event.ContextWithEvent(ctx, event.FromFlatMap(metadata))
+			break
+		}
+	}
+
+	producer := traceContext.Extract(context.Background(), propagation.MapCarrier(metadata))
+	link := trace.Link{
+		SpanContext: oteltrace.SpanContextFromContext(producer),
+	}
+
+	return trace.StartWithLinks(ctx, trace.SpanKindConsumer, name, link)
+}
+
+/*
+encodeEnvelope wraps message in an envelope carrying the metadata found in ctx.
+*/
+func encodeEnvelope(ctx context.Context, message []byte) ([]byte, error) {
+	if message == nil {
+		message = []byte{}
+	}
+
+	return json.Marshal(envelope{
+		Metadata: metadataFromContext(ctx),
+		Payload:  message,
+	})
+}
+
+/*
+decodeEnvelope unwraps a message published with envelopes enabled. Returns false
+if message is not an envelope, such as a message published by a client not
+relying on this integration.
+*/
+func decodeEnvelope(message []byte) (envelope, bool) {
+	var env envelope
+	if err := json.Unmarshal(message, &env); err != nil || env.Payload == nil {
+		return envelope{}, false
+	}
+
+	return env, true
+}
+
+/*
+fieldsWithMetadata returns a copy of fields with the metadata found in ctx set in
+the reserved envelope field. fields is returned as is if there is nothing to
+propagate.
+*/
+func fieldsWithMetadata(ctx context.Context, fields map[string]string) (map[string]string, error) {
+	metadata := metadataFromContext(ctx)
+	if metadata == nil {
+		return fields, nil
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	withMetadata := make(map[string]string, len(fields)+1)
+	for k, v := range fields {
+		withMetadata[k] = v
+	}
+
+	withMetadata[envelopeField] = string(encoded)
+	return withMetadata, nil
+}
+
+/*
+metadataFromFields removes the reserved envelope field from fields and returns
+the metadata it holds, if any.
+*/
+func metadataFromFields(fields map[string]string) map[string]string {
+	encoded, ok := fields[envelopeField]
+	if !ok {
+		return nil
+	}
+
+	delete(fields, envelopeField)
+
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(encoded), &metadata); err != nil {
+		return nil
+	}
+
+	return metadata
+}
@@ -0,0 +1,193 @@
+package valkey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mountayaapp/helix.go/event"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+/*
+producerContext returns a context holding a sampled remote span context and the
+Event passed, as a producer would have when calling Publish or XAdd.
+*/
+func producerContext(t *testing.T, e event.Event) (context.Context, oteltrace.SpanContext) {
+	t.Helper()
+
+	sc := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    oteltrace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     oteltrace.SpanID{0x04, 0x05, 0x06},
+		TraceFlags: oteltrace.FlagsSampled,
+		Remote:     true,
+	})
+
+	ctx := oteltrace.ContextWithRemoteSpanContext(t.Context(), sc)
+	return event.ContextWithEvent(ctx, e), sc
+}
+
+/*
+inMemoryExporter registers a global tracer provider exporting to memory, and
+restores the previous one on cleanup.
+*/
+func inMemoryExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return exporter
+}
+
+func TestEnvelope_RoundTrip(t *testing.T) {
+	ctx, sc := producerContext(t, event.Event{Name: "subscribed", UserID: "user_123"})
+
+	encoded, err := encodeEnvelope(ctx, []byte(`{"hello":"world"}`))
+	require.NoError(t, err)
+
+	env, ok := decodeEnvelope(encoded)
+	require.True(t, ok)
+	assert.Equal(t, []byte(`{"hello":"world"}`), env.Payload)
+	assert.Equal(t, "subscribed", env.Metadata["event.name"])
+	assert.Equal(t, "user_123", env.Metadata["event.user_id"])
+	assert.Contains(t, env.Metadata["traceparent"], sc.TraceID().String())
+}
+
+func TestEnvelope_EmptyMessage(t *testing.T) {
+	encoded, err := encodeEnvelope(t.Context(), nil)
+	require.NoError(t, err)
+
+	env, ok := decodeEnvelope(encoded)
+	require.True(t, ok)
+	assert.Empty(t, env.Payload)
+	assert.Nil(t, env.Metadata)
+}
+
+func TestDecodeEnvelope_NotAnEnvelope(t *testing.T) {
+	testcases := []struct {
+		name    string
+		message []byte
+	}{
+		{name: "plain text", message: []byte("hello")},
+		{name: "JSON without payload", message: []byte(`{"hello":"world"}`)},
+		{name: "empty", message: []byte{}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, ok := decodeEnvelope(tc.message)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestStreamEntry_Metadata_RoundTrip(t *testing.T) {
+	ctx, sc := producerContext(t, event.Event{Name: "subscribed"})
+
+	fields := map[string]string{"order_id": "ord_123"}
+	withMetadata, err := fieldsWithMetadata(ctx, fields)
+	require.NoError(t, err)
+
+	assert.Len(t, fields, 1, "fields passed must not be mutated")
+	assert.Contains(t, withMetadata, envelopeField)
+
+	metadata := metadataFromFields(withMetadata)
+	assert.Equal(t, map[string]string{"order_id": "ord_123"}, withMetadata)
+	assert.Equal(t, "subscribed", metadata["event.name"])
+	assert.Contains(t, metadata["traceparent"], sc.TraceID().String())
+}
+
+func TestFieldsWithMetadata_NothingToPropagate(t *testing.T) {
+	fields := map[string]string{"order_id": "ord_123"}
+
+	withMetadata, err := fieldsWithMetadata(t.Context(), fields)
+	require.NoError(t, err)
+	assert.Equal(t, fields, withMetadata)
+	assert.Nil(t, metadataFromFields(withMetadata))
+}
+
+func TestStreamEntry_Start(t *testing.T) {
+	exporter := inMemoryExporter(t)
+	producer, sc := producerContext(t, event.Event{Name: "subscribed", UserID: "user_123"})
+
+	fields, err := fieldsWithMetadata(producer, map[string]string{"order_id": "ord_123"})
+	require.NoError(t, err)
+
+	entry := StreamEntry{Id: "1-0", Fields: fields}
+	entry.Metadata = metadataFromFields(entry.Fields)
+
+	ctx, span := entry.Start(t.Context())
+	span.End()
+
+	e, ok := event.EventFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "subscribed", e.Name)
+	assert.Equal(t, "user_123", e.UserID)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, spanConsume, spans[0].Name)
+	assert.Equal(t, oteltrace.SpanKindConsumer, spans[0].SpanKind)
+	require.Len(t, spans[0].Links, 1)
+	assert.Equal(t, sc.SpanID(), spans[0].Links[0].SpanContext.SpanID())
+}
+
+func TestStreamEntry_Start_WithoutMetadata(t *testing.T) {
+	exporter := inMemoryExporter(t)
+
+	ctx, span := StreamEntry{Id: "1-0"}.Start(t.Context())
+	span.End()
+
+	_, ok := event.EventFromContext(ctx)
+	assert.False(t, ok)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Empty(t, spans[0].Links)
+}
+
+func TestConnection_Receive(t *testing.T) {
+	exporter := inMemoryExporter(t)
+	producer, sc := producerContext(t, event.Event{Name: "subscribed"})
+	conn := &connection{config: &Config{Envelope: true}}
+
+	encoded, err := encodeEnvelope(producer, []byte("hello"))
+	require.NoError(t, err)
+
+	var received PubSubMessage
+	var e event.Event
+	conn.receive(t.Context(), PubSubMessage{Channel: "orders", Payload: encoded}, func(ctx context.Context, msg PubSubMessage) {
+		received = msg
+		e, _ = event.EventFromContext(ctx)
+	})
+
+	assert.Equal(t, "orders", received.Channel)
+	assert.Equal(t, []byte("hello"), received.Payload)
+	assert.Equal(t, "subscribed", e.Name)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, spanReceive, spans[0].Name)
+	require.Len(t, spans[0].Links, 1)
+	assert.Equal(t, sc.TraceID(), spans[0].Links[0].SpanContext.TraceID())
+}
+
+func TestConnection_Receive_NotAnEnvelope(t *testing.T) {
+	conn := &connection{config: &Config{Envelope: true}}
+
+	var received PubSubMessage
+	conn.receive(t.Context(), PubSubMessage{Channel: "orders", Payload: []byte("hello")}, func(_ context.Context, msg PubSubMessage) {
+		received = msg
+	})
+
+	assert.Equal(t, []byte("hello"), received.Payload)
+	assert.Nil(t, received.Metadata)
+}
@@ -0,0 +1,34 @@
+package valkey
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/valkey-io/valkey-go"
+)
+
+/*
+mapError wraps err with errorstack.Wrap, carrying the errorstack code matching
+the driver error: CodeNotFound for a Valkey nil reply (e.g. a missing key), and
+CodeGatewayTimeout when the context deadline is exceeded. Other errors carry
+CodeInternalError with the message passed. The driver error is preserved in the
+chain for errors.Is/As support. Returns nil if err is nil.
+*/
+func mapError(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	code := errorstack.CodeInternalError
+	switch {
+	case errors.Is(err, valkey.Nil):
+		code, message = errorstack.CodeNotFound, "Resource does not exist"
+
+	case errors.Is(err, context.DeadlineExceeded):
+		code, message = errorstack.CodeGatewayTimeout, "Command timed out"
+	}
+
+	return errorstack.Wrap(err, message, errorstack.WithCode(code))
+}
@@ -0,0 +1,55 @@
+package valkey
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valkey-io/valkey-go"
+)
+
+func TestMapError(t *testing.T) {
+	testcases := []struct {
+		name            string
+		err             error
+		expectedCode    string
+		expectedMessage string
+	}{
+		{
+			name:            "nil reply",
+			err:             valkey.Nil,
+			expectedCode:    errorstack.CodeNotFound,
+			expectedMessage: "Resource does not exist",
+		},
+		{
+			name:            "deadline exceeded",
+			err:             context.DeadlineExceeded,
+			expectedCode:    errorstack.CodeGatewayTimeout,
+			expectedMessage: "Command timed out",
+		},
+		{
+			name:            "unknown error",
+			err:             errors.New("connection refused"),
+			expectedCode:    errorstack.CodeInternalError,
+			expectedMessage: "Failed to get key",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := mapError(tc.err, "Failed to get key")
+
+			var stack *errorstack.Error
+			require.ErrorAs(t, err, &stack)
+			assert.Equal(t, tc.expectedCode, errorstack.CodeOf(err))
+			assert.Equal(t, tc.expectedMessage, stack.Entries[0].Message)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	assert.NoError(t, mapError(nil, "Failed to get key"))
+}
@@ -29,9 +29,12 @@ const (
 	spanDelete    = humanized + ": Delete"
 	spanPublish   = humanized + ": Publish"
 	spanSubscribe = humanized + ": Subscribe"
+	spanReceive   = humanized + ": Receive"
+	spanConsume   = humanized + ": Consume"
 	spanXAdd      = humanized + ": XAdd"
 	spanXRange    = humanized + ": XRange"
 	spanSetNX     = humanized + ": SetNX"
+	spanEval      = humanized + ": Eval"
 )
 
 /*
@@ -48,6 +51,10 @@ PubSubMessage is a single message received on a subscribed channel.
 type PubSubMessage struct {
 	Channel string `json:"channel"`
 	Payload []byte `json:"payload"`
+
+	// Metadata holds the trace context and flat-mapped Event embedded by the
+	// publisher when envelopes are enabled.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 /*
@@ -57,6 +64,24 @@ id and carrying the field/value pairs of the entry.
 type StreamEntry struct {
 	Id     string            `json:"id"`
 	Fields map[string]string `json:"fields"`
+
+	// Metadata holds the trace context and flat-mapped Event embedded by the
+	// producer when envelopes are enabled.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+/*
+Start returns a copy of ctx with the Event propagated by the producer of the
+entry, and starts a consumer span linked to the producer's span. Consumers of
+XRange call it for every entry they handle when envelopes are enabled:
+
+	ctx, span := entry.Start(ctx)
+	defer span.End()
+
+The span must be ended by the caller.
+*/
+func (entry StreamEntry) Start(ctx context.Context) (context.Context, *trace.Span) {
+	return startConsumer(ctx, spanConsume, entry.Metadata)
 }
 
 /*
@@ -80,6 +105,39 @@ type Valkey interface {
 	XRange(ctx context.Context, stream, start, end string) ([]StreamEntry, error)
 }
 
+/*
+ContextSubscriber is implemented by a Valkey able to subscribe to a channel with
+a handler receiving a context, which holds the Event propagated by the publisher.
+It is kept apart from Valkey so existing implementations of Valkey, such as
+mocks, still comply to it. Every Valkey returned by Connect implements it:
+
+	subscriber, ok := store.(valkey.ContextSubscriber)
+*/
+type ContextSubscriber interface {
+	SubscribeWithContext(ctx context.Context, channel string, handler func(context.Context, PubSubMessage)) error
+}
+
+/*
+Ensure *connection complies to the ContextSubscriber type.
+*/
+var _ ContextSubscriber = (*connection)(nil)
+
+/*
+Scripter is implemented by a Valkey able to run Lua scripts atomically on the
+server. It is kept apart from Valkey so existing implementations of Valkey, such
+as mocks, still comply to it. Every Valkey returned by Connect implements it:
+
+	scripter, ok := store.(valkey.Scripter)
+*/
+type Scripter interface {
+	Eval(ctx context.Context, script string, keys []string, args []string) (any, error)
+}
+
+/*
+Ensure *connection complies to the Scripter type.
+*/
+var _ Scripter = (*connection)(nil)
+
 /*
 connection represents the valkey integration. It respects the integration.Dependency
 and Valkey interfaces.
@@ -164,11 +222,12 @@ func (conn *connection) Exists(ctx context.Context, key string) (bool, error) {
 
 	setKeyAttributes(span, key)
 
-	return count > 0, err
+	return count > 0, mapError(err, "Failed to check key existence")
 }
 
 /*
-Get reads the value at key and returns its byte representation.
+Get reads the value at key and returns its byte representation. Returns an error
+carrying errorstack.CodeNotFound if the key does not exist.
 
 It automatically handles tracing and error recording.
 */
@@ -187,7 +246,7 @@ func (conn *connection) Get(ctx context.Context, key string, opts *OptionsGet) (
 
 	setKeyAttributes(span, key)
 
-	return value, err
+	return value, mapError(err, "Failed to get key")
 }
 
 /*
@@ -211,7 +270,7 @@ func (conn *connection) Set(ctx context.Context, key string, value []byte, opts
 
 	setKeyAttributes(span, key)
 
-	return err
+	return mapError(err, "Failed to set key")
 }
 
 /*
@@ -231,7 +290,7 @@ func (conn *connection) Increment(ctx context.Context, key string, increment int
 
 	setKeyAttributes(span, key)
 
-	return err
+	return mapError(err, "Failed to increment value")
 }
 
 /*
@@ -251,7 +310,7 @@ func (conn *connection) Decrement(ctx context.Context, key string, decrement int
 
 	setKeyAttributes(span, key)
 
-	return err
+	return mapError(err, "Failed to decrement value")
 }
 
 /*
@@ -279,7 +338,7 @@ func (conn *connection) Expire(ctx context.Context, key string, ttl time.Duratio
 
 	setKeyAttributes(span, key)
 
-	return err
+	return mapError(err, "Failed to set key expiration")
 }
 
 /*
@@ -311,7 +370,7 @@ func (conn *connection) Scan(ctx context.Context, pattern string) ([]string, err
 		}
 	}
 
-	return keys, scanErr
+	return keys, mapError(scanErr, "Failed to scan keys")
 }
 
 /*
@@ -331,7 +390,7 @@ func (conn *connection) MGet(ctx context.Context, keys []string) ([]Entry, error
 	sse, err := values.AsStrSlice()
 	if err != nil {
 		span.RecordError("failed to get multiple keys", err)
-		return nil, err
+		return nil, mapError(err, "Failed to get multiple keys")
 	}
 
 	result := make([]Entry, 0, len(keys))
@@ -373,7 +432,7 @@ func (conn *connection) Delete(ctx context.Context, keys []string) error {
 		span.RecordError("failed to delete keys", err)
 	}
 
-	return err
+	return mapError(err, "Failed to delete keys")
 }
 
 /*
@@ -401,14 +460,46 @@ func (conn *connection) SetNX(ctx context.Context, key string, value []byte, ttl
 		}
 
 		span.RecordError("failed to set key if not exists", err)
-		return false, err
+		return false, mapError(err, "Failed to set key if not exists")
 	}
 
 	return true, nil
 }
 
+/*
+Eval runs a Lua script atomically on the server, with the keys and arguments
+passed available as KEYS and ARGV. The script is sent by its SHA-1 digest with
+EVALSHA, and only sent in full with EVAL when the server does not have it cached
+yet. Returns the reply of the script converted to Go values: int64 for integers,
+string for strings, []any for arrays, and nil for a nil reply.
+
+It automatically handles tracing and error recording.
+*/
+func (conn *connection) Eval(ctx context.Context, script string, keys []string, args []string) (any, error) {
+	ctx, span := trace.Start(ctx, trace.SpanKindClient, spanEval)
+	defer span.End()
+
+	if len(keys) > 0 {
+		setKeyAttributes(span, keys[0])
+	}
+
+	reply, err := valkey.NewLuaScript(script).Exec(ctx, conn.client, keys, args).ToAny()
+	if err != nil {
+		if errors.Is(err, valkey.Nil) {
+			return nil, nil
+		}
+
+		span.RecordError("failed to evaluate script", err)
+		return nil, mapError(err, "Failed to evaluate script")
+	}
+
+	return reply, nil
+}
+
 /*
 Publish publishes a message to a channel for live fan-out to current subscribers.
+When envelopes are enabled, the message is wrapped in an envelope carrying the
+trace context and the Event found in ctx.
 
 It automatically handles tracing and error recording.
 */
@@ -416,13 +507,22 @@ func (conn *connection) Publish(ctx context.Context, channel string, message []b
 	ctx, span := trace.Start(ctx, trace.SpanKindClient, spanPublish)
 	defer span.End()
 
+	if conn.config.Envelope {
+		var err error
+		message, err = encodeEnvelope(ctx, message)
+		if err != nil {
+			span.RecordError("failed to encode envelope", err)
+			return err
+		}
+	}
+
 	cmd := conn.client.B().Publish().Channel(channel).Message(bytesToString(message))
 	err := conn.client.Do(ctx, cmd.Build()).Error()
 	if err != nil {
 		span.RecordError("failed to publish message", err)
 	}
 
-	return err
+	return mapError(err, "Failed to publish message")
 }
 
 /*
@@ -431,22 +531,49 @@ is cancelled. It blocks for the lifetime of the subscription on a dedicated
 connection, so callers run it in a goroutine and cancel ctx to tear it down. A
 cancellation is normal teardown and is not recorded as an error.
 
+When envelopes are enabled, messages are unwrapped before being passed to
+handler. Use SubscribeWithContext to receive the Event propagated by the
+publisher as well.
+
 It automatically handles tracing and error recording.
 */
 func (conn *connection) Subscribe(ctx context.Context, channel string, handler func(PubSubMessage)) error {
+	return conn.SubscribeWithContext(ctx, channel, func(_ context.Context, message PubSubMessage) {
+		handler(message)
+	})
+}
+
+/*
+SubscribeWithContext is like Subscribe, but invokes handler with a context as
+well. When envelopes are enabled, the context holds the Event propagated by the
+publisher, and a consumer span linked to the publisher's span which ends when
+handler returns. Messages that are not envelopes are passed as is. Otherwise,
+handler is invoked with the subscription's context.
+
+It automatically handles tracing and error recording.
+*/
+func (conn *connection) SubscribeWithContext(ctx context.Context, channel string, handler func(context.Context, PubSubMessage)) error {
+	base := ctx
 	ctx, span := trace.Start(ctx, trace.SpanKindClient, spanSubscribe)
 	defer span.End()
 
 	cmd := conn.client.B().Subscribe().Channel(channel).Build()
 	err := conn.client.Receive(ctx, cmd, func(msg valkey.PubSubMessage) {
-		handler(PubSubMessage{
+		message := PubSubMessage{
 			Channel: msg.Channel,
 			Payload: []byte(msg.Message),
-		})
+		}
+
+		if !conn.config.Envelope {
+			handler(ctx, message)
+			return
+		}
+
+		conn.receive(base, message, handler)
 	})
 	if err != nil && !errors.Is(err, context.Canceled) {
 		span.RecordError("failed to subscribe to channel", err)
-		return err
+		return mapError(err, "Failed to subscribe to channel")
 	}
 
 	return nil
@@ -455,7 +582,8 @@ func (conn *connection) Subscribe(ctx context.Context, channel string, handler f
 /*
 XAdd appends an entry of field/value pairs to a stream and returns the
 server-assigned entry id. When maxLen is greater than zero the stream is capped to
-approximately that many entries (MAXLEN ~).
+approximately that many entries (MAXLEN ~). When envelopes are enabled, the trace
+context and the Event found in ctx are embedded in a reserved field of the entry.
 
 It automatically handles tracing and error recording.
 */
@@ -465,6 +593,15 @@ func (conn *connection) XAdd(ctx context.Context, stream string, maxLen int64, f
 
 	setKeyAttributes(span, stream)
 
+	if conn.config.Envelope {
+		var err error
+		fields, err = fieldsWithMetadata(ctx, fields)
+		if err != nil {
+			span.RecordError("failed to encode envelope", err)
+			return "", err
+		}
+	}
+
 	var built valkey.Completed
 	if maxLen > 0 {
 		entry := conn.client.B().Xadd().Key(stream).Maxlen().Almost().Threshold(strconv.FormatInt(maxLen, 10)).Id("*").FieldValue()
@@ -487,12 +624,14 @@ func (conn *connection) XAdd(ctx context.Context, stream string, maxLen int64, f
 		span.RecordError("failed to append to stream", err)
 	}
 
-	return id, err
+	return id, mapError(err, "Failed to append to stream")
 }
 
 /*
 XRange returns stream entries within the inclusive id range [start, end]. Pass "-"
 and "+" for the full range, or an exclusive "(id" start to resume after a known id.
+When envelopes are enabled, the metadata embedded by XAdd is moved from the fields
+to the Metadata of each entry, and StreamEntry.Start restores it.
 
 It automatically handles tracing and error recording.
 */
@@ -506,16 +645,42 @@ func (conn *connection) XRange(ctx context.Context, stream, start, end string) (
 	entries, err := conn.client.Do(ctx, cmd.Build()).AsXRange()
 	if err != nil {
 		span.RecordError("failed to read stream range", err)
-		return nil, err
+		return nil, mapError(err, "Failed to read stream range")
 	}
 
 	result := make([]StreamEntry, 0, len(entries))
 	for _, entry := range entries {
-		result = append(result, StreamEntry{
+		se := StreamEntry{
 			Id:     entry.ID,
 			Fields: entry.FieldValues,
-		})
+		}
+
+		if conn.config.Envelope {
+			se.Metadata = metadataFromFields(se.Fields)
+		}
+
+		result = append(result, se)
 	}
 
 	return result, nil
 }
+
+/*
+receive unwraps the envelope of a message received on a subscribed channel, and
+invokes handler within a consumer span linked to the publisher's span. The span
+is started from ctx, the context passed to SubscribeWithContext, so messages are
+not traced as children of the long-lived subscription span.
+*/
+func (conn *connection) receive(ctx context.Context, message PubSubMessage, handler func(context.Context, PubSubMessage)) {
+	if env, ok := decodeEnvelope(message.Payload); ok {
+		message.Payload = env.Payload
+		message.Metadata = env.Metadata
+	}
+
+	ctx, span := startConsumer(ctx, spanReceive, message.Metadata)
+	defer span.End()
+
+	setKeyAttributes(span, message.Channel)
+
+	handler(ctx, message)
+}
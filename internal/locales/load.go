@@ -0,0 +1,233 @@
+package locales
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+builtin holds the translations of the default per-status messages shipped with
+helix.go, one JSON catalog per language. They are not added to the catalog
+unless requested with AddBuiltInLanguages.
+*/
+//go:embed catalogs/*.json
+var builtin embed.FS
+
+/*
+BuiltInLanguages are the languages for which helix.go ships a translation of the
+default per-status messages.
+*/
+var BuiltInLanguages = []language.Tag{
+	language.French,
+	language.German,
+	language.Spanish,
+	language.Italian,
+	language.Portuguese,
+	language.Japanese,
+}
+
+/*
+pluralForms maps the CLDR plural categories allowed in catalog files to their
+plural.Form.
+*/
+var pluralForms = map[string]plural.Form{
+	"zero":  plural.Zero,
+	"one":   plural.One,
+	"two":   plural.Two,
+	"few":   plural.Few,
+	"many":  plural.Many,
+	"other": plural.Other,
+}
+
+/*
+catalogFile is the content of a catalog file. Statuses are keyed by HTTP status
+code, messages by message ID. Example, in YAML:
+
+	statuses:
+	  400: Requête invalide
+	messages:
+	  must_be_set: Doit être renseigné
+	  items_selected:
+	    one: "{count} élément sélectionné"
+	    other: "{count} éléments sélectionnés"
+*/
+type catalogFile struct {
+	Statuses map[int]string         `json:"statuses" yaml:"statuses"`
+	Messages map[string]fileMessage `json:"messages" yaml:"messages"`
+}
+
+/*
+fileMessage is a message of a catalog file: either a single template, or its
+plural forms keyed by CLDR plural category.
+*/
+type fileMessage map[string]string
+
+/*
+UnmarshalJSON decodes a message given either as a string or as an object of
+plural forms.
+*/
+func (msg *fileMessage) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*msg = fileMessage{"other": single}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*map[string]string)(msg))
+}
+
+/*
+UnmarshalYAML decodes a message given either as a string or as a mapping of
+plural forms.
+*/
+func (msg *fileMessage) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*msg = fileMessage{"other": node.Value}
+		return nil
+	}
+
+	return node.Decode((*map[string]string)(msg))
+}
+
+/*
+LoadFS loads into the catalog every file of fsys matching the glob pattern
+passed, which works for both an embed.FS and a directory opened with os.DirFS.
+Each file holds the catalog of a single language, named after the file (e.g.
+"fr.yaml", "pt-BR.json"). Files are decoded as JSON or YAML depending on their
+extension.
+
+Messages may reference the parameters of an entry with {name} placeholders, and
+may have plural forms keyed by CLDR plural category (zero, one, two, few, many,
+other). The form is selected by the "count" parameter of the entry, and the
+"other" form is required.
+
+Files are all decoded before any of them is added to the catalog: if one of them
+is not valid, an error is returned and the catalog is left untouched. Returns an
+error as well if no file matches pattern.
+*/
+func LoadFS(fsys fs.FS, pattern string) error {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return errorstack.Wrap(err, "Failed to load locale catalogs", errorstack.WithPath("locales", pattern))
+	}
+
+	if len(names) == 0 {
+		return errorstack.New("Must match at least one locale catalog", errorstack.WithPath("locales", pattern))
+	}
+
+	type loaded struct {
+		lang      language.Tag
+		statuses  map[int]string
+		templates map[string]template
+	}
+
+	catalogs := make([]loaded, 0, len(names))
+	for _, name := range names {
+		lang, statuses, templates, err := decodeCatalog(fsys, name)
+		if err != nil {
+			return errorstack.Wrap(err, "Failed to load locale catalog", errorstack.WithPath("locales", name))
+		}
+
+		catalogs = append(catalogs, loaded{lang, statuses, templates})
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, c := range catalogs {
+		addOrEdit(c.lang, c.statuses, c.templates)
+	}
+
+	return nil
+}
+
+/*
+AddBuiltInLanguages adds to the catalog the translations of the default
+per-status messages shipped with helix.go for the languages passed, or for all
+BuiltInLanguages if none is passed. Translations previously set for the same
+statuses are replaced, so services overriding some of them should do so after
+calling this function. Returns an error if a language has no built-in
+translation.
+*/
+func AddBuiltInLanguages(langs ...language.Tag) error {
+	if len(langs) == 0 {
+		langs = BuiltInLanguages
+	}
+
+	for _, lang := range langs {
+		if err := LoadFS(builtin, "catalogs/"+lang.String()+".json"); err != nil {
+			return errorstack.New("Must be a language with built-in translations", errorstack.WithPath("locales", lang.String()))
+		}
+	}
+
+	return nil
+}
+
+/*
+decodeCatalog reads and decodes the catalog file name of fsys. The language of
+the catalog is parsed from the file name, without its extension.
+*/
+func decodeCatalog(fsys fs.FS, name string) (language.Tag, map[int]string, map[string]template, error) {
+	ext := path.Ext(name)
+	lang, err := language.Parse(strings.TrimSuffix(path.Base(name), ext))
+	if err != nil {
+		return language.Und, nil, nil, err
+	}
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return language.Und, nil, nil, err
+	}
+
+	var file catalogFile
+	switch ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&file)
+
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&file)
+
+	default:
+		err = fmt.Errorf("unsupported file extension %q", ext)
+	}
+
+	if err != nil {
+		return language.Und, nil, nil, err
+	}
+
+	templates := make(map[string]template, len(file.Messages))
+	for id, msg := range file.Messages {
+		tmpl := make(template, len(msg))
+		for category, text := range msg {
+			form, ok := pluralForms[category]
+			if !ok {
+				return language.Und, nil, nil, fmt.Errorf("message %q: unknown plural category %q", id, category)
+			}
+
+			tmpl[form] = text
+		}
+
+		if tmpl[plural.Other] == "" {
+			return language.Und, nil, nil, fmt.Errorf("message %q: missing \"other\" form", id)
+		}
+
+		templates[id] = tmpl
+	}
+
+	return lang, file.Statuses, templates, nil
+}
@@ -0,0 +1,243 @@
+package locales
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+/*
+restoreCatalogs snapshots the package-level catalogs and matcher, and restores
+them on cleanup. Loading catalogs registers any number of languages, which the
+French-only teardown helpers cannot remove.
+*/
+func restoreCatalogs(t *testing.T) {
+	t.Helper()
+
+	mu.RLock()
+	savedLanguages := slices.Clone(languages)
+	savedCatalog := make(map[language.Tag]map[int]string, len(catalog))
+	for lang, m := range catalog {
+		savedCatalog[lang] = maps.Clone(m)
+	}
+	savedMessages := make(map[language.Tag]map[string]template, len(messages))
+	for lang, m := range messages {
+		savedMessages[lang] = maps.Clone(m)
+	}
+	mu.RUnlock()
+
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		languages = savedLanguages
+		catalog = savedCatalog
+		messages = savedMessages
+		matcher = language.NewMatcher(languages)
+	})
+}
+
+/*
+requestWithLanguage returns a request sent with the Accept-Language header
+passed.
+*/
+func requestWithLanguage(lang string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", lang)
+	return req
+}
+
+func TestLoadFS(t *testing.T) {
+	restoreCatalogs(t)
+
+	fsys := fstest.MapFS{
+		"locales/fr.yaml": {Data: []byte(`
+statuses:
+  400: Requête invalide
+messages:
+  must_be_set: Doit être renseigné
+  items_selected:
+    one: "{count} élément sélectionné"
+    other: "{count} éléments sélectionnés"
+`)},
+		"locales/pt-BR.json": {Data: []byte(`{
+  "statuses": {"404": "Recurso não encontrado"},
+  "messages": {"must_be_set": "Deve ser definido"}
+}`)},
+		"locales/README.md": {Data: []byte("Not a catalog")},
+	}
+
+	require.NoError(t, LoadFS(fsys, "locales/*.yaml"))
+	require.NoError(t, LoadFS(fsys, "locales/*.json"))
+
+	assert.Equal(t, "Requête invalide", Message(requestWithLanguage("fr"), http.StatusBadRequest))
+	assert.Equal(t, "Recurso não encontrado", Message(requestWithLanguage("pt-BR"), http.StatusNotFound))
+
+	testcases := []struct {
+		name     string
+		lang     language.Tag
+		entry    errorstack.Entry
+		expected string
+	}{
+		{
+			name:     "single template",
+			lang:     language.French,
+			entry:    errorstack.Entry{Message: "Must be set", MessageID: "must_be_set"},
+			expected: "Doit être renseigné",
+		},
+		{
+			name:     "plural one",
+			lang:     language.French,
+			entry:    errorstack.Entry{Message: "1 item selected", MessageID: "items_selected", Params: map[string]any{"count": 1}},
+			expected: "1 élément sélectionné",
+		},
+		{
+			name:     "plural other",
+			lang:     language.French,
+			entry:    errorstack.Entry{Message: "3 items selected", MessageID: "items_selected", Params: map[string]any{"count": 3}},
+			expected: "3 éléments sélectionnés",
+		},
+		{
+			name:     "plural with float count",
+			lang:     language.French,
+			entry:    errorstack.Entry{Message: "2.5 items selected", MessageID: "items_selected", Params: map[string]any{"count": 2.5}},
+			expected: "2.5 éléments sélectionnés",
+		},
+		{
+			name:     "plural without count",
+			lang:     language.French,
+			entry:    errorstack.Entry{Message: "Items selected", MessageID: "items_selected"},
+			expected: "{count} éléments sélectionnés",
+		},
+		{
+			name:     "regional language",
+			lang:     language.BrazilianPortuguese,
+			entry:    errorstack.Entry{Message: "Must be set", MessageID: "must_be_set"},
+			expected: "Deve ser definido",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			localized := Localize(tc.lang, []errorstack.Entry{tc.entry})
+			assert.Equal(t, tc.expected, localized[0].Message)
+		})
+	}
+}
+
+func TestLoadFS_Errors(t *testing.T) {
+	testcases := []struct {
+		name    string
+		fsys    fstest.MapFS
+		pattern string
+	}{
+		{
+			name:    "no file matching",
+			fsys:    fstest.MapFS{},
+			pattern: "*.yaml",
+		},
+		{
+			name:    "invalid pattern",
+			fsys:    fstest.MapFS{},
+			pattern: "[",
+		},
+		{
+			name:    "invalid language",
+			fsys:    fstest.MapFS{"not a language.json": {Data: []byte(`{}`)}},
+			pattern: "*.json",
+		},
+		{
+			name:    "unsupported extension",
+			fsys:    fstest.MapFS{"fr.toml": {Data: []byte(``)}},
+			pattern: "*.toml",
+		},
+		{
+			name:    "unknown field",
+			fsys:    fstest.MapFS{"fr.json": {Data: []byte(`{"status":{"400":"Requête invalide"}}`)}},
+			pattern: "*.json",
+		},
+		{
+			name:    "unknown plural category",
+			fsys:    fstest.MapFS{"fr.yaml": {Data: []byte("messages:\n  items:\n    several: x\n    other: y\n")}},
+			pattern: "*.yaml",
+		},
+		{
+			name:    "missing other plural form",
+			fsys:    fstest.MapFS{"fr.json": {Data: []byte(`{"messages":{"items":{"one":"x"}}}`)}},
+			pattern: "*.json",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			restoreCatalogs(t)
+			before := len(languages)
+
+			err := LoadFS(tc.fsys, tc.pattern)
+
+			var stack *errorstack.Error
+			require.ErrorAs(t, err, &stack)
+			assert.Equal(t, before, len(languages), "catalog must be left untouched")
+		})
+	}
+}
+
+func TestLoadFS_AllOrNothing(t *testing.T) {
+	restoreCatalogs(t)
+
+	fsys := fstest.MapFS{
+		"de.json": {Data: []byte(`{"statuses":{"400":"Ungültige Anfrage"}}`)},
+		"fr.json": {Data: []byte(`{"statuses":`)},
+	}
+
+	require.Error(t, LoadFS(fsys, "*.json"))
+	assert.Equal(t, "Request is invalid", Message(requestWithLanguage("de"), http.StatusBadRequest))
+}
+
+func TestAddBuiltInLanguages(t *testing.T) {
+	restoreCatalogs(t)
+
+	require.NoError(t, AddBuiltInLanguages(language.French, language.Japanese))
+
+	assert.Equal(t, "La ressource n'existe pas", Message(requestWithLanguage("fr-FR"), http.StatusNotFound))
+	assert.Equal(t, "リソースが存在しません", Message(requestWithLanguage("ja"), http.StatusNotFound))
+	assert.Equal(t, "Resource does not exist", Message(requestWithLanguage("de"), http.StatusNotFound))
+}
+
+func TestAddBuiltInLanguages_Unavailable(t *testing.T) {
+	restoreCatalogs(t)
+
+	err := AddBuiltInLanguages(language.Korean)
+
+	var stack *errorstack.Error
+	require.ErrorAs(t, err, &stack)
+	assert.Equal(t, []any{"locales", "ko"}, stack.Entries[0].Path)
+}
+
+/*
+TestBuiltInLanguages_CoverDefaults ensures every built-in catalog translates all
+the default per-status messages, and nothing else.
+*/
+func TestBuiltInLanguages_CoverDefaults(t *testing.T) {
+	for _, lang := range BuiltInLanguages {
+		t.Run(lang.String(), func(t *testing.T) {
+			_, statuses, templates, err := decodeCatalog(builtin, "catalogs/"+lang.String()+".json")
+			require.NoError(t, err)
+
+			assert.ElementsMatch(t, slices.Collect(maps.Keys(defaults)), slices.Collect(maps.Keys(statuses)))
+			assert.Empty(t, templates)
+			for status, msg := range statuses {
+				assert.NotEmpty(t, msg, "empty translation for status %d", status)
+				assert.NotEqual(t, defaults[status], msg, "untranslated message for status %d", status)
+			}
+		})
+	}
+}
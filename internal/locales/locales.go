@@ -4,6 +4,9 @@ both the REST and GraphQL integrations. Both transports are thin wrappers
 around the same backing state, so a service that adds or edits a locale via
 either rest.AddOrEditLanguage or graphql.AddOrEditLanguage sees the change
 applied across both error paths — no silent divergence between the two.
+
+The same holds for the message-ID catalog used to localize the entries built
+with errorstack.WithMessageID.
 */
 package locales
 
@@ -31,6 +34,7 @@ var defaults = map[int]string{
 	http.StatusNotFound:              "Resource does not exist",
 	http.StatusMethodNotAllowed:      "Method is not allowed for this resource",
 	http.StatusConflict:              "Resource conflicts with current state",
+	http.StatusPreconditionFailed:    "Precondition is not met",
 	http.StatusRequestEntityTooLarge: "Payload exceeds size limit",
 	http.StatusTooManyRequests:       "Rate limit has been exceeded",
 	http.StatusInternalServerError:   "Internal server error",
@@ -66,13 +70,7 @@ func AddOrEditLanguage(lang language.Tag, locales map[int]string) {
 	mu.Lock()
 	defer mu.Unlock()
 
-	if _, exists := catalog[lang]; !exists {
-		catalog[lang] = make(map[int]string)
-		languages = append(languages, lang)
-	}
-
-	maps.Copy(catalog[lang], locales)
-	matcher = language.NewMatcher(languages)
+	addOrEdit(lang, locales, nil)
 }
 
 /*
@@ -96,7 +94,11 @@ func GetPreferredLanguage(req *http.Request) language.Tag {
 		header = req.Header.Get("Accept-Language")
 	}
 
+	// The matcher annotates the tag matched with the region of the client when
+	// it differs from the supported one (e.g. "fr-u-rg-frzzzz" for "fr-FR").
+	// Drop it so the tag returned is always a key of the catalogs.
 	tag, _ := language.MatchStrings(m, cookieValue, header)
+	tag, _ = tag.SetTypeForKey("rg", "")
 	return tag
 }
 
@@ -109,8 +111,15 @@ for handling that (typically by passing the status through HTTPStatusToCode
 to keep the wire shape valid).
 */
 func Message(req *http.Request, status int) string {
-	lang := GetPreferredLanguage(req)
+	return MessageFor(GetPreferredLanguage(req), status)
+}
 
+/*
+MessageFor returns the localized message for the given (language, status) pair,
+for code only having access to the language, such as the one stored in a context
+by Middleware. Falls back the same way Message does.
+*/
+func MessageFor(lang language.Tag, status int) string {
 	mu.RLock()
 	defer mu.RUnlock()
 
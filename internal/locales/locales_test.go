@@ -108,6 +108,20 @@ func TestGetPreferredLanguage_WithAddedLanguage(t *testing.T) {
 	assert.Equal(t, language.French, GetPreferredLanguage(req))
 }
 
+func TestGetPreferredLanguage_RegionalVariant(t *testing.T) {
+	defer withFrenchTearDown(t)()
+
+	AddOrEditLanguage(language.French, map[int]string{
+		http.StatusBadRequest: "Requête invalide",
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "fr-FR,fr;q=0.9")
+
+	assert.Equal(t, language.French, GetPreferredLanguage(req))
+	assert.Equal(t, "Requête invalide", Message(req, http.StatusBadRequest))
+}
+
 func TestGetPreferredLanguage_CookieAndHeader(t *testing.T) {
 	defer withFrenchTearDown(t)()
 
@@ -243,3 +257,15 @@ func TestMessage_FallsBackToEnglishWhenLanguageMissing(t *testing.T) {
 
 	assert.Equal(t, "Authentication is required", Message(req, http.StatusUnauthorized))
 }
+
+func TestMessageFor(t *testing.T) {
+	defer withFrenchTearDown(t)()
+
+	AddOrEditLanguage(language.French, map[int]string{
+		http.StatusInternalServerError: "Erreur interne du serveur",
+	})
+
+	assert.Equal(t, "Erreur interne du serveur", MessageFor(language.French, http.StatusInternalServerError))
+	assert.Equal(t, "Authentication is required", MessageFor(language.French, http.StatusUnauthorized))
+	assert.Equal(t, Message(nil, http.StatusInternalServerError), MessageFor(language.English, http.StatusInternalServerError))
+}
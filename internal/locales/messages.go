@@ -0,0 +1,215 @@
+package locales
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+/*
+messages is the message-ID → template catalog used to localize the entries
+carrying an errorstack.Entry.MessageID. It is guarded by the same mu as the
+per-status catalog, and shares its matcher: a language added via either
+AddOrEditLanguage or AddOrEditMessages is a candidate for both.
+
+There are no English defaults: the Message of an entry is its English fallback.
+Registering English templates is still allowed, to override them.
+*/
+var messages = map[language.Tag]map[string]template{}
+
+/*
+template holds the plural forms of a message, keyed by CLDR plural category.
+Messages without plural forms only have the plural.Other form, which is also the
+fallback when the form selected for a count is missing.
+*/
+type template map[plural.Form]string
+
+/*
+pluralParam is the parameter of an entry selecting the plural form of its
+message.
+*/
+const pluralParam = "count"
+
+/*
+AddOrEditMessages adds or replaces message templates for a given language, keyed
+by message ID. Previously-set templates for the same language are preserved
+unless the new catalog overrides them. Templates may reference the parameters of
+an entry with {name} placeholders. Plural forms can only be set by loading a
+catalog with LoadFS.
+*/
+func AddOrEditMessages(lang language.Tag, templates map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	m := make(map[string]template, len(templates))
+	for id, tmpl := range templates {
+		m[id] = template{plural.Other: tmpl}
+	}
+
+	addOrEdit(lang, nil, m)
+}
+
+/*
+addOrEdit adds or replaces the per-status messages and message templates for a
+given language, registering the language in the matcher if it is new. The
+caller must hold mu.
+*/
+func addOrEdit(lang language.Tag, statuses map[int]string, templates map[string]template) {
+	if _, exists := catalog[lang]; !exists {
+		catalog[lang] = make(map[int]string)
+		languages = append(languages, lang)
+		matcher = language.NewMatcher(languages)
+	}
+
+	maps.Copy(catalog[lang], statuses)
+
+	if len(templates) == 0 {
+		return
+	}
+
+	if _, exists := messages[lang]; !exists {
+		messages[lang] = make(map[string]template)
+	}
+
+	maps.Copy(messages[lang], templates)
+}
+
+/*
+Localize returns a copy of entries where the message of every entry carrying a
+MessageID is replaced by its template for lang, with placeholders substituted
+by the entry's parameters. Entries without a MessageID, or whose MessageID has
+no template for lang, keep their message. When lang has no template but English
+does, the English template is only used if the entry has no message of its own.
+The input slice is never mutated.
+*/
+func Localize(lang language.Tag, entries []errorstack.Entry) []errorstack.Entry {
+	if len(entries) == 0 {
+		return entries
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	out := make([]errorstack.Entry, len(entries))
+	for i, entry := range entries {
+		if entry.MessageID != "" {
+			tmplLang := lang
+			tmpl, ok := messages[lang][entry.MessageID]
+			if !ok && entry.Message == "" {
+				tmplLang = language.English
+				tmpl, ok = messages[language.English][entry.MessageID]
+			}
+
+			if msg := tmpl.format(tmplLang, entry.Params); ok && msg != "" {
+				entry.Message = msg
+			}
+		}
+
+		out[i] = entry
+	}
+
+	return out
+}
+
+/*
+format returns the message of the plural form selected by the "count" parameter
+for lang, with its placeholders substituted. The plural.Other form is used when
+there is no "count" parameter or when the selected form is missing.
+*/
+func (tmpl template) format(lang language.Tag, params map[string]any) string {
+	msg := tmpl[plural.Other]
+	if count, ok := params[pluralParam]; ok && len(tmpl) > 1 {
+		if form, ok := pluralForm(lang, count); ok && tmpl[form] != "" {
+			msg = tmpl[form]
+		}
+	}
+
+	return expand(msg, params)
+}
+
+/*
+pluralForm returns the CLDR plural category of count for lang. count must be an
+integer or a floating-point number; returns false otherwise.
+*/
+func pluralForm(lang language.Tag, count any) (plural.Form, bool) {
+	var digits string
+	v := reflect.ValueOf(count)
+	switch {
+	case v.CanInt():
+		digits = strconv.FormatInt(v.Int(), 10)
+	case v.CanUint():
+		digits = strconv.FormatUint(v.Uint(), 10)
+	case v.CanFloat():
+		digits = strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return plural.Other, false
+	}
+
+	// Operands are defined by https://unicode.org/reports/tr35/tr35-numbers.html#Operands.
+	// FormatFloat never writes trailing zeros, so visible fraction digits with
+	// and without trailing zeros are the same.
+	integer, fraction, _ := strings.Cut(strings.TrimPrefix(digits, "-"), ".")
+	i, err := strconv.Atoi(integer)
+	if err != nil {
+		return plural.Other, false
+	}
+
+	f, _ := strconv.Atoi(fraction)
+	return plural.Cardinal.MatchPlural(lang, i, len(fraction), len(fraction), f, f), true
+}
+
+/*
+expand substitutes every {name} placeholder of tmpl with the matching parameter.
+Placeholders without a matching parameter are left untouched, so a missing
+parameter is visible rather than silently dropped.
+*/
+func expand(tmpl string, params map[string]any) string {
+	if len(params) == 0 || !strings.Contains(tmpl, "{") {
+		return tmpl
+	}
+
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+/*
+contextKey is the key of the language stored in a context by Middleware.
+*/
+type contextKey struct{}
+
+/*
+Middleware stores the preferred language of every request in its context, so
+code only having access to the context (such as a GraphQL error presenter) can
+localize messages with LanguageFromContext.
+*/
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), contextKey{}, GetPreferredLanguage(req))
+		next.ServeHTTP(rw, req.WithContext(ctx))
+	})
+}
+
+/*
+LanguageFromContext returns the language stored in ctx by Middleware. Falls back
+to English when none is found.
+*/
+func LanguageFromContext(ctx context.Context) language.Tag {
+	if lang, ok := ctx.Value(contextKey{}).(language.Tag); ok {
+		return lang
+	}
+
+	return language.English
+}
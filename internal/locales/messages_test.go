@@ -0,0 +1,163 @@
+package locales
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+/*
+withMessagesTearDown returns a cleanup function removing French from both the
+per-status and message-ID catalogs, and English templates from the latter.
+*/
+func withMessagesTearDown(t *testing.T) func() {
+	t.Helper()
+	frenchTearDown := withFrenchTearDown(t)
+
+	return func() {
+		frenchTearDown()
+
+		mu.Lock()
+		defer mu.Unlock()
+		delete(messages, language.French)
+		delete(messages, language.English)
+	}
+}
+
+func TestAddOrEditMessages_RegistersLanguage(t *testing.T) {
+	defer withMessagesTearDown(t)()
+
+	AddOrEditMessages(language.French, map[string]string{
+		"must_be_set": "Doit être renseigné",
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "fr")
+
+	assert.Equal(t, language.French, GetPreferredLanguage(req))
+	assert.Equal(t, "Authentication is required", Message(req, http.StatusUnauthorized))
+}
+
+func TestAddOrEditMessages_PartialUpdate(t *testing.T) {
+	defer withMessagesTearDown(t)()
+
+	AddOrEditMessages(language.French, map[string]string{"must_be_set": "Doit être renseigné"})
+	AddOrEditMessages(language.French, map[string]string{"min_length": "Doit contenir au moins {min} caractères"})
+
+	mu.RLock()
+	defer mu.RUnlock()
+	assert.Equal(t, "Doit être renseigné", messages[language.French]["must_be_set"][plural.Other])
+	assert.Equal(t, "Doit contenir au moins {min} caractères", messages[language.French]["min_length"][plural.Other])
+}
+
+func TestLocalize(t *testing.T) {
+	defer withMessagesTearDown(t)()
+
+	AddOrEditMessages(language.French, map[string]string{
+		"must_be_set": "Doit être renseigné",
+		"min_length":  "Doit contenir au moins {min} caractères",
+	})
+
+	AddOrEditMessages(language.English, map[string]string{
+		"max_length": "Must be at most {max} characters long",
+	})
+
+	testcases := []struct {
+		name     string
+		lang     language.Tag
+		entry    errorstack.Entry
+		expected string
+	}{
+		{
+			name:     "translated",
+			lang:     language.French,
+			entry:    errorstack.Entry{Message: "Must be set", MessageID: "must_be_set"},
+			expected: "Doit être renseigné",
+		},
+		{
+			name:     "translated with params",
+			lang:     language.French,
+			entry:    errorstack.Entry{Message: "Must be at least 8 characters long", MessageID: "min_length", Params: map[string]any{"min": 8}},
+			expected: "Doit contenir au moins 8 caractères",
+		},
+		{
+			name:     "missing param is left untouched",
+			lang:     language.French,
+			entry:    errorstack.Entry{Message: "Must be at least 8 characters long", MessageID: "min_length"},
+			expected: "Doit contenir au moins {min} caractères",
+		},
+		{
+			name:     "untranslated ID keeps the message",
+			lang:     language.French,
+			entry:    errorstack.Entry{Message: "Must be unique", MessageID: "must_be_unique"},
+			expected: "Must be unique",
+		},
+		{
+			name:     "without ID keeps the message",
+			lang:     language.French,
+			entry:    errorstack.Entry{Message: "Must be set"},
+			expected: "Must be set",
+		},
+		{
+			name:     "unsupported language keeps the message",
+			lang:     language.German,
+			entry:    errorstack.Entry{Message: "Must be set", MessageID: "must_be_set"},
+			expected: "Must be set",
+		},
+		{
+			name:     "English template used when message is empty",
+			lang:     language.French,
+			entry:    errorstack.Entry{MessageID: "max_length", Params: map[string]any{"max": 64}},
+			expected: "Must be at most 64 characters long",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			localized := Localize(tc.lang, []errorstack.Entry{tc.entry})
+
+			require.Len(t, localized, 1)
+			assert.Equal(t, tc.expected, localized[0].Message)
+		})
+	}
+}
+
+func TestLocalize_DoesNotMutateInput(t *testing.T) {
+	defer withMessagesTearDown(t)()
+
+	AddOrEditMessages(language.French, map[string]string{"must_be_set": "Doit être renseigné"})
+
+	entries := []errorstack.Entry{{Message: "Must be set", MessageID: "must_be_set"}}
+	localized := Localize(language.French, entries)
+
+	assert.Equal(t, "Doit être renseigné", localized[0].Message)
+	assert.Equal(t, "Must be set", entries[0].Message)
+}
+
+func TestMiddleware_LanguageFromContext(t *testing.T) {
+	defer withMessagesTearDown(t)()
+
+	AddOrEditMessages(language.French, map[string]string{"must_be_set": "Doit être renseigné"})
+
+	var lang language.Tag
+	h := Middleware(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		lang = LanguageFromContext(req.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "fr-FR,fr;q=0.9")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, language.French, lang)
+}
+
+func TestLanguageFromContext_DefaultsToEnglish(t *testing.T) {
+	assert.Equal(t, language.English, LanguageFromContext(t.Context()))
+}
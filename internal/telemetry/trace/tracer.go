@@ -32,38 +32,57 @@ If the context contains a Span then the newly-created Span will be a child of
 that Span, otherwise it will be a root Span.
 
 Any Span that is created must also be ended. This is the responsibility of the
-caller.
+caller. Options passed are applied after the SpanKind.
 */
-func (t *Tracer) Start(ctx context.Context, kind oteltrace.SpanKind, name string) (context.Context, oteltrace.Span) {
-	var attrs []attribute.KeyValue
-
-	if e, ok := event.EventFromContext(ctx); ok {
-		if mapped := event.ToFlatMap(e); len(mapped) > 0 {
-			members := make([]baggage.Member, 0, len(mapped))
-			attrs = make([]attribute.KeyValue, 0, len(mapped))
-
-			for k, v := range mapped {
-				attrs = append(attrs, attribute.String(k, v))
-				if m, err := baggage.NewMember(flatMapKeyToBaggageKey(k), v); err == nil {
-					members = append(members, m)
-				}
-			}
-
-			if len(members) > 0 {
-				if b, err := baggage.New(members...); err == nil {
-					ctx = baggage.ContextWithBaggage(ctx, b)
-				}
-			}
-		}
-	}
+func (t *Tracer) Start(ctx context.Context, kind oteltrace.SpanKind, name string, opts ...oteltrace.SpanStartOption) (context.Context, oteltrace.Span) {
+	ctx, attrs := ContextWithEventBaggage(ctx)
 
-	ctx, span := t.tracer.Start(ctx, name, oteltrace.WithSpanKind(kind))
+	ctx, span := t.tracer.Start(ctx, name, append([]oteltrace.SpanStartOption{oteltrace.WithSpanKind(kind)}, opts...)...)
 	if len(attrs) > 0 {
 		span.SetAttributes(attrs...)
 	}
 	return ctx, span
 }
 
+/*
+ContextWithEventBaggage returns a copy of the context with the Event found in it,
+if any, serialized as a Baggage. It also returns the flat-mapped Event as span
+attributes so callers can record it without walking the Event a second time.
+
+The context is returned as is when no Event is found. Integrations propagating
+context across process boundaries (e.g. outbound HTTP requests) use this so the
+Event travels in the same shape as spans created via Tracer.Start.
+*/
+func ContextWithEventBaggage(ctx context.Context) (context.Context, []attribute.KeyValue) {
+	e, ok := event.EventFromContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+
+	mapped := event.ToFlatMap(e)
+	if len(mapped) == 0 {
+		return ctx, nil
+	}
+
+	members := make([]baggage.Member, 0, len(mapped))
+	attrs := make([]attribute.KeyValue, 0, len(mapped))
+
+	for k, v := range mapped {
+		attrs = append(attrs, attribute.String(k, v))
+		if m, err := baggage.NewMember(flatMapKeyToBaggageKey(k), v); err == nil {
+			members = append(members, m)
+		}
+	}
+
+	if len(members) > 0 {
+		if b, err := baggage.New(members...); err == nil {
+			ctx = baggage.ContextWithBaggage(ctx, b)
+		}
+	}
+
+	return ctx, attrs
+}
+
 /*
 Provider returns the underlying OpenTelemetry TracerProvider. Integrations that
 need to wire OTEL-native interceptors (e.g., Temporal) use this.
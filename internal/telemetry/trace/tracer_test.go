@@ -3,8 +3,12 @@ package trace
 import (
 	"testing"
 
+	"github.com/mountayaapp/helix.go/event"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/sdk/resource"
 	oteltrace "go.opentelemetry.io/otel/trace"
 )
@@ -133,3 +137,32 @@ func TestNewTracer_ExporterNone_SpansAreNoop(t *testing.T) {
 
 	assert.NoError(t, tr.Shutdown(t.Context()))
 }
+
+func TestContextWithEventBaggage(t *testing.T) {
+	t.Run("NoEvent", func(t *testing.T) {
+		ctx, attrs := ContextWithEventBaggage(t.Context())
+
+		assert.Empty(t, baggage.FromContext(ctx).Members())
+		assert.Nil(t, attrs)
+	})
+
+	t.Run("WithEvent", func(t *testing.T) {
+		e := event.Event{
+			Name:   "subscribed",
+			UserID: "user_123",
+			App:    event.App{Name: "my-app"},
+		}
+
+		ctx, attrs := ContextWithEventBaggage(event.ContextWithEvent(t.Context(), e))
+
+		b := baggage.FromContext(ctx)
+		assert.Equal(t, "subscribed", b.Member("event.name").Value())
+		assert.Equal(t, "user_123", b.Member("event.user_id").Value())
+		assert.Equal(t, "my-app", b.Member("event.app.name").Value())
+		assert.ElementsMatch(t, []attribute.KeyValue{
+			attribute.String("event.name", "subscribed"),
+			attribute.String("event.user_id", "user_123"),
+			attribute.String("event.app.name", "my-app"),
+		}, attrs)
+	})
+}
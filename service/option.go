@@ -18,6 +18,7 @@ type serviceConfig struct {
 	cloud           *cloud
 	shutdownTimeout time.Duration
 	signals         []os.Signal
+	stackTraces     *bool
 }
 
 /*
@@ -39,3 +40,15 @@ func WithSignals(signals ...os.Signal) Option {
 		cfg.signals = signals
 	}
 }
+
+/*
+WithStackTraces enables or disables the capture of stack traces by errorstack.New
+and errorstack.Wrap, overriding the ERRORSTACK_STACKTRACE environment variable.
+Stack traces are never sent to clients: they are recorded on span exceptions
+and logged along errors.
+*/
+func WithStackTraces(enabled bool) Option {
+	return func(cfg *serviceConfig) {
+		cfg.stackTraces = &enabled
+	}
+}
@@ -82,6 +82,10 @@ func New(opts ...Option) (*Service, error) {
 			opt(cfg)
 		}
 
+		if cfg.stackTraces != nil {
+			errorstack.EnableStackTraces(*cfg.stackTraces)
+		}
+
 		otelDisabled := strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true")
 
 		// Default OTLP protocol to gRPC when not explicitly configured.
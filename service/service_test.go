@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mountayaapp/helix.go/errorstack"
 	"github.com/mountayaapp/helix.go/internal/telemetry/log"
 	"github.com/mountayaapp/helix.go/internal/telemetry/trace"
 
@@ -698,6 +699,16 @@ func TestWithShutdownTimeout(t *testing.T) {
 	})
 }
 
+func TestWithStackTraces(t *testing.T) {
+	t.Cleanup(func() { errorstack.EnableStackTraces(false) })
+
+	newTestService(t, WithStackTraces(true))
+	assert.NotEmpty(t, errorstack.New("Failed to process").StackTrace())
+
+	newTestService(t, WithStackTraces(false))
+	assert.Empty(t, errorstack.New("Failed to process").StackTrace())
+}
+
 func TestNew_OTELSDKDisabled(t *testing.T) {
 	t.Setenv("OTEL_SDK_DISABLED", "true")
 
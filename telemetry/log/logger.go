@@ -2,8 +2,10 @@ package log
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/mountayaapp/helix.go/errorstack"
 	"github.com/mountayaapp/helix.go/internal/telemetry/log"
 
 	"go.uber.org/zap"
@@ -64,12 +66,41 @@ func Bool(key string, val bool) Field {
 
 /*
 Err constructs a Field that lazily stores the error's message under the key
-"error".
+"error". If err is an *errorstack.Error carrying a stack trace, the trace is
+stored as well under the key "error_stacktrace". The stack trace is only
+formatted when the field is emitted, so it costs nothing for entries filtered
+out by the log level.
 */
 func Err(err error) Field {
+	var stack *errorstack.Error
+	if errors.As(err, &stack) {
+		return zap.Inline(errWithStackTrace{err: err, stack: stack})
+	}
+
 	return zap.Error(err)
 }
 
+/*
+errWithStackTrace is the zapcore.ObjectMarshaler of an *errorstack.Error, inlined
+in the log entry by Err.
+*/
+type errWithStackTrace struct {
+	err   error
+	stack *errorstack.Error
+}
+
+/*
+MarshalLogObject implements zapcore.ObjectMarshaler.
+*/
+func (e errWithStackTrace) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("error", e.err.Error())
+	if stacktrace := e.stack.StackTrace(); stacktrace != "" {
+		enc.AddString("error_stacktrace", stacktrace)
+	}
+
+	return nil
+}
+
 /*
 Any constructs a Field with the given key and an arbitrary value.
 */
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mountayaapp/helix.go/errorstack"
 	internallog "github.com/mountayaapp/helix.go/internal/telemetry/log"
 
 	"github.com/stretchr/testify/assert"
@@ -128,6 +129,25 @@ func TestFieldConstructors(t *testing.T) {
 	}
 }
 
+func TestErr_StackTrace(t *testing.T) {
+	errorstack.EnableStackTraces(true)
+	t.Cleanup(func() { errorstack.EnableStackTraces(false) })
+
+	enc := zapcore.NewMapObjectEncoder()
+	Err(errorstack.New("Failed to process")).AddTo(enc)
+
+	assert.Equal(t, "INTERNAL_ERROR: Failed to process", enc.Fields["error"])
+	assert.Contains(t, enc.Fields["error_stacktrace"], "TestErr_StackTrace")
+}
+
+func TestErr_WithoutStackTrace(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	Err(errorstack.New("Failed to process")).AddTo(enc)
+
+	assert.Equal(t, "INTERNAL_ERROR: Failed to process", enc.Fields["error"])
+	assert.NotContains(t, enc.Fields, "error_stacktrace")
+}
+
 func TestPackageLevelFunctions_WithFields(t *testing.T) {
 	l := internallog.NewNopLogger()
 	ctx := internallog.ContextWithLogger(t.Context(), l)
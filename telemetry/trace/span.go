@@ -1,6 +1,10 @@
 package trace
 
 import (
+	"errors"
+
+	"github.com/mountayaapp/helix.go/errorstack"
+
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	oteltrace "go.opentelemetry.io/otel/trace"
@@ -19,6 +23,12 @@ const (
 	SpanKindConsumer = oteltrace.SpanKindConsumer
 )
 
+/*
+Link is the relationship between a Span and a Span from another Trace, or from
+an unrelated part of the same Trace. Type alias for OTEL's Link.
+*/
+type Link = oteltrace.Link
+
 /*
 Span is the individual component of a Trace. It represents a single named and
 timed operation of a workflow that is traced. Always safe to call methods on —
@@ -50,15 +60,22 @@ func (s *Span) SetAttributes(attrs ...attribute.KeyValue) {
 
 /*
 RecordError records the error as an exception span event and sets the span
-status to error.
+status to error. If err is an *errorstack.Error carrying a stack trace, the
+trace is recorded as the "exception.stacktrace" attribute of the event.
 */
 func (s *Span) RecordError(msg string, err error) {
 	if s.span == nil || err == nil {
 		return
 	}
 
+	var opts []oteltrace.EventOption
+	var stack *errorstack.Error
+	if errors.As(err, &stack) && stack.StackTrace() != "" {
+		opts = append(opts, oteltrace.WithAttributes(attribute.String("exception.stacktrace", stack.StackTrace())))
+	}
+
 	s.hasError = true
-	s.span.RecordError(err)
+	s.span.RecordError(err, opts...)
 	s.span.SetStatus(codes.Error, msg)
 }
 
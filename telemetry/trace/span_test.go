@@ -4,10 +4,15 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/mountayaapp/helix.go/errorstack"
 	internaltrace "github.com/mountayaapp/helix.go/internal/telemetry/trace"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	otelTrace "go.opentelemetry.io/otel/trace"
 )
 
@@ -154,3 +159,32 @@ func TestSpan_FullLifecycle_WithError(t *testing.T) {
 
 	assert.True(t, s.hasError)
 }
+
+func TestSpan_RecordError_StackTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	errorstack.EnableStackTraces(true)
+	t.Cleanup(func() { errorstack.EnableStackTraces(false) })
+
+	err := errorstack.Wrap(errors.New("connection refused"), "Failed to query database")
+
+	_, s := Start(t.Context(), SpanKindInternal, "query")
+	s.RecordError("query failed", err)
+	s.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events, 1)
+
+	var stacktrace string
+	for _, attr := range spans[0].Events[0].Attributes {
+		if attr.Key == "exception.stacktrace" {
+			stacktrace = attr.Value.AsString()
+		}
+	}
+
+	assert.Contains(t, stacktrace, "TestSpan_RecordError_StackTrace")
+}
@@ -19,15 +19,53 @@ Any Span that is created must also be ended. This is the responsibility of the
 caller. If no Tracer is found in the context, returns a no-op Span.
 */
 func Start(ctx context.Context, kind SpanKind, name string) (context.Context, *Span) {
+	return start(ctx, kind, name)
+}
+
+/*
+StartWithLinks is like Start, but also links the newly-created Span to the
+Links passed. Links with an invalid span context are ignored.
+
+This is useful for asynchronous consumers, such as pub/sub subscribers or stream
+readers, which must not be children of the Span that produced the message they
+handle but still need to be related to it.
+*/
+func StartWithLinks(ctx context.Context, kind SpanKind, name string, links ...Link) (context.Context, *Span) {
+	valid := make([]Link, 0, len(links))
+	for _, link := range links {
+		if link.SpanContext.IsValid() {
+			valid = append(valid, link)
+		}
+	}
+
+	if len(valid) == 0 {
+		return start(ctx, kind, name)
+	}
+
+	return start(ctx, kind, name, oteltrace.WithLinks(valid...))
+}
+
+/*
+start creates a Span with the Tracer found in the context, if any, applying the
+options passed.
+*/
+func start(ctx context.Context, kind SpanKind, name string, opts ...oteltrace.SpanStartOption) (context.Context, *Span) {
 	t := trace.TracerFromContext(ctx)
 	if t != nil {
-		ctx, span := t.Start(ctx, kind, name)
+		ctx, span := t.Start(ctx, kind, name, opts...)
 		return ctx, NewSpan(span)
 	}
 
-	// Fall back to globally registered provider set by service.New().
-	// This path skips event-to-baggage propagation (handled by the context-
-	// based tracer) but still creates proper named spans with correct status.
-	ctx, span := otel.Tracer("github.com/mountayaapp/helix.go").Start(ctx, name, oteltrace.WithSpanKind(oteltrace.SpanKind(kind)))
+	// Fall back to globally registered provider set by service.New(). The Event
+	// is propagated the same way the context-based tracer does, so it travels in
+	// the baggage and is recorded on the span whichever path is taken.
+	ctx, attrs := trace.ContextWithEventBaggage(ctx)
+
+	opts = append([]oteltrace.SpanStartOption{oteltrace.WithSpanKind(oteltrace.SpanKind(kind))}, opts...)
+	ctx, span := otel.Tracer("github.com/mountayaapp/helix.go").Start(ctx, name, opts...)
+	if len(attrs) > 0 {
+		span.SetAttributes(attrs...)
+	}
+
 	return ctx, NewSpan(span)
 }
@@ -8,7 +8,12 @@ import (
 	internaltrace "github.com/mountayaapp/helix.go/internal/telemetry/trace"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 func TestStart_PopulatesBaggageFromEvent(t *testing.T) {
@@ -375,3 +380,75 @@ func TestStart_BaggageContainsNestedFields(t *testing.T) {
 		assert.Equal(t, "false", b.Member("event.is_anonymous").Value())
 	})
 }
+
+func TestStartWithLinks(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	producer := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    oteltrace.TraceID{0x01},
+		SpanID:     oteltrace.SpanID{0x02},
+		TraceFlags: oteltrace.FlagsSampled,
+		Remote:     true,
+	})
+
+	t.Run("links valid span contexts", func(t *testing.T) {
+		exporter.Reset()
+
+		_, s := StartWithLinks(t.Context(), SpanKindConsumer, "consume", Link{SpanContext: producer})
+		s.End()
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, SpanKindConsumer, spans[0].SpanKind)
+		require.Len(t, spans[0].Links, 1)
+		assert.Equal(t, producer.TraceID(), spans[0].Links[0].SpanContext.TraceID())
+		assert.Equal(t, producer.SpanID(), spans[0].Links[0].SpanContext.SpanID())
+		assert.NotEqual(t, producer.TraceID(), spans[0].SpanContext.TraceID())
+	})
+
+	t.Run("ignores invalid span contexts", func(t *testing.T) {
+		exporter.Reset()
+
+		_, s := StartWithLinks(t.Context(), SpanKindConsumer, "consume", Link{})
+		s.End()
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Empty(t, spans[0].Links)
+	})
+}
+
+func TestStart_NoTracerInContext_PopulatesBaggageFromEvent(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	e := event.Event{
+		Name:   "subscribed",
+		UserID: "user_123",
+	}
+
+	// The fallback on the global provider propagates the Event the same way the
+	// context-based tracer does.
+	ctx, s := Start(event.ContextWithEvent(t.Context(), e), SpanKindInternal, "fallback")
+	s.End()
+
+	b := baggage.FromContext(ctx)
+	assert.Equal(t, "subscribed", b.Member("event.name").Value())
+	assert.Equal(t, "user_123", b.Member("event.user_id").Value())
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	attrs := make(map[string]string)
+	for _, attr := range spans[0].Attributes {
+		attrs[string(attr.Key)] = attr.Value.AsString()
+	}
+
+	assert.Equal(t, "subscribed", attrs["event.name"])
+	assert.Equal(t, "user_123", attrs["event.user_id"])
+}